@@ -2,12 +2,20 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"tormentus/internal/auth"
+	"tormentus/internal/backtest"
 	"tormentus/internal/database"
+	"tormentus/internal/events"
+	"tormentus/internal/export"
 	"tormentus/internal/handlers"
+	"tormentus/internal/lifecycle"
 	"tormentus/internal/middleware"
 	"tormentus/internal/repositories"
 	"tormentus/internal/services"
@@ -37,6 +45,9 @@ func (w *UserRepoWrapper) GetBalance(ctx context.Context, userID int64, isDemo b
 	return w.repo.GetBalance(ctx, userID, isDemo)
 }
 
+// shutdownTimeout plazo total del apagado ordenado tras SIGTERM/SIGINT
+const shutdownTimeout = 30 * time.Second
+
 func main() {
 	// Cargar configuración
 	cfg := config.Load()
@@ -49,7 +60,6 @@ func main() {
 	if err != nil {
 		log.Fatal("Error conectando a la base de datos", err)
 	}
-	defer db.Close()
 
 	log.Println("Conectado a PostgreSQL exitosamente")
 
@@ -63,10 +73,13 @@ func main() {
 	if err != nil {
 		log.Fatal("Error obteniendo conexión", err)
 	}
-	defer conn.Release()
 
 	log.Println("Conexión adquirida del pool")
 
+	// Contexto de los servicios en segundo plano; se cancela durante el apagado
+	appCtx, cancelApp := context.WithCancel(context.Background())
+	defer cancelApp()
+
 	// Inicializar JWT Manager
 	jwtManager := auth.NewJWTManager(
 		cfg.JWTSecret,
@@ -79,26 +92,73 @@ func main() {
 	wsHub.StartHeartbeat()
 	log.Println("WebSocket Hub iniciado")
 
-	// Inicializar servicios
-	priceService := services.NewPriceService(wsHub)
-	go priceService.Start(context.Background())
+	// Inicializar servicios (los ticks se guardan para liquidar trades tras un reinicio)
+	priceTickRepo := repositories.NewPostgresPriceTickRepository(db.Pool)
+	priceService := services.NewPriceService(wsHub, priceTickRepo)
+	// Feed de precios por símbolo (PRICE_FEED_DEFAULT y PRICE_FEED_SYMBOLS)
+	priceFeeds := map[string]services.PriceFeed{
+		services.FeedSimulated: services.NewSimulatedFeed(),
+		services.FeedBinance:   services.NewBinanceFeed(cfg.PriceFeedWSURL),
+		services.FeedREST:      services.NewRESTFeed(cfg.PriceFeedRESTURL, time.Duration(cfg.PriceFeedRESTIntervalMs)*time.Millisecond),
+	}
+	if cfg.PriceFeedReplayFile != "" {
+		priceFeeds[services.FeedReplay] = services.NewReplayFeed(cfg.PriceFeedReplayFile, cfg.PriceFeedReplaySpeed)
+	}
+	if err := priceService.UseFeeds(priceFeeds, cfg.PriceFeedDefault, cfg.PriceFeedSymbols); err != nil {
+		log.Fatal("Configuración de feeds de precios inválida:", err)
+	}
+	go priceService.Start(appCtx)
 	log.Println("Servicio de precios iniciado")
 
 	// Inicializar repositorios
 	userRepo := repositories.NewPostgresUserRepository(conn.Conn())
 	tradeRepo := repositories.NewPostgresTradeRepository(db.Pool)
+	payoutRepo := repositories.NewPostgresPayoutRepository(db.Pool)
+	riskRepo := repositories.NewPostgresRiskRepository(db.Pool)
+	calendarRepo := repositories.NewPostgresCalendarRepository(db.Pool)
+	tournamentRepo := repositories.NewPostgresTournamentRepository(db.SQL)
+	eventRepo := repositories.NewPostgresEventRepository(db.Pool)
+	exposureRepo := repositories.NewPostgresExposureRepository(db.Pool)
 	log.Println("Repositorios inicializados")
 
+	// Bus de eventos de dominio (outbox transaccional); los suscriptores se registran antes de Run
+	eventBus := events.NewBus(eventRepo)
+
 	// Crear wrapper para user repo que implemente la interfaz del trading engine
 	userRepoWrapper := &UserRepoWrapper{repo: userRepo, conn: conn.Conn()}
 
-	// Inicializar motor de trading con repositorios
-	tradingEngine := trading.NewTradingEngine(wsHub, db.Pool, tradeRepo, userRepoWrapper)
-	go tradingEngine.Start(context.Background())
+	// Inicializar motor de trading con repositorios y liquidación por precio de mercado
+	settler := trading.NewPriceSettler(priceService)
+	recoverySettler := trading.NewTickSettler(priceTickRepo, 5*time.Second)
+
+	// Exposición neta por símbolo con límites configurados por el operador
+	exposureBook := trading.NewExposureBook(exposureRepo)
+	if err := exposureBook.Refresh(appCtx); err != nil {
+		log.Printf("Error cargando límites de exposición: %v", err)
+	}
+	go exposureBook.Run(appCtx)
+
+	payoutResolver := trading.NewPayoutResolver(payoutRepo)
+	riskChecker := trading.NewRiskChecker(riskRepo)
+
+	tradingEngine := trading.NewTradingEngine(wsHub, db.Pool, tradeRepo, userRepoWrapper, eventBus, exposureBook, priceService, settler, recoverySettler)
+	priceService.OnTick(tradingEngine.OnPriceTick)
+
+	// Copy trading: los trades de los líderes se replican en las cuentas de sus seguidores
+	copyRepo := repositories.NewPostgresCopyTradingRepository(db.Pool)
+	copyMirror := trading.NewCopyMirror(copyRepo, tradingEngine, payoutResolver, riskChecker, wsHub)
+	if err := copyMirror.Load(appCtx); err != nil {
+		log.Printf("Error cargando líderes de copy trading: %v", err)
+	}
+	tradingEngine.SetCopyTrading(copyMirror)
+	tradingEngine.SetBarrierTicks(priceTickRepo)
+	// Recupera los trades pendientes antes de aceptar conexiones
+	tradingEngine.Start(appCtx)
 	log.Println("Motor de trading iniciado")
 
 	// Inicializar repositorio de wallet
 	walletRepo := repositories.NewPostgresWalletRepository(db.Pool)
+	demoRepo := repositories.NewPostgresDemoRepository(db.Pool)
 	log.Println("Repositorio de wallet inicializado")
 
 	// Inicializar repositorio de bonuses
@@ -109,6 +169,12 @@ func main() {
 	notifRepo := repositories.NewPostgresNotificationRepository(db.SQL)
 	log.Println("Repositorio de notificaciones inicializado")
 
+	// Suscriptores del bus de eventos
+	services.NewEventNotifier(notifRepo).Register(eventBus)
+	journalRepo := repositories.NewPostgresJournalRepository(db.Pool)
+	services.NewJournalPrompter(journalRepo, wsHub).Register(eventBus)
+	go eventBus.Run(appCtx)
+
 	// Inicializar repositorio de referidos
 	referralRepo := repositories.NewPostgresReferralRepository(db.SQL)
 	log.Println("Repositorio de referidos inicializado")
@@ -121,10 +187,6 @@ func main() {
 	supportRepo := repositories.NewPostgresSupportRepository(db.SQL)
 	log.Println("Repositorio de soporte inicializado")
 
-	// Inicializar repositorio de torneos
-	tournamentRepo := repositories.NewPostgresTournamentRepository(db.SQL)
-	log.Println("Repositorio de torneos inicializado")
-
 	// Inicializar repositorio de watchlist
 	watchlistRepo := repositories.NewPostgresWatchlistRepository(db.SQL)
 	log.Println("Repositorio de watchlist inicializado")
@@ -138,11 +200,55 @@ func main() {
 	log.Println("Repositorio de chart inicializado")
 
 	// Inicializar handlers
-	authHandler := handlers.NewAuthHandler(userRepo, jwtManager)
+	authHandler := handlers.NewAuthHandler(userRepo, jwtManager, cfg.DemoBalance)
 	wsHandler := handlers.NewWebSocketHandler(wsHub)
-	tradingHandler := handlers.NewTradingHandler(tradingEngine, priceService, tradeRepo, userRepoWrapper)
+	quoteBook := trading.NewQuoteBook(5 * time.Second)
+	go quoteBook.Run(appCtx)
+	marketCalendar := trading.NewMarketCalendar(calendarRepo, priceService)
+	if err := marketCalendar.Refresh(appCtx); err != nil {
+		log.Printf("Error cargando calendario de mercado: %v", err)
+	}
+	go marketCalendar.Run(appCtx)
+
+	// Órdenes de entrada: se evalúan con cada tick y se convierten en trades del motor
+	entryOrderRepo := repositories.NewPostgresEntryOrderRepository(db.Pool)
+	entryOrderBook := trading.NewEntryOrderBook(entryOrderRepo, tradingEngine, marketCalendar, riskChecker, wsHub)
+	if err := entryOrderBook.Load(appCtx); err != nil {
+		log.Printf("Error cargando órdenes de entrada pendientes: %v", err)
+	}
+	priceService.OnTick(entryOrderBook.OnPriceTick)
+	go entryOrderBook.Run(appCtx)
+	// Backtesting: reproduce price_history por WebSocket con trades simulados
+	backtestRepo := repositories.NewPostgresBacktestRepository(db.Pool)
+	backtestService := backtest.NewService(backtestRepo, wsHub, payoutResolver)
+	if err := backtestService.Start(appCtx); err != nil {
+		log.Printf("Error cerrando sesiones de backtesting abiertas: %v", err)
+	}
+	backtestHandler := handlers.NewBacktestHandler(backtestService, backtestRepo)
+	// Señales de trading: se evalúan automáticamente al cumplirse su timeframe
+	signalRepo := repositories.NewPostgresSignalRepository(db.Pool)
+	signalService := services.NewSignalService(signalRepo, priceService, priceTickRepo, 5*time.Second, wsHub)
+	if err := signalService.Load(appCtx); err != nil {
+		log.Printf("Error cargando señales activas: %v", err)
+	}
+	go signalService.Run(appCtx)
+	signalHandler := handlers.NewSignalHandler(signalRepo, signalService)
+	journalHandler := handlers.NewJournalHandler(journalRepo, tradeRepo)
+	statisticsHandler := handlers.NewStatisticsHandler(repositories.NewPostgresStatisticsRepository(db.Pool))
+	// Exportaciones de historial: los archivos se generan en segundo plano y se descargan con enlace firmado
+	exportRepo := repositories.NewPostgresExportRepository(db.Pool)
+	exportService := export.NewService(exportRepo, wsHub, cfg.ExportDir, time.Duration(cfg.ExportRetentionHours)*time.Hour, cfg.JWTSecret)
+	if err := exportService.Start(appCtx); err != nil {
+		log.Printf("Error iniciando exportaciones: %v", err)
+	}
+	go exportService.Run(appCtx)
+	tradeExportHandler := handlers.NewTradeExportHandler(exportService, exportRepo)
+	copyTradingHandler := handlers.NewCopyTradingHandler(copyRepo, copyMirror)
+	entryOrderHandler := handlers.NewEntryOrderHandler(entryOrderBook, entryOrderRepo, tradingEngine, priceService, payoutResolver, riskChecker)
+	tradingHandler := handlers.NewTradingHandler(tradingEngine, priceService, tradeRepo, userRepoWrapper, payoutResolver, quoteBook, riskChecker, marketCalendar, tournamentRepo)
 	tournamentHandler := handlers.NewTournamentDBHandler(tournamentRepo)
 	walletHandler := handlers.NewWalletHandler(walletRepo)
+	demoHandler := handlers.NewDemoHandler(demoRepo, tradingEngine, entryOrderBook, cfg.DemoBalance, time.Duration(cfg.DemoResetCooldownHours)*time.Hour)
 	profileHandler := handlers.NewProfileHandler(userRepo)
 	bonusHandler := handlers.NewBonusHandler(bonusRepo)
 	notificationHandler := handlers.NewNotificationHandler(notifRepo)
@@ -165,7 +271,7 @@ func main() {
 	r.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key")
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
 			return
@@ -240,6 +346,9 @@ func main() {
 
 		// Stats WebSocket
 		api.GET("/ws/stats", wsHandler.GetConnectionStats)
+
+		// Descarga de exportaciones (enlace firmado, sin JWT)
+		api.GET("/exports/:id/download", tradeExportHandler.Download)
 	}
 
 	// ============ RUTAS PROTEGIDAS ============
@@ -251,6 +360,10 @@ func main() {
 		protected.PUT("/profile", profileHandler.UpdateProfile)
 		protected.POST("/profile/password", profileHandler.ChangePassword)
 		protected.GET("/profile/stats", profileHandler.GetUserStats)
+		protected.GET("/profile/stats/calendar", statisticsHandler.GetCalendar)
+		protected.GET("/profile/stats/symbols", statisticsHandler.GetSymbols)
+		protected.GET("/profile/stats/hours", statisticsHandler.GetHours)
+		protected.GET("/profile/stats/streaks", statisticsHandler.GetStreaks)
 		protected.GET("/profile/settings", profileHandler.GetUserSettings)
 		protected.PUT("/profile/settings", profileHandler.UpdateUserSettings)
 
@@ -261,10 +374,52 @@ func main() {
 
 		// Trading
 		protected.POST("/trades", tradingHandler.PlaceTrade)
+		protected.POST("/trades/quote", tradingHandler.QuoteTrade)
 		protected.GET("/trades/active", tradingHandler.GetActiveTrades)
 		protected.GET("/trades/history", tradingHandler.GetTradeHistory)
 		protected.GET("/trades/stats", tradingHandler.GetTradeStats)
+		protected.POST("/trades/exports", tradeExportHandler.RequestExport)
+		protected.GET("/trades/exports", tradeExportHandler.GetExports)
+		protected.GET("/trades/exports/:id", tradeExportHandler.GetExport)
 		protected.DELETE("/trades/:id", tradingHandler.CancelTrade)
+		protected.POST("/orders", entryOrderHandler.PlaceEntryOrder)
+		protected.GET("/orders", entryOrderHandler.GetEntryOrders)
+		protected.DELETE("/orders/:id", entryOrderHandler.CancelEntryOrder)
+		protected.GET("/copy-trading/leaders", copyTradingHandler.GetLeaders)
+		protected.GET("/copy-trading/leaders/:id", copyTradingHandler.GetLeader)
+		protected.POST("/copy-trading/leaders/:id/follow", copyTradingHandler.Follow)
+		protected.DELETE("/copy-trading/leaders/:id/follow", copyTradingHandler.Unfollow)
+		protected.GET("/copy-trading/subscriptions", copyTradingHandler.GetSubscriptions)
+		protected.GET("/copy-trading/follower/stats", copyTradingHandler.GetFollowerStats)
+		protected.POST("/copy-trading/leader", copyTradingHandler.ApplyAsLeader)
+		protected.DELETE("/copy-trading/leader", copyTradingHandler.StopLeading)
+		protected.GET("/copy-trading/leader/stats", copyTradingHandler.GetLeaderStats)
+
+		// Diario de trading
+		protected.GET("/journal", journalHandler.GetEntries)
+		protected.POST("/journal", journalHandler.CreateEntry)
+		protected.GET("/journal/analytics", journalHandler.GetAnalytics)
+		protected.GET("/journal/:id", journalHandler.GetEntry)
+		protected.PUT("/journal/:id", journalHandler.UpdateEntry)
+		protected.DELETE("/journal/:id", journalHandler.DeleteEntry)
+
+		// Señales de trading
+		protected.GET("/signals", signalHandler.GetFeed)
+		protected.POST("/signals", signalHandler.PublishSignal)
+		protected.GET("/signals/:id", signalHandler.GetSignal)
+		protected.GET("/signal-providers", signalHandler.GetProviders)
+		protected.GET("/signal-providers/:id", signalHandler.GetProvider)
+		protected.POST("/signal-providers/:id/subscribe", signalHandler.Subscribe)
+		protected.DELETE("/signal-providers/:id/subscribe", signalHandler.Unsubscribe)
+		protected.GET("/signal-subscriptions", signalHandler.GetSubscriptions)
+		protected.POST("/backtesting/sessions", backtestHandler.StartSession)
+		protected.GET("/backtesting/sessions", backtestHandler.GetSessions)
+		protected.GET("/backtesting/sessions/:id", backtestHandler.GetSession)
+		protected.POST("/backtesting/sessions/:id/trades", backtestHandler.PlaceTrade)
+		protected.POST("/backtesting/sessions/:id/pause", backtestHandler.PauseSession)
+		protected.POST("/backtesting/sessions/:id/resume", backtestHandler.ResumeSession)
+		protected.POST("/backtesting/sessions/:id/stop", backtestHandler.StopSession)
+		protected.GET("/trades/:id/settlement", tradingHandler.GetTradeSettlement)
 
 		// Torneos
 		protected.POST("/tournaments/:id/join", tournamentHandler.JoinTournament)
@@ -281,6 +436,8 @@ func main() {
 		protected.POST("/wallet/withdraw", walletHandler.RequestWithdrawal)
 		protected.GET("/wallet/withdrawals", walletHandler.GetWithdrawals)
 		protected.DELETE("/wallet/withdrawals/:id", walletHandler.CancelWithdrawal)
+		protected.POST("/wallet/demo/reset", demoHandler.ResetDemoAccount)
+		protected.GET("/wallet/demo/resets", demoHandler.GetDemoResets)
 
 		// Bonuses
 		protected.GET("/bonuses", bonusHandler.GetAvailableBonuses)
@@ -438,6 +595,7 @@ func main() {
 		supportAgent.GET("/users", supportAgentDBHandler.GetUsers)
 		supportAgent.GET("/users/:id", supportAgentDBHandler.GetUserByID)
 		supportAgent.POST("/users/:id/note", supportAgentDBHandler.AddUserNote)
+		supportAgent.GET("/users/:id/trades/:tradeId/settlement", supportAgentDBHandler.GetUserTradeSettlement)
 
 		// Notifications
 		supportAgent.GET("/notifications", supportAgentDBHandler.GetNotifications)
@@ -782,6 +940,7 @@ func main() {
 	// ============ RUTAS OPERATOR (OPERADOR) ============
 	operatorRepo := repositories.NewOperatorRepository(db.Pool)
 	operatorDBHandler := handlers.NewOperatorDBHandler(operatorRepo)
+	exposureHandler := handlers.NewExposureHandler(tradingEngine)
 	operator := api.Group("/operator")
 	operator.Use(middleware.AuthMiddleware(jwtManager))
 	{
@@ -931,6 +1090,7 @@ func main() {
 		operator.GET("/monitoring/metrics/latest", operatorDBHandler.GetLatestMetrics)
 		operator.GET("/monitoring/users", operatorDBHandler.GetActiveUsersMonitor)
 		operator.GET("/monitoring/trades", operatorDBHandler.GetActiveTradesMonitor)
+		operator.GET("/monitoring/exposure", exposureHandler.GetExposure)
 		operator.GET("/monitoring/health", operatorDBHandler.GetSystemHealth)
 		operator.GET("/monitoring/realtime-alerts", operatorDBHandler.GetRealtimeAlerts)
 		operator.GET("/monitoring/thresholds", operatorDBHandler.GetMonitoringThresholds)
@@ -987,6 +1147,10 @@ func main() {
 		operator.GET("/stats/trading", operatorDBHandler.GetTradingStatsAggregate)
 		operator.GET("/stats/financial", operatorDBHandler.GetFinancialStatsAggregate)
 
+		// Señales de trading: analistas verificados
+		operator.POST("/signal-providers", signalHandler.VerifyProvider)
+		operator.DELETE("/signal-providers/:userId", signalHandler.RevokeProvider)
+
 		// Part 10: Final Features
 		operator.GET("/search-history", operatorDBHandler.GetSearchHistory)
 		operator.POST("/search-history", operatorDBHandler.SaveSearchHistory)
@@ -1014,6 +1178,38 @@ func main() {
 		operator.DELETE("/quick-responses/:id", operatorDBHandler.DeleteQuickResponse)
 	}
 
+	srv := &http.Server{Addr: ":" + cfg.ServerPort, Handler: r}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Error iniciando servidor: ", err)
+		}
+	}()
 	log.Println("Servidor iniciado en http://localhost:" + cfg.ServerPort)
-	r.Run(":" + cfg.ServerPort)
+
+	// Esperar SIGTERM/SIGINT y apagar en orden
+	sigCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	<-sigCtx.Done()
+	stopSignals()
+	log.Println("Señal de apagado recibida")
+
+	err = lifecycle.Shutdown(context.Background(), shutdownTimeout,
+		lifecycle.Step{Name: "dejar de aceptar operaciones", Run: lifecycle.Func(tradingEngine.StopAccepting)},
+		lifecycle.Step{Name: "detener órdenes de entrada", Run: entryOrderBook.Shutdown},
+		lifecycle.Step{Name: "detener copy trading", Run: copyMirror.Shutdown},
+		lifecycle.Step{Name: "liquidar cierres en curso", Run: tradingEngine.Shutdown},
+		lifecycle.Step{Name: "detener servicios en segundo plano", Run: lifecycle.Func(cancelApp)},
+		lifecycle.Step{Name: "cerrar sesiones de backtesting", Run: backtestService.Shutdown},
+		lifecycle.Step{Name: "guardar ticks pendientes", Run: priceService.FlushTicks},
+		lifecycle.Step{Name: "cerrar conexiones WebSocket", Run: wsHub.Shutdown},
+		lifecycle.Step{Name: "detener servidor HTTP", Run: srv.Shutdown},
+		lifecycle.Step{Name: "cerrar base de datos", Run: lifecycle.Func(func() {
+			conn.Release()
+			db.Close()
+		})},
+	)
+	if err != nil {
+		log.Printf("Apagado con errores: %v", err)
+		return
+	}
+	log.Println("Servidor detenido")
 }
@@ -0,0 +1,51 @@
+// Comando backfill-stats: suma a las estadísticas de usuario (diarias, por símbolo, por
+// hora y rachas) los trades cerrados antes de que se mantuvieran al liquidar. Es
+// idempotente: cada trade se registra una sola vez y puede ejecutarse con la API en marcha.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"tormentus/internal/database"
+	"tormentus/internal/repositories"
+	"tormentus/pkg/config"
+)
+
+func main() {
+	batchSize := flag.Int("batch", 500, "trades por transacción")
+	flag.Parse()
+	if *batchSize <= 0 {
+		log.Fatal("batch debe ser mayor que 0")
+	}
+
+	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		log.Fatal("Configuración inválida:", err)
+	}
+
+	db, err := database.NewDB(cfg)
+	if err != nil {
+		log.Fatal("Error conectando a la base de datos", err)
+	}
+	defer db.Close()
+
+	if err := database.RunMigrations(db.SQL, "./migrations"); err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	started := time.Now()
+	statsRepo := repositories.NewPostgresStatisticsRepository(db.Pool)
+	processed, err := statsRepo.BackfillStatistics(ctx, *batchSize)
+	if err != nil {
+		log.Fatalf("Backfill interrumpido tras %d trades: %v", processed, err)
+	}
+	log.Printf("Backfill completado: %d trades registrados en %s", processed, time.Since(started).Round(time.Millisecond))
+}
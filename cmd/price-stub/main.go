@@ -0,0 +1,53 @@
+// Comando price-stub: servidor local de precios con las mismas APIs que usan los feeds
+// binance (WebSocket /stream?streams=<symbol>@ticker) y rest (/api/v3/ticker/24hr), para
+// probar la API sin conectarse a un proveedor real. Los precios son simulados a partir de
+// los precios base de los activos; -drop y -fail-every fuerzan cortes para probar la
+// reconexión con backoff.
+//
+//	go run ./cmd/price-stub -addr :9090
+//	PRICE_FEED_WS_URL=ws://localhost:9090 PRICE_FEED_REST_URL=http://localhost:9090 \
+//	PRICE_FEED_SYMBOLS=BTC/USDT=binance,ETH/USDT=rest go run cmd/api/main.go
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"tormentus/internal/services/pricestub"
+)
+
+func main() {
+	addr := flag.String("addr", ":9090", "dirección de escucha")
+	interval := flag.Duration("interval", 500*time.Millisecond, "intervalo entre precios")
+	drop := flag.Duration("drop", 0, "cerrar cada conexión WebSocket tras este plazo (0 = nunca)")
+	failEvery := flag.Int64("fail-every", 0, "responder 503 a una de cada N consultas REST (0 = nunca)")
+	flag.Parse()
+	if *interval <= 0 {
+		log.Fatal("interval debe ser mayor que 0")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	stub := pricestub.New(pricestub.Config{Interval: *interval, Drop: *drop, FailEvery: *failEvery})
+	go stub.Run(ctx)
+
+	srv := &http.Server{Addr: *addr, Handler: stub.Handler()}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("[stub] precios en %s (%d símbolos)", *addr, stub.Symbols())
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,193 @@
+package backtest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"tormentus/internal/models"
+	"tormentus/internal/trading"
+)
+
+// Mensajes WebSocket enviados al dueño de la sesión
+const (
+	msgCandle      = "backtest_candle"       // Vela reproducida
+	msgTradeResult = "backtest_trade_result" // Trade simulado liquidado
+	msgFinished    = "backtest_finished"     // Sesión terminada (completed, stopped o interrupted)
+)
+
+// CandleUpdate vela reproducida de una sesión
+type CandleUpdate struct {
+	SessionID int64             `json:"session_id"`
+	Candle    models.CandleData `json:"candle"`
+	Index     int               `json:"index"` // Posición de la vela (desde 0)
+	Total     int               `json:"total"`
+}
+
+// TradeUpdate trade simulado liquidado en una sesión
+type TradeUpdate struct {
+	SessionID int64         `json:"session_id"`
+	Trade     *models.Trade `json:"trade"`
+	Balance   float64       `json:"balance"` // Balance simulado tras liquidar
+}
+
+// replay reproducción en curso de una sesión
+type replay struct {
+	service   *Service
+	candles   []models.CandleData
+	timeframe time.Duration
+
+	mutex   sync.Mutex
+	session models.BacktestSession
+	last    *models.PriceData // Último precio reproducido (cierre de la vela)
+	open    []*models.Trade
+	tradeNo int64
+	index   int
+
+	wake   chan struct{}      // Despierta la reproducción al reanudar o cambiar de estado
+	cancel context.CancelFunc // Detiene la reproducción (Stop)
+}
+
+// step tiempo real entre velas según la velocidad de la sesión
+func (r *replay) step() time.Duration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return time.Duration(float64(r.timeframe) / r.session.Speed)
+}
+
+// paused indica si la reproducción está en pausa
+func (r *replay) paused() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.session.Status == models.BacktestPaused
+}
+
+// notify despierta a la reproducción sin bloquear
+func (r *replay) notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run emite las velas al ritmo de la sesión y liquida los trades que vencen.
+// Al terminar (fin del rango, Stop o cancelación de ctx) cierra la sesión.
+func (r *replay) run(ctx context.Context) {
+	status := models.BacktestCompleted
+	defer func() { r.service.finish(r, status) }()
+
+	timer := time.NewTimer(r.step())
+	defer timer.Stop()
+
+	for r.index < len(r.candles) {
+		select {
+		case <-ctx.Done():
+			status = r.stoppedStatus()
+			return
+		case <-r.wake:
+			// Pausa, reanudación o cambio de estado: se reevalúa con el timer en curso
+			continue
+		case <-timer.C:
+		}
+
+		if r.paused() {
+			// En pausa se espera a la reanudación
+			select {
+			case <-ctx.Done():
+				status = r.stoppedStatus()
+				return
+			case <-r.wake:
+			}
+			timer.Reset(r.step())
+			continue
+		}
+
+		r.advance(ctx)
+		timer.Reset(r.step())
+	}
+}
+
+// stoppedStatus estado final cuando la reproducción se cancela antes de terminar
+func (r *replay) stoppedStatus() models.BacktestStatus {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.session.Status == models.BacktestStopped {
+		return models.BacktestStopped
+	}
+	return models.BacktestInterrupted
+}
+
+// advance reproduce la siguiente vela. El precio de la vela vale al cierre
+// (timestamp + timeframe), igual que un tick del feed en vivo: un trade se liquida
+// con el último precio reproducido en o antes de su vencimiento.
+func (r *replay) advance(ctx context.Context) {
+	r.mutex.Lock()
+	candle := r.candles[r.index]
+	tick := models.PriceData{
+		Symbol:    candle.Symbol,
+		Price:     candle.Close,
+		Bid:       candle.Close,
+		Ask:       candle.Close,
+		Volume:    candle.Volume,
+		Timestamp: candle.Timestamp.Add(r.timeframe),
+	}
+
+	// Vencidos antes de este cierre: precio del cierre anterior
+	var settled []*models.Trade
+	if r.last != nil {
+		settled = r.settleDue(*r.last, func(t *models.Trade) bool { return t.ExpiresAt.Before(tick.Timestamp) })
+	}
+	r.last = &tick
+	replayTime := tick.Timestamp
+	r.session.ReplayTime = &replayTime
+	// Vencidos justo en este cierre
+	settled = append(settled, r.settleDue(tick, func(t *models.Trade) bool { return !t.ExpiresAt.After(tick.Timestamp) })...)
+
+	update := CandleUpdate{SessionID: r.session.ID, Candle: candle, Index: r.index, Total: len(r.candles)}
+	r.index++
+	session := r.session
+	r.mutex.Unlock()
+
+	r.service.recordTrades(ctx, session, settled)
+	r.service.notifier.BroadcastToUser(session.UserID, msgCandle, update)
+}
+
+// settleDue liquida y retira los trades abiertos que cumplen due (requiere mutex tomado)
+func (r *replay) settleDue(tick models.PriceData, due func(*models.Trade) bool) []*models.Trade {
+	var settled []*models.Trade
+	remaining := r.open[:0]
+	for _, trade := range r.open {
+		if !due(trade) {
+			remaining = append(remaining, trade)
+			continue
+		}
+		trading.SettleAt(trade, &tick, models.PriceSourceHistory, models.RuleExpiryPrice, tick.Timestamp)
+		r.close(trade)
+		settled = append(settled, trade)
+	}
+	r.open = remaining
+	return settled
+}
+
+// refundOpen reembolsa los trades que vencen después de la última vela (requiere mutex tomado)
+func (r *replay) refundOpen(reason string) []*models.Trade {
+	closedAt := time.Now()
+	if r.last != nil {
+		closedAt = r.last.Timestamp
+	}
+	refunded := r.open
+	for _, trade := range refunded {
+		// Un reembolso no cuenta como trade realizado
+		trading.Refund(trade, closedAt, reason)
+		r.session.Balance += trading.SettlementCredit(trade)
+	}
+	r.open = nil
+	return refunded
+}
+
+// close acredita el resultado en el balance simulado (requiere mutex tomado)
+func (r *replay) close(trade *models.Trade) {
+	r.session.Balance += trading.SettlementCredit(trade)
+	r.session.ProfitLoss += trade.Profit
+	r.session.TradesMade++
+}
@@ -0,0 +1,411 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+var (
+	// ErrSessionNotFound la sesión no existe o no es del usuario
+	ErrSessionNotFound = errors.New("sesión de backtesting no encontrada")
+	// ErrSessionClosed la sesión ya terminó
+	ErrSessionClosed = errors.New("la sesión de backtesting ya terminó")
+	// ErrSessionInProgress el usuario ya tiene una sesión abierta
+	ErrSessionInProgress = errors.New("ya tienes una sesión de backtesting en curso")
+	// ErrNoPriceHistory no hay velas guardadas para el símbolo y rango
+	ErrNoPriceHistory = errors.New("sin historial de precios para el rango indicado")
+	// ErrReplayNotStarted todavía no se reprodujo ninguna vela
+	ErrReplayNotStarted = errors.New("la reproducción aún no emitió precios")
+	// ErrInsufficientBalance el balance simulado no alcanza
+	ErrInsufficientBalance = errors.New("balance simulado insuficiente")
+	// ErrInvalidParams parámetros de sesión o de trade no válidos
+	ErrInvalidParams = errors.New("parámetros de backtesting no válidos")
+)
+
+const (
+	// InitialBalance balance simulado con el que empieza cada sesión
+	InitialBalance = 10000
+	// MaxCandles velas máximas por sesión
+	MaxCandles = 20000
+	// MaxSpeed velocidad máxima de reproducción (speed DECIMAL(5,2))
+	MaxSpeed = 999
+)
+
+// Timeframes temporalidades de price_history que se pueden reproducir
+var Timeframes = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+}
+
+// Store persistencia de sesiones y velas históricas
+type Store interface {
+	GetCandles(ctx context.Context, symbol, timeframe string, from, to time.Time, limit int) ([]models.CandleData, error)
+	CreateSession(ctx context.Context, session *models.BacktestSession) error
+	GetSession(ctx context.Context, id int64) (*models.BacktestSession, error)
+	RecordTrade(ctx context.Context, session *models.BacktestSession, trade *models.Trade) error
+	UpdateSessionState(ctx context.Context, session *models.BacktestSession) error
+	InterruptOpenSessions(ctx context.Context) (int64, error)
+}
+
+// Notifier envía mensajes WebSocket a un usuario
+type Notifier interface {
+	BroadcastToUser(userID int64, msgType string, data interface{})
+}
+
+// PayoutResolver payout vigente del activo (el mismo que en trading en vivo)
+type PayoutResolver interface {
+	Resolve(ctx context.Context, userID int64, symbol string, optionType models.OptionType, duration int, amount float64) (float64, error)
+}
+
+// Service sesiones de backtesting: reproduce velas de price_history por WebSocket y
+// liquida trades simulados con las mismas reglas que el trading en vivo. El balance
+// es simulado: no toca la cuenta real ni la demo.
+type Service struct {
+	store    Store
+	notifier Notifier
+	payouts  PayoutResolver
+
+	mutex   sync.Mutex
+	ctx     context.Context
+	replays map[int64]*replay // sessionID -> reproducción en curso
+	running sync.WaitGroup    // Reproducciones que aún no cerraron su sesión
+}
+
+// NewService crea el servicio de backtesting
+func NewService(store Store, notifier Notifier, payouts PayoutResolver) *Service {
+	return &Service{
+		store:    store,
+		notifier: notifier,
+		payouts:  payouts,
+		ctx:      context.Background(),
+		replays:  make(map[int64]*replay),
+	}
+}
+
+// Start marca como interrumpidas las sesiones que quedaron abiertas en una ejecución
+// anterior. Las reproducciones se detienen al cancelar ctx.
+func (s *Service) Start(ctx context.Context) error {
+	s.mutex.Lock()
+	s.ctx = ctx
+	s.mutex.Unlock()
+
+	n, err := s.store.InterruptOpenSessions(ctx)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("[backtest] %d sesiones abiertas marcadas como interrumpidas", n)
+	}
+	return nil
+}
+
+// Shutdown interrumpe las reproducciones en curso y espera a que cierren sus sesiones
+func (s *Service) Shutdown(ctx context.Context) error {
+	s.mutex.Lock()
+	for _, r := range s.replays {
+		r.cancel()
+	}
+	s.mutex.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("sesiones de backtesting sin cerrar al apagar: %w", ctx.Err())
+	}
+}
+
+// StartSession crea una sesión y comienza a reproducir las velas del rango
+func (s *Service) StartSession(ctx context.Context, userID int64, symbol, timeframe string, from, to time.Time, speed float64) (*models.BacktestSession, error) {
+	tf, ok := Timeframes[timeframe]
+	if !ok {
+		return nil, fmt.Errorf("%w: timeframe debe ser 1m, 5m, 15m o 1h", ErrInvalidParams)
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("%w: end_date debe ser posterior a start_date", ErrInvalidParams)
+	}
+	if speed <= 0 || speed > MaxSpeed {
+		return nil, fmt.Errorf("%w: speed debe estar entre 0 y %d", ErrInvalidParams, MaxSpeed)
+	}
+	if s.userReplay(userID) != nil {
+		return nil, ErrSessionInProgress
+	}
+
+	candles, err := s.store.GetCandles(ctx, symbol, timeframe, from, to, MaxCandles)
+	if err != nil {
+		return nil, err
+	}
+	if len(candles) == 0 {
+		return nil, ErrNoPriceHistory
+	}
+
+	session := &models.BacktestSession{
+		UserID:         userID,
+		Symbol:         symbol,
+		Timeframe:      timeframe,
+		StartDate:      from,
+		EndDate:        to,
+		Speed:          speed,
+		InitialBalance: InitialBalance,
+		Balance:        InitialBalance,
+		Status:         models.BacktestActive,
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Revalidar con el mutex tomado: dos solicitudes simultáneas del mismo usuario
+	for _, r := range s.replays {
+		if r.session.UserID == userID {
+			return nil, ErrSessionInProgress
+		}
+	}
+	if err := s.store.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	replayCtx, cancel := context.WithCancel(s.ctx)
+	r := &replay{
+		service:   s,
+		candles:   candles,
+		timeframe: tf,
+		session:   *session,
+		wake:      make(chan struct{}, 1),
+		cancel:    cancel,
+	}
+	s.replays[session.ID] = r
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		r.run(replayCtx)
+	}()
+
+	log.Printf("[backtest] Sesión %d iniciada: Usuario=%d, Símbolo=%s, %d velas de %s a velocidad %.2fx",
+		session.ID, userID, symbol, len(candles), timeframe, speed)
+	return session, nil
+}
+
+// PlaceTrade abre un trade simulado high_low al último precio reproducido. Vence
+// duration segundos después en tiempo de mercado reproducido.
+func (s *Service) PlaceTrade(ctx context.Context, sessionID, userID int64, direction models.TradeDirection, amount float64, duration int) (*models.Trade, error) {
+	r, err := s.ownedReplay(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if int64(duration) < int64(r.timeframe/time.Second) {
+		return nil, fmt.Errorf("%w: duration debe ser al menos la temporalidad de la sesión (%s)", ErrInvalidParams, r.session.Timeframe)
+	}
+
+	payout, err := s.payouts.Resolve(ctx, userID, r.session.Symbol, models.OptionHighLow, duration, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.session.Status.IsOpen() {
+		return nil, ErrSessionClosed
+	}
+	if r.last == nil {
+		return nil, ErrReplayNotStarted
+	}
+	if r.session.Balance < amount {
+		return nil, ErrInsufficientBalance
+	}
+
+	r.tradeNo++
+	trade := &models.Trade{
+		ID:         r.tradeNo,
+		UserID:     userID,
+		Symbol:     r.session.Symbol,
+		Direction:  direction,
+		Amount:     amount,
+		EntryPrice: r.last.Price,
+		Duration:   duration,
+		Status:     models.TradePending,
+		Payout:     payout,
+		OptionType: models.OptionHighLow,
+		ExpiryMode: models.ExpiryDuration,
+		CreatedAt:  r.last.Timestamp,
+		ExpiresAt:  r.last.Timestamp.Add(time.Duration(duration) * time.Second),
+	}
+	r.session.Balance -= amount
+	r.open = append(r.open, trade)
+
+	placed := *trade
+	return &placed, nil
+}
+
+// Pause pausa la reproducción
+func (s *Service) Pause(ctx context.Context, sessionID, userID int64) (*models.BacktestSession, error) {
+	return s.setPaused(ctx, sessionID, userID, true)
+}
+
+// Resume reanuda una reproducción en pausa
+func (s *Service) Resume(ctx context.Context, sessionID, userID int64) (*models.BacktestSession, error) {
+	return s.setPaused(ctx, sessionID, userID, false)
+}
+
+// Stop detiene la sesión; los trades abiertos se reembolsan al balance simulado
+func (s *Service) Stop(ctx context.Context, sessionID, userID int64) error {
+	r, err := s.ownedReplay(sessionID, userID)
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	if !r.session.Status.IsOpen() {
+		r.mutex.Unlock()
+		return ErrSessionClosed
+	}
+	r.session.Status = models.BacktestStopped
+	r.mutex.Unlock()
+
+	r.cancel()
+	return nil
+}
+
+// Session estado de la sesión y trades simulados abiertos (solo si sigue en curso)
+func (s *Service) Session(ctx context.Context, sessionID, userID int64) (*models.BacktestSession, []*models.Trade, error) {
+	s.mutex.Lock()
+	r, running := s.replays[sessionID]
+	s.mutex.Unlock()
+
+	if running {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		if r.session.UserID != userID {
+			return nil, nil, ErrSessionNotFound
+		}
+		session := r.session
+		open := make([]*models.Trade, 0, len(r.open))
+		for _, trade := range r.open {
+			t := *trade
+			open = append(open, &t)
+		}
+		return &session, open, nil
+	}
+
+	session, err := s.store.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if session == nil || session.UserID != userID {
+		return nil, nil, ErrSessionNotFound
+	}
+	return session, []*models.Trade{}, nil
+}
+
+// setPaused cambia el estado de pausa y lo persiste
+func (s *Service) setPaused(ctx context.Context, sessionID, userID int64, paused bool) (*models.BacktestSession, error) {
+	r, err := s.ownedReplay(sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	if !r.session.Status.IsOpen() {
+		r.mutex.Unlock()
+		return nil, ErrSessionClosed
+	}
+	r.session.Status = models.BacktestActive
+	if paused {
+		r.session.Status = models.BacktestPaused
+	}
+	session := r.session
+	r.mutex.Unlock()
+	r.notify()
+
+	if err := s.store.UpdateSessionState(ctx, &session); err != nil {
+		log.Printf("[backtest] Error guardando estado de la sesión %d: %v", session.ID, err)
+	}
+	return &session, nil
+}
+
+// ownedReplay reproducción en curso de la sesión si pertenece al usuario
+func (s *Service) ownedReplay(sessionID, userID int64) (*replay, error) {
+	s.mutex.Lock()
+	r, running := s.replays[sessionID]
+	s.mutex.Unlock()
+
+	if !running {
+		return nil, ErrSessionClosed
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.session.UserID != userID {
+		return nil, ErrSessionNotFound
+	}
+	return r, nil
+}
+
+// userReplay reproducción en curso del usuario, o nil
+func (s *Service) userReplay(userID int64) *replay {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, r := range s.replays {
+		if r.session.UserID == userID {
+			return r
+		}
+	}
+	return nil
+}
+
+// recordTrades persiste y notifica los trades simulados liquidados
+func (s *Service) recordTrades(ctx context.Context, session models.BacktestSession, trades []*models.Trade) {
+	for _, trade := range trades {
+		if err := s.store.RecordTrade(ctx, &session, trade); err != nil {
+			log.Printf("[backtest] Error guardando trade %d de la sesión %d: %v", trade.ID, session.ID, err)
+		}
+		s.notifier.BroadcastToUser(session.UserID, msgTradeResult, TradeUpdate{
+			SessionID: session.ID,
+			Trade:     trade,
+			Balance:   session.Balance,
+		})
+	}
+}
+
+// finish cierra la sesión: reembolsa los trades abiertos, persiste el estado final
+// y notifica al usuario
+func (s *Service) finish(r *replay, status models.BacktestStatus) {
+	// El contexto de la reproducción puede estar cancelado (Stop o apagado)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	r.mutex.Lock()
+	refunded := r.refundOpen("sesión terminada antes del vencimiento")
+	finishedAt := time.Now()
+	r.session.Status = status
+	r.session.FinishedAt = &finishedAt
+	session := r.session
+	r.mutex.Unlock()
+
+	s.recordTrades(ctx, session, refunded)
+	if err := s.store.UpdateSessionState(ctx, &session); err != nil {
+		log.Printf("[backtest] Error cerrando sesión %d: %v", session.ID, err)
+	}
+
+	s.mutex.Lock()
+	delete(s.replays, session.ID)
+	s.mutex.Unlock()
+	r.cancel()
+
+	s.notifier.BroadcastToUser(session.UserID, msgFinished, session)
+	log.Printf("[backtest] Sesión %d terminada (%s): %d trades, P/L %.2f",
+		session.ID, status, session.TradesMade, session.ProfitLoss)
+}
@@ -0,0 +1,207 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// pollInterval frecuencia con la que se buscan eventos escritos por otras transacciones
+	pollInterval = 500 * time.Millisecond
+	// batchSize eventos leídos por suscriptor en cada pasada
+	batchSize = 100
+	// maxAttempts intentos antes de descartar el evento para un suscriptor (dead letter)
+	maxAttempts = 10
+	// retention antigüedad de los eventos que se eliminan del outbox
+	retention = 7 * 24 * time.Hour
+)
+
+// Handler procesa un evento. Un error provoca reintentos con backoff.
+type Handler func(ctx context.Context, event Event) error
+
+// Store outbox de eventos y estado de entrega por suscriptor
+type Store interface {
+	Append(ctx context.Context, eventType Type, payload []byte) (int64, error)
+	RegisterSubscriber(ctx context.Context, name string) error
+	Pending(ctx context.Context, subscriber string, types []Type, limit int) ([]Event, error)
+	MarkDelivered(ctx context.Context, subscriber string, eventID int64) error
+	MarkFailed(ctx context.Context, subscriber string, eventID int64, errMsg string, retryIn time.Duration, dead bool) error
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// subscription suscriptor registrado
+type subscription struct {
+	name    string
+	types   []Type
+	handler Handler
+	wake    chan struct{}
+}
+
+// Bus bus de eventos en proceso respaldado por un outbox transaccional.
+// Publish (o un insert en la misma transacción del cambio de dominio) persiste
+// el evento; cada suscriptor lo recibe al menos una vez. Un suscriptor nuevo
+// solo recibe eventos creados después de su primer registro.
+type Bus struct {
+	store Store
+
+	mutex   sync.Mutex
+	subs    []*subscription
+	started bool
+}
+
+// NewBus crea un bus sobre el outbox indicado
+func NewBus(store Store) *Bus {
+	return &Bus{store: store}
+}
+
+// Subscribe registra un handler para los tipos indicados. name identifica al
+// suscriptor en el outbox y debe ser estable entre reinicios. Debe llamarse antes de Run.
+func (b *Bus) Subscribe(name string, handler Handler, types ...Type) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.started {
+		log.Printf("[events] Suscriptor %s registrado después de Run, se ignora", name)
+		return
+	}
+	b.subs = append(b.subs, &subscription{
+		name:    name,
+		types:   types,
+		handler: handler,
+		wake:    make(chan struct{}, 1),
+	})
+}
+
+// Publish persiste un evento en el outbox y despierta a los suscriptores
+func (b *Bus) Publish(ctx context.Context, payload Payload) error {
+	eventType, data, err := Encode(payload)
+	if err != nil {
+		return fmt.Errorf("error serializando evento %s: %w", eventType, err)
+	}
+	if _, err := b.store.Append(ctx, eventType, data); err != nil {
+		return err
+	}
+	b.Notify()
+	return nil
+}
+
+// Notify despierta a los suscriptores (ej. tras confirmar una transacción con eventos)
+func (b *Bus) Notify() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, sub := range b.subs {
+		select {
+		case sub.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Run registra los suscriptores y entrega eventos hasta que se cancele el contexto
+func (b *Bus) Run(ctx context.Context) {
+	b.mutex.Lock()
+	b.started = true
+	subs := b.subs
+	b.mutex.Unlock()
+
+	for _, sub := range subs {
+		if err := b.store.RegisterSubscriber(ctx, sub.name); err != nil {
+			log.Printf("[events] Error registrando suscriptor %s: %v", sub.name, err)
+			continue
+		}
+		go b.dispatch(ctx, sub)
+	}
+
+	go b.cleanup(ctx)
+	log.Printf("[events] Bus iniciado con %d suscriptores", len(subs))
+}
+
+// dispatch entrega los eventos pendientes de un suscriptor
+func (b *Bus) dispatch(ctx context.Context, sub *subscription) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for b.deliverBatch(ctx, sub) == batchSize {
+			// Quedan más eventos pendientes
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-sub.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverBatch entrega un lote y devuelve cuántos eventos leyó
+func (b *Bus) deliverBatch(ctx context.Context, sub *subscription) int {
+	pending, err := b.store.Pending(ctx, sub.name, sub.types, batchSize)
+	if err != nil {
+		log.Printf("[events] Error leyendo eventos de %s: %v", sub.name, err)
+		return 0
+	}
+
+	for _, event := range pending {
+		if err := b.handle(ctx, sub, event); err != nil {
+			attempts := event.Attempts + 1
+			dead := attempts >= maxAttempts
+			if dead {
+				log.Printf("[events] %s descartó el evento %d (%s) tras %d intentos: %v",
+					sub.name, event.ID, event.Type, attempts, err)
+			}
+			if err := b.store.MarkFailed(ctx, sub.name, event.ID, err.Error(), backoff(attempts), dead); err != nil {
+				log.Printf("[events] Error registrando fallo de %s: %v", sub.name, err)
+			}
+			continue
+		}
+
+		if err := b.store.MarkDelivered(ctx, sub.name, event.ID); err != nil {
+			log.Printf("[events] Error confirmando entrega a %s: %v", sub.name, err)
+		}
+	}
+	return len(pending)
+}
+
+// handle ejecuta el handler convirtiendo un panic en error
+func (b *Bus) handle(ctx context.Context, sub *subscription, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return sub.handler(ctx, event)
+}
+
+// cleanup elimina periódicamente los eventos más antiguos que retention
+func (b *Bus) cleanup(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := b.store.DeleteBefore(ctx, time.Now().Add(-retention)); err != nil {
+				log.Printf("[events] Error limpiando outbox: %v", err)
+			} else if n > 0 {
+				log.Printf("[events] %d eventos antiguos eliminados", n)
+			}
+		}
+	}
+}
+
+// backoff espera exponencial entre reintentos (1s, 2s, 4s... hasta 5 minutos)
+func backoff(attempts int) time.Duration {
+	d := time.Second << (attempts - 1)
+	if d <= 0 || d > 5*time.Minute {
+		return 5 * time.Minute
+	}
+	return d
+}
@@ -0,0 +1,103 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+// Type identifica el tipo de evento de dominio
+type Type string
+
+const (
+	TradePlaced         Type = "trade.placed"
+	TradeSettled        Type = "trade.settled"
+	BalanceChanged      Type = "balance.changed"
+	DepositConfirmed    Type = "deposit.confirmed"
+	WithdrawalProcessed Type = "withdrawal.processed"
+)
+
+// Motivos de BalanceChangedEvent
+const (
+	ReasonTradePlaced  = "trade_placed"
+	ReasonTradeSettled = "trade_settled"
+	ReasonDeposit      = "deposit"
+	ReasonWithdrawal   = "withdrawal"
+	ReasonDemoReset    = "demo_reset"
+	ReasonOrderReserve = "order_reserved"    // Monto reservado por una orden de entrada
+	ReasonOrderRelease = "order_released"    // Orden vencida, cancelada o rechazada
+	ReasonProfitShare  = "copy_profit_share" // Comisión del líder sobre un trade copiado ganador
+)
+
+// Payload contenido de un evento; cada tipo de evento declara su Type
+type Payload interface {
+	EventType() Type
+}
+
+// Event evento persistido en el outbox
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      Type            `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+	Attempts  int             `json:"attempts"` // Intentos previos de entrega al suscriptor
+}
+
+// Decode deserializa el payload en v (ej. *TradeSettledEvent)
+func (e Event) Decode(v Payload) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// Encode serializa un payload para guardarlo en el outbox
+func Encode(p Payload) (Type, []byte, error) {
+	data, err := json.Marshal(p)
+	return p.EventType(), data, err
+}
+
+// TradePlacedEvent trade colocado y debitado
+type TradePlacedEvent struct {
+	Trade *models.Trade `json:"trade"`
+}
+
+func (TradePlacedEvent) EventType() Type { return TradePlaced }
+
+// TradeSettledEvent trade cerrado (won, lost, draw, sold o canceled)
+type TradeSettledEvent struct {
+	Trade  *models.Trade `json:"trade"`
+	Credit float64       `json:"credit"` // Monto acreditado al cerrar
+}
+
+func (TradeSettledEvent) EventType() Type { return TradeSettled }
+
+// BalanceChangedEvent movimiento de balance de un usuario
+type BalanceChangedEvent struct {
+	UserID int64   `json:"user_id"`
+	Amount float64 `json:"amount"` // Positivo: crédito; negativo: débito
+	IsDemo bool    `json:"is_demo"`
+	Reason string  `json:"reason"` // Ver Reason*
+	RefID  int64   `json:"ref_id"` // ID de la entidad que originó el movimiento
+}
+
+func (BalanceChangedEvent) EventType() Type { return BalanceChanged }
+
+// DepositConfirmedEvent depósito confirmado por un contador
+type DepositConfirmedEvent struct {
+	DepositID      int64   `json:"deposit_id"`
+	UserID         int64   `json:"user_id"`
+	CreditedAmount float64 `json:"credited_amount"`
+	ConfirmedBy    int64   `json:"confirmed_by"`
+}
+
+func (DepositConfirmedEvent) EventType() Type { return DepositConfirmed }
+
+// WithdrawalProcessedEvent retiro aprobado o rechazado
+type WithdrawalProcessedEvent struct {
+	WithdrawalID int64   `json:"withdrawal_id"`
+	UserID       int64   `json:"user_id"`
+	Amount       float64 `json:"amount"`
+	Status       string  `json:"status"` // approved, rejected
+	ProcessedBy  int64   `json:"processed_by"`
+}
+
+func (WithdrawalProcessedEvent) EventType() Type { return WithdrawalProcessed }
@@ -0,0 +1,29 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// csvWriter historial en CSV (separado por comas, UTF-8)
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) WriteRow(values []any) error {
+	c.record = c.record[:0]
+	for _, value := range values {
+		c.record = append(c.record, formatValue(value))
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
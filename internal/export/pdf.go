@@ -0,0 +1,175 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Página A4 horizontal en puntos; tabla en Courier para alinear columnas sin métricas
+const (
+	pdfPageWidth   = 842
+	pdfPageHeight  = 595
+	pdfMargin      = 36
+	pdfFontSize    = 8
+	pdfLeading     = 11
+	pdfRowsPerPage = 44
+)
+
+// pdfColumnWidths ancho en caracteres de cada columna de columns
+var pdfColumnWidths = []int{8, 19, 19, 6, 10, 9, 9, 10, 14, 14, 8, 8, 12}
+
+// Objetos fijos: 1 catálogo, 2 árbol de páginas (se escribe al cerrar), 3 y 4 fuentes.
+// Cada página usa dos objetos más (contenido y página).
+const (
+	pdfCatalogObj   = 1
+	pdfPagesObj     = 2
+	pdfFontObj      = 3
+	pdfBoldFontObj  = 4
+	pdfFirstPageObj = 5
+)
+
+// pdfWriter historial en PDF: una tabla de texto por página con el título del reporte.
+// Las páginas se escriben a medida que se llenan.
+type pdfWriter struct {
+	w       *countingWriter
+	title   string
+	header  string
+	lines   []string
+	offsets map[int]int64 // objeto -> posición en el archivo
+	pages   []int         // objetos de página
+	nextObj int
+}
+
+func newPDFWriter(w io.Writer, title string) *pdfWriter {
+	p := &pdfWriter{
+		w:       &countingWriter{w: w},
+		title:   title,
+		offsets: make(map[int]int64),
+		nextObj: pdfFirstPageObj,
+	}
+	p.w.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	p.writeObject(pdfCatalogObj, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pdfPagesObj))
+	p.writeObject(pdfFontObj, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	p.writeObject(pdfBoldFontObj, "<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>")
+	return p
+}
+
+func (p *pdfWriter) WriteRow(values []any) error {
+	cells := make([]string, len(values))
+	for i, value := range values {
+		width := 12
+		if i < len(pdfColumnWidths) {
+			width = pdfColumnWidths[i]
+		}
+		cells[i] = fitCell(formatValue(value), width)
+	}
+	line := strings.TrimRight(strings.Join(cells, " "), " ")
+
+	// La primera fila es el encabezado: se repite en cada página
+	if p.header == "" {
+		p.header = line
+		return p.w.err
+	}
+	p.lines = append(p.lines, line)
+	if len(p.lines) == pdfRowsPerPage {
+		p.flushPage()
+	}
+	return p.w.err
+}
+
+func (p *pdfWriter) Close() error {
+	if len(p.pages) == 0 && len(p.lines) == 0 {
+		p.lines = append(p.lines, "Sin trades para los filtros indicados")
+	}
+	if len(p.lines) > 0 {
+		p.flushPage()
+	}
+
+	kids := make([]string, len(p.pages))
+	for i, page := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", page)
+	}
+	p.writeObject(pdfPagesObj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages)))
+
+	xref := p.w.n
+	size := p.nextObj
+	p.w.WriteString(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", size))
+	for obj := 1; obj < size; obj++ {
+		p.w.WriteString(fmt.Sprintf("%010d 00000 n \n", p.offsets[obj]))
+	}
+	p.w.WriteString(fmt.Sprintf("trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", size, pdfCatalogObj, xref))
+	return p.w.err
+}
+
+// flushPage escribe la página con las filas acumuladas
+func (p *pdfWriter) flushPage() {
+	var content bytes.Buffer
+	top := pdfPageHeight - pdfMargin
+	fmt.Fprintf(&content, "BT /F2 11 Tf %d %d Td (%s) Tj ET\n", pdfMargin, top-11, pdfEscape(p.title))
+	fmt.Fprintf(&content, "BT /F2 %d Tf %d TL %d %d Td (%s) Tj ET\n", pdfFontSize, pdfLeading, pdfMargin, top-32, pdfEscape(p.header))
+	fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td", pdfFontSize, pdfLeading, pdfMargin, top-32)
+	for _, line := range p.lines {
+		fmt.Fprintf(&content, " T* (%s) Tj", pdfEscape(line))
+	}
+	content.WriteString(" ET\n")
+	fmt.Fprintf(&content, "BT /F1 %d Tf %d %d Td (Página %d) Tj ET\n", pdfFontSize, pdfPageWidth-pdfMargin-60, pdfMargin/2, len(p.pages)+1)
+
+	contentObj, pageObj := p.nextObj, p.nextObj+1
+	p.nextObj += 2
+	p.writeObject(contentObj, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	p.writeObject(pageObj, fmt.Sprintf(
+		"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 %d 0 R /F2 %d 0 R >> >> /Contents %d 0 R >>",
+		pdfPagesObj, pdfPageWidth, pdfPageHeight, pdfFontObj, pdfBoldFontObj, contentObj))
+	p.pages = append(p.pages, pageObj)
+	p.lines = p.lines[:0]
+}
+
+func (p *pdfWriter) writeObject(obj int, body string) {
+	p.offsets[obj] = p.w.n
+	p.w.WriteString(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", obj, body))
+}
+
+// fitCell rellena o recorta el texto al ancho de la columna
+func fitCell(s string, width int) string {
+	n := utf8.RuneCountInString(s)
+	if n > width {
+		return string([]rune(s)[:width])
+	}
+	return s + strings.Repeat(" ", width-n)
+}
+
+// pdfEscape convierte el texto a WinAnsi (Latin-1) y escapa los delimitadores de cadena
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// countingWriter cuenta los bytes escritos (posiciones del xref) y conserva el primer error
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) WriteString(s string) {
+	if c.err != nil {
+		return
+	}
+	n, err := io.WriteString(c.w, s)
+	c.n += int64(n)
+	c.err = err
+}
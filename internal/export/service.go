@@ -0,0 +1,314 @@
+package export
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+var (
+	// ErrTooManyExports el usuario ya tiene el máximo de exportaciones en cola
+	ErrTooManyExports = errors.New("ya tienes exportaciones en curso; espera a que terminen")
+	// ErrInvalidLink el enlace de descarga no es válido, venció o el archivo ya no existe
+	ErrInvalidLink = errors.New("enlace de descarga inválido o vencido")
+)
+
+const (
+	// MaxActiveExports exportaciones en cola o en proceso por usuario
+	MaxActiveExports = 3
+	// DownloadLinkTTL validez de un enlace de descarga firmado (nunca más allá de la
+	// expiración del archivo)
+	DownloadLinkTTL = 15 * time.Minute
+
+	pollInterval   = 5 * time.Second
+	expireInterval = time.Minute
+
+	// msgTradeExport mensaje WebSocket al terminar una exportación (ready o failed)
+	msgTradeExport = "trade_export"
+)
+
+// Store persistencia de las exportaciones y lectura de los trades a exportar
+type Store interface {
+	CreateExport(ctx context.Context, export *models.TradeExport) error
+	GetExport(ctx context.Context, id int64) (*models.TradeExport, error)
+	CountActiveExports(ctx context.Context, userID int64) (int, error)
+	ClaimPendingExport(ctx context.Context) (*models.TradeExport, error)
+	RequeueProcessingExports(ctx context.Context) (int64, error)
+	StreamExportTrades(ctx context.Context, export *models.TradeExport, fn func(*models.Trade) error) error
+	CompleteExport(ctx context.Context, export *models.TradeExport, retention time.Duration) error
+	FailExport(ctx context.Context, id int64, message string) error
+	ExpireExports(ctx context.Context) ([]*models.TradeExport, error)
+}
+
+// Notifier envía mensajes WebSocket a un usuario
+type Notifier interface {
+	BroadcastToUser(userID int64, msgType string, data interface{})
+}
+
+// Service genera en segundo plano los archivos de historial pedidos por los usuarios,
+// los guarda en dir durante retention y firma los enlaces de descarga
+type Service struct {
+	store     Store
+	notifier  Notifier
+	dir       string
+	retention time.Duration
+	secret    []byte
+	wake      chan struct{}
+}
+
+// NewService crea el servicio de exportaciones; secret firma los enlaces de descarga
+func NewService(store Store, notifier Notifier, dir string, retention time.Duration, secret string) *Service {
+	return &Service{
+		store:     store,
+		notifier:  notifier,
+		dir:       dir,
+		retention: retention,
+		secret:    []byte(secret),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Start crea el directorio de archivos y devuelve a la cola las exportaciones que
+// quedaron a medias en una ejecución anterior
+func (s *Service) Start(ctx context.Context) error {
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return fmt.Errorf("error creando directorio de exportaciones: %w", err)
+	}
+	n, err := s.store.RequeueProcessingExports(ctx)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("[export] %d exportaciones interrumpidas vuelven a la cola", n)
+	}
+	return nil
+}
+
+// Run procesa la cola hasta que se cancele ctx y elimina los archivos vencidos
+func (s *Service) Run(ctx context.Context) {
+	poll := time.NewTicker(pollInterval)
+	defer poll.Stop()
+	expire := time.NewTicker(expireInterval)
+	defer expire.Stop()
+
+	s.expire(ctx)
+	for {
+		s.processQueue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-poll.C:
+		case <-expire.C:
+			s.expire(ctx)
+		}
+	}
+}
+
+// Request encola una exportación del usuario
+func (s *Service) Request(ctx context.Context, export *models.TradeExport) error {
+	active, err := s.store.CountActiveExports(ctx, export.UserID)
+	if err != nil {
+		return err
+	}
+	if active >= MaxActiveExports {
+		return ErrTooManyExports
+	}
+
+	export.Status = models.ExportPending
+	if err := s.store.CreateExport(ctx, export); err != nil {
+		return err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// SignDownload asigna a una exportación lista su enlace de descarga firmado
+func (s *Service) SignDownload(export *models.TradeExport) {
+	if export.Status != models.ExportReady || export.ExpiresAt == nil {
+		return
+	}
+	expiresAt := time.Now().Add(DownloadLinkTTL).Truncate(time.Second)
+	if export.ExpiresAt.Before(expiresAt) {
+		expiresAt = *export.ExpiresAt
+	}
+	export.DownloadURL = fmt.Sprintf("/api/exports/%d/download?expires=%d&signature=%s",
+		export.ID, expiresAt.Unix(), s.sign(export.ID, expiresAt.Unix()))
+	export.DownloadExpiresAt = &expiresAt
+}
+
+// OpenDownload valida el enlace firmado y devuelve la exportación con su archivo
+func (s *Service) OpenDownload(ctx context.Context, id int64, expires int64, signature string) (*models.TradeExport, error) {
+	if !hmac.Equal([]byte(signature), []byte(s.sign(id, expires))) || time.Now().Unix() > expires {
+		return nil, ErrInvalidLink
+	}
+
+	export, err := s.store.GetExport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if export == nil || export.Status != models.ExportReady {
+		return nil, ErrInvalidLink
+	}
+	if _, err := os.Stat(export.FilePath); err != nil {
+		return nil, ErrInvalidLink
+	}
+	return export, nil
+}
+
+// Filename nombre con el que se descarga el archivo
+func Filename(export *models.TradeExport) string {
+	return fmt.Sprintf("historial-trades-%d.%s", export.ID, export.Format)
+}
+
+func (s *Service) sign(id, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("trade-export:" + strconv.FormatInt(id, 10) + ":" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// processQueue genera las exportaciones en cola, de a una
+func (s *Service) processQueue(ctx context.Context) {
+	for ctx.Err() == nil {
+		export, err := s.store.ClaimPendingExport(ctx)
+		if err != nil {
+			log.Printf("[export] Error tomando exportación de la cola: %v", err)
+			return
+		}
+		if export == nil {
+			return
+		}
+		s.generate(ctx, export)
+	}
+}
+
+// generate escribe el archivo de la exportación y la marca como lista o fallida. Si se
+// interrumpe por el apagado queda en processing y se reintenta al reiniciar.
+func (s *Service) generate(ctx context.Context, export *models.TradeExport) {
+	err := s.writeFile(ctx, export)
+	if err == nil {
+		if err = s.store.CompleteExport(ctx, export, s.retention); err != nil {
+			os.Remove(export.FilePath)
+		}
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("[export] Error generando exportación %d: %v", export.ID, err)
+		if err := s.store.FailExport(ctx, export.ID, "No se pudo generar el archivo"); err != nil {
+			log.Printf("[export] Error marcando exportación %d como fallida: %v", export.ID, err)
+			return
+		}
+		export.Status = models.ExportFailed
+		export.Error = "No se pudo generar el archivo"
+	} else {
+		export.Status = models.ExportReady
+		s.SignDownload(export)
+	}
+
+	s.notifier.BroadcastToUser(export.UserID, msgTradeExport, export)
+}
+
+// writeFile genera el archivo en un temporal y lo renombra al terminar
+func (s *Service) writeFile(ctx context.Context, export *models.TradeExport) error {
+	dir := filepath.Join(s.dir, strconv.FormatInt(export.UserID, 10))
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, Filename(export))
+	tmp := path + ".tmp"
+
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	defer file.Close()
+
+	tw, err := newTableWriter(export.Format, file, reportTitle(export))
+	if err != nil {
+		return err
+	}
+	export.RowsCount = 0
+	err = s.store.StreamExportTrades(ctx, export, func(trade *models.Trade) error {
+		export.RowsCount++
+		return tw.WriteRow(tradeRow(trade))
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(tmp)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	export.FilePath = path
+	export.FileSize = info.Size()
+	return nil
+}
+
+// expire elimina los archivos de las exportaciones vencidas
+func (s *Service) expire(ctx context.Context) {
+	exports, err := s.store.ExpireExports(ctx)
+	if err != nil {
+		log.Printf("[export] Error expirando exportaciones: %v", err)
+		return
+	}
+	for _, export := range exports {
+		if export.FilePath == "" {
+			continue
+		}
+		if err := os.Remove(export.FilePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("[export] Error eliminando %s: %v", export.FilePath, err)
+		}
+	}
+	if len(exports) > 0 {
+		log.Printf("[export] %d exportaciones expiradas", len(exports))
+	}
+}
+
+// reportTitle título del PDF con la cuenta y los filtros
+func reportTitle(export *models.TradeExport) string {
+	parts := []string{"Historial de trades", "cuenta real"}
+	if export.IsDemo {
+		parts[1] = "cuenta demo"
+	}
+	if export.Symbol != "" {
+		parts = append(parts, export.Symbol)
+	}
+	switch {
+	case export.DateFrom != "" && export.DateTo != "":
+		parts = append(parts, export.DateFrom+" a "+export.DateTo)
+	case export.DateFrom != "":
+		parts = append(parts, "desde "+export.DateFrom)
+	case export.DateTo != "":
+		parts = append(parts, "hasta "+export.DateTo)
+	}
+	return strings.Join(parts, " - ")
+}
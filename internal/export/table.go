@@ -0,0 +1,93 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+// tableWriter escribe el historial fila por fila; Close completa el archivo
+type tableWriter interface {
+	WriteRow(values []any) error
+	Close() error
+}
+
+// columns encabezados del historial exportado (en el orden de tradeRow)
+var columns = []string{
+	"ID", "Apertura", "Cierre", "Cuenta", "Símbolo", "Tipo", "Dirección",
+	"Monto", "Precio entrada", "Precio salida", "Payout %", "Estado", "Resultado",
+}
+
+// dateTimeLayout formato de las fechas exportadas
+const dateTimeLayout = "2006-01-02 15:04:05"
+
+// newTableWriter crea el escritor del formato y escribe los encabezados. title solo se
+// usa en PDF.
+func newTableWriter(format models.ExportFormat, w io.Writer, title string) (tableWriter, error) {
+	var tw tableWriter
+	switch format {
+	case models.ExportCSV:
+		tw = newCSVWriter(w)
+	case models.ExportXLSX:
+		tw = newXLSXWriter(w)
+	case models.ExportPDF:
+		tw = newPDFWriter(w, title)
+	default:
+		return nil, fmt.Errorf("formato de exportación no soportado: %s", format)
+	}
+
+	header := make([]any, len(columns))
+	for i, column := range columns {
+		header[i] = column
+	}
+	if err := tw.WriteRow(header); err != nil {
+		return nil, err
+	}
+	return tw, nil
+}
+
+// tradeRow valores de un trade: string, int64 o float64
+func tradeRow(trade *models.Trade) []any {
+	account := "real"
+	if trade.IsDemo {
+		account = "demo"
+	}
+	closedAt := ""
+	if trade.ClosedAt != nil {
+		closedAt = trade.ClosedAt.Format(dateTimeLayout)
+	}
+	return []any{
+		trade.ID,
+		trade.CreatedAt.Format(dateTimeLayout),
+		closedAt,
+		account,
+		trade.Symbol,
+		string(trade.OptionType),
+		string(trade.Direction),
+		trade.Amount,
+		trade.EntryPrice,
+		trade.ExitPrice,
+		trade.Payout,
+		string(trade.Status),
+		trade.Profit,
+	}
+}
+
+// formatValue representación de texto de un valor de tradeRow
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(dateTimeLayout)
+	default:
+		return fmt.Sprint(v)
+	}
+}
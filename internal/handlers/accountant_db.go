@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -10,6 +11,15 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// respondProcessError responde el error de procesar una solicitud de retiro o depósito
+func respondProcessError(c *gin.Context, err error, message string) {
+	if errors.Is(err, repositories.ErrRequestAlreadyProcessed) {
+		c.JSON(http.StatusConflict, gin.H{"error": "La solicitud ya fue procesada", "code": "ALREADY_PROCESSED"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
+
 // AccountantDBHandler maneja las peticiones del contador
 type AccountantDBHandler struct {
 	repo *repositories.AccountantRepository
@@ -91,7 +101,7 @@ func (h *AccountantDBHandler) ApproveWithdrawal(c *gin.Context) {
 
 	accountantID := h.getAccountantID(c)
 	if err := h.repo.ApproveWithdrawal(c.Request.Context(), id, accountantID, req.TxHash, req.Notes); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error aprobando retiro"})
+		respondProcessError(c, err, "Error aprobando retiro")
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Retiro aprobado"})
@@ -115,7 +125,7 @@ func (h *AccountantDBHandler) RejectWithdrawal(c *gin.Context) {
 
 	accountantID := h.getAccountantID(c)
 	if err := h.repo.RejectWithdrawal(c.Request.Context(), id, accountantID, req.Reason); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error rechazando retiro"})
+		respondProcessError(c, err, "Error rechazando retiro")
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Retiro rechazado"})
@@ -155,7 +165,7 @@ func (h *AccountantDBHandler) ConfirmDeposit(c *gin.Context) {
 
 	accountantID := h.getAccountantID(c)
 	if err := h.repo.ConfirmDeposit(c.Request.Context(), id, accountantID, req.CreditedAmount, req.Notes); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error confirmando depósito"})
+		respondProcessError(c, err, "Error confirmando depósito")
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Depósito confirmado"})
@@ -11,14 +11,16 @@ import (
 )
 
 type AuthHandler struct {
-	userRepo   repositories.UserRepository
-	jwtManager *auth.JWTManager
+	userRepo    repositories.UserRepository
+	jwtManager  *auth.JWTManager
+	demoBalance float64 // Balance demo inicial
 }
 
-func NewAuthHandler(userRepo repositories.UserRepository, jwtManager *auth.JWTManager) *AuthHandler {
+func NewAuthHandler(userRepo repositories.UserRepository, jwtManager *auth.JWTManager, demoBalance float64) *AuthHandler {
 	return &AuthHandler{
-		userRepo:   userRepo,
-		jwtManager: jwtManager,
+		userRepo:    userRepo,
+		jwtManager:  jwtManager,
+		demoBalance: demoBalance,
 	}
 }
 
@@ -115,7 +117,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		LastName:           req.LastName,
 		Role:               models.RoleUser,
 		Balance:            0,
-		DemoBalance:        10000,
+		DemoBalance:        h.demoBalance,
 		IsVerified:         false,
 		VerificationStatus: models.VerificationPending,
 	}
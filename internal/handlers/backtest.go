@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"tormentus/internal/backtest"
+	"tormentus/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BacktestRepository consultas de sesiones de backtesting
+type BacktestRepository interface {
+	GetUserSessions(ctx context.Context, userID int64, limit, offset int) ([]*models.BacktestSession, error)
+	GetSessionTrades(ctx context.Context, sessionID int64) ([]*models.Trade, error)
+}
+
+// BacktestHandler maneja las sesiones de backtesting
+type BacktestHandler struct {
+	service *backtest.Service
+	repo    BacktestRepository
+}
+
+// NewBacktestHandler crea un nuevo handler de backtesting
+func NewBacktestHandler(service *backtest.Service, repo BacktestRepository) *BacktestHandler {
+	return &BacktestHandler{
+		service: service,
+		repo:    repo,
+	}
+}
+
+// StartBacktestRequest request para iniciar una sesión de backtesting
+type StartBacktestRequest struct {
+	Symbol    string    `json:"symbol" binding:"required"`
+	Timeframe string    `json:"timeframe" binding:"required,oneof=1m 5m 15m 1h"`
+	StartDate time.Time `json:"start_date" binding:"required"`
+	EndDate   time.Time `json:"end_date" binding:"required"`
+	Speed     float64   `json:"speed" binding:"omitempty,gt=0,max=999"` // Velas de mercado por vela real; por defecto 1
+}
+
+// BacktestTradeRequest request para abrir un trade simulado
+type BacktestTradeRequest struct {
+	Direction string  `json:"direction" binding:"required,oneof=up down"`
+	Amount    float64 `json:"amount" binding:"required,gt=0"`
+	Duration  int     `json:"duration" binding:"required,min=60,max=86400"` // Segundos de mercado reproducido
+}
+
+// respondBacktestError traduce los errores del servicio de backtesting
+func respondBacktestError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, backtest.ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sesión no encontrada", "code": "BACKTEST_NOT_FOUND"})
+	case errors.Is(err, backtest.ErrSessionClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "BACKTEST_CLOSED"})
+	case errors.Is(err, backtest.ErrSessionInProgress):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "BACKTEST_IN_PROGRESS"})
+	case errors.Is(err, backtest.ErrNoPriceHistory):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "code": "NO_PRICE_HISTORY"})
+	case errors.Is(err, backtest.ErrReplayNotStarted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "REPLAY_NOT_STARTED"})
+	case errors.Is(err, backtest.ErrInsufficientBalance):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Balance insuficiente", "code": "INSUFFICIENT_BALANCE"})
+	case errors.Is(err, backtest.ErrInvalidParams):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "INVALID_PARAMS"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// backtestSessionParams usuario autenticado e ID de sesión de la ruta
+func backtestSessionParams(c *gin.Context) (userID, sessionID int64, ok bool) {
+	id, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return 0, 0, false
+	}
+	sessionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de sesión inválido"})
+		return 0, 0, false
+	}
+	return id.(int64), sessionID, true
+}
+
+// StartSession inicia una sesión y comienza la reproducción por WebSocket
+func (h *BacktestHandler) StartSession(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var req StartBacktestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos", "details": err.Error()})
+		return
+	}
+	if req.Speed == 0 {
+		req.Speed = 1
+	}
+
+	session, err := h.service.StartSession(c.Request.Context(), userID.(int64), req.Symbol, req.Timeframe,
+		req.StartDate, req.EndDate, req.Speed)
+	if err != nil {
+		respondBacktestError(c, err, "Error iniciando sesión de backtesting")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Sesión de backtesting iniciada",
+		"session": session,
+	})
+}
+
+// GetSessions lista las sesiones del usuario
+func (h *BacktestHandler) GetSessions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	sessions, err := h.repo.GetUserSessions(c.Request.Context(), userID.(int64), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo sesiones"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// GetSession estado de la sesión con sus trades liquidados y abiertos
+func (h *BacktestHandler) GetSession(c *gin.Context) {
+	userID, sessionID, ok := backtestSessionParams(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+
+	session, open, err := h.service.Session(ctx, sessionID, userID)
+	if err != nil {
+		respondBacktestError(c, err, "Error obteniendo sesión")
+		return
+	}
+	settled, err := h.repo.GetSessionTrades(ctx, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo trades de la sesión"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session":     session,
+		"trades":      settled,
+		"open_trades": open,
+	})
+}
+
+// PlaceTrade abre un trade simulado al último precio reproducido
+func (h *BacktestHandler) PlaceTrade(c *gin.Context) {
+	userID, sessionID, ok := backtestSessionParams(c)
+	if !ok {
+		return
+	}
+
+	var req BacktestTradeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos", "details": err.Error()})
+		return
+	}
+
+	trade, err := h.service.PlaceTrade(c.Request.Context(), sessionID, userID,
+		models.TradeDirection(req.Direction), req.Amount, req.Duration)
+	if err != nil {
+		respondBacktestError(c, err, "Error abriendo trade simulado")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Trade simulado abierto",
+		"trade":   trade,
+	})
+}
+
+// PauseSession pausa la reproducción
+func (h *BacktestHandler) PauseSession(c *gin.Context) {
+	userID, sessionID, ok := backtestSessionParams(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.service.Pause(c.Request.Context(), sessionID, userID)
+	if err != nil {
+		respondBacktestError(c, err, "Error pausando sesión")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session": session})
+}
+
+// ResumeSession reanuda la reproducción
+func (h *BacktestHandler) ResumeSession(c *gin.Context) {
+	userID, sessionID, ok := backtestSessionParams(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.service.Resume(c.Request.Context(), sessionID, userID)
+	if err != nil {
+		respondBacktestError(c, err, "Error reanudando sesión")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"session": session})
+}
+
+// StopSession detiene la sesión; los trades abiertos se reembolsan
+func (h *BacktestHandler) StopSession(c *gin.Context) {
+	userID, sessionID, ok := backtestSessionParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Stop(c.Request.Context(), sessionID, userID); err != nil {
+		respondBacktestError(c, err, "Error deteniendo sesión")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Sesión de backtesting detenida"})
+}
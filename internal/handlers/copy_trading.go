@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"tormentus/internal/models"
+	"tormentus/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultMinCopyAmount monto mínimo por trade copiado si el líder no lo indica
+const defaultMinCopyAmount = 100
+
+// CopyTradingRepository persistencia de copy trading
+type CopyTradingRepository interface {
+	UpsertLeader(ctx context.Context, trader *models.CopyTrader) error
+	DeactivateLeader(ctx context.Context, userID int64) (bool, error)
+	GetLeader(ctx context.Context, id int64) (*models.CopyTrader, error)
+	GetLeaders(ctx context.Context, limit, offset int) ([]*models.CopyTrader, error)
+	Follow(ctx context.Context, rel *models.CopyRelationship) error
+	Unfollow(ctx context.Context, copierID, traderID int64) (bool, error)
+	GetUserRelationships(ctx context.Context, copierID int64) ([]*models.CopyRelationship, error)
+	GetLeaderStats(ctx context.Context, userID int64) (*models.CopyLeaderStats, error)
+	GetFollowerStats(ctx context.Context, copierID int64) (*models.CopyFollowerStats, error)
+}
+
+// CopyLeaderRegistry activa o desactiva la réplica de los trades de un líder
+type CopyLeaderRegistry interface {
+	SetLeader(userID int64, active bool)
+}
+
+// CopyTradingHandler maneja líderes y seguidores de copy trading
+type CopyTradingHandler struct {
+	repo    CopyTradingRepository
+	leaders CopyLeaderRegistry
+}
+
+// NewCopyTradingHandler crea un nuevo handler de copy trading
+func NewCopyTradingHandler(repo CopyTradingRepository, leaders CopyLeaderRegistry) *CopyTradingHandler {
+	return &CopyTradingHandler{
+		repo:    repo,
+		leaders: leaders,
+	}
+}
+
+// CopyLeaderRequest request para postularse (o actualizar el perfil) como líder
+type CopyLeaderRequest struct {
+	DisplayName   string  `json:"display_name" binding:"required,max=100"`
+	Bio           string  `json:"bio" binding:"max=1000"`
+	AvatarURL     string  `json:"avatar_url" binding:"omitempty,url,max=500"`
+	ProfitShare   float64 `json:"profit_share" binding:"min=0,max=50"`      // % de la ganancia de los seguidores
+	MinCopyAmount float64 `json:"min_copy_amount" binding:"omitempty,gt=0"` // Por defecto 100
+}
+
+// FollowRequest request para copiar a un líder
+type FollowRequest struct {
+	CopyMode       string  `json:"copy_mode" binding:"required,oneof=fixed percentage"`
+	CopyAmount     float64 `json:"copy_amount" binding:"omitempty,gt=0"`             // Modo fixed: monto por trade
+	CopyPercentage float64 `json:"copy_percentage" binding:"omitempty,gt=0,max=500"` // Modo percentage: % del monto del líder
+}
+
+// ApplyAsLeader crea o actualiza el perfil de líder del usuario y activa la réplica de sus trades
+func (h *CopyTradingHandler) ApplyAsLeader(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var req CopyLeaderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos", "details": err.Error()})
+		return
+	}
+	if req.MinCopyAmount == 0 {
+		req.MinCopyAmount = defaultMinCopyAmount
+	}
+
+	trader := &models.CopyTrader{
+		UserID:        userID.(int64),
+		DisplayName:   req.DisplayName,
+		Bio:           req.Bio,
+		AvatarURL:     req.AvatarURL,
+		ProfitShare:   req.ProfitShare,
+		MinCopyAmount: req.MinCopyAmount,
+	}
+	if err := h.repo.UpsertLeader(c.Request.Context(), trader); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error guardando perfil de líder"})
+		return
+	}
+	h.leaders.SetLeader(trader.UserID, true)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Perfil de líder activo",
+		"trader":  trader,
+	})
+}
+
+// StopLeading desactiva el perfil de líder: sus trades dejan de copiarse
+func (h *CopyTradingHandler) StopLeading(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	deactivated, err := h.repo.DeactivateLeader(c.Request.Context(), userID.(int64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error desactivando perfil de líder"})
+		return
+	}
+	if !deactivated {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No tienes un perfil de líder activo", "code": "NOT_A_LEADER"})
+		return
+	}
+	h.leaders.SetLeader(userID.(int64), false)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Perfil de líder desactivado"})
+}
+
+// GetLeaders lista los líderes activos ordenados por ganancia
+func (h *CopyTradingHandler) GetLeaders(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	leaders, err := h.repo.GetLeaders(c.Request.Context(), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo líderes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"leaders": leaders})
+}
+
+// GetLeader perfil y estadísticas de un líder
+func (h *CopyTradingHandler) GetLeader(c *gin.Context) {
+	traderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de líder inválido"})
+		return
+	}
+
+	leader, err := h.repo.GetLeader(c.Request.Context(), traderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo líder"})
+		return
+	}
+	if leader == nil || !leader.IsActive {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Líder no encontrado", "code": "LEADER_NOT_FOUND"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"leader": leader})
+}
+
+// Follow suscribe al usuario a un líder con un monto fijo o un porcentaje del monto
+// del líder por trade. Volver a llamarlo actualiza los montos.
+func (h *CopyTradingHandler) Follow(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	traderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de líder inválido"})
+		return
+	}
+
+	var req FollowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos", "details": err.Error()})
+		return
+	}
+	ctx := c.Request.Context()
+
+	leader, err := h.repo.GetLeader(ctx, traderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo líder"})
+		return
+	}
+	if leader == nil || !leader.IsActive {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Líder no encontrado", "code": "LEADER_NOT_FOUND"})
+		return
+	}
+	if leader.UserID == userID.(int64) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No puedes copiarte a ti mismo", "code": "CANNOT_COPY_SELF"})
+		return
+	}
+
+	rel := &models.CopyRelationship{
+		CopierID: userID.(int64),
+		TraderID: traderID,
+		CopyMode: models.CopyMode(req.CopyMode),
+	}
+	if rel.CopyMode == models.CopyFixed {
+		if req.CopyAmount < leader.MinCopyAmount {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "El monto por trade es menor al mínimo del líder",
+				"code":    "BELOW_MIN_COPY_AMOUNT",
+				"minimum": leader.MinCopyAmount,
+			})
+			return
+		}
+		rel.CopyAmount = req.CopyAmount
+	} else {
+		if req.CopyPercentage == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Indica copy_percentage para el modo percentage"})
+			return
+		}
+		rel.CopyPercentage = req.CopyPercentage
+	}
+
+	if err := h.repo.Follow(ctx, rel); err != nil {
+		if errors.Is(err, repositories.ErrCopyLeaderNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Líder no encontrado", "code": "LEADER_NOT_FOUND"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creando suscripción"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Ahora copias a " + leader.DisplayName,
+		"relationship": rel,
+	})
+}
+
+// Unfollow detiene la suscripción; los trades ya copiados siguen hasta su vencimiento
+func (h *CopyTradingHandler) Unfollow(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	traderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de líder inválido"})
+		return
+	}
+
+	stopped, err := h.repo.Unfollow(c.Request.Context(), userID.(int64), traderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deteniendo suscripción"})
+		return
+	}
+	if !stopped {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No copias a este líder", "code": "NOT_FOLLOWING"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Dejaste de copiar al líder"})
+}
+
+// GetSubscriptions suscripciones del usuario con su resultado
+func (h *CopyTradingHandler) GetSubscriptions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	relationships, err := h.repo.GetUserRelationships(c.Request.Context(), userID.(int64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo suscripciones"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subscriptions": relationships})
+}
+
+// GetLeaderStats estadísticas del usuario como líder
+func (h *CopyTradingHandler) GetLeaderStats(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	stats, err := h.repo.GetLeaderStats(c.Request.Context(), userID.(int64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo estadísticas"})
+		return
+	}
+	if stats == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No tienes un perfil de líder", "code": "NOT_A_LEADER"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetFollowerStats estadísticas del usuario como seguidor
+func (h *CopyTradingHandler) GetFollowerStats(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	stats, err := h.repo.GetFollowerStats(c.Request.Context(), userID.(int64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo estadísticas"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"tormentus/internal/models"
+	"tormentus/internal/repositories"
+	"tormentus/internal/trading"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DemoRepository resets del balance demo
+type DemoRepository interface {
+	NextResetAt(ctx context.Context, userID int64, cooldown time.Duration) (*time.Time, error)
+	ResetDemoAccount(ctx context.Context, userID int64, balance float64, cooldown time.Duration, voided []*models.Trade) (*models.DemoReset, error)
+	GetDemoResets(ctx context.Context, userID int64, limit, offset int) ([]*models.DemoReset, error)
+}
+
+// DemoTradeVoider anula los trades demo abiertos del usuario en el motor
+type DemoTradeVoider interface {
+	VoidDemoTrades(userID int64, reason string, persist func(voided []*models.Trade) error) error
+}
+
+// DemoOrderVoider retira del libro las órdenes de entrada demo pendientes del usuario
+type DemoOrderVoider interface {
+	VoidDemoOrders(userID int64, persist func() error) error
+}
+
+// DemoHandler maneja el reset de la cuenta demo
+type DemoHandler struct {
+	repo     DemoRepository
+	engine   DemoTradeVoider
+	orders   DemoOrderVoider
+	balance  float64       // Balance demo tras el reset
+	cooldown time.Duration // Espera mínima entre resets
+}
+
+// NewDemoHandler crea un nuevo handler de cuenta demo
+func NewDemoHandler(repo DemoRepository, engine DemoTradeVoider, orders DemoOrderVoider, balance float64, cooldown time.Duration) *DemoHandler {
+	return &DemoHandler{
+		repo:     repo,
+		engine:   engine,
+		orders:   orders,
+		balance:  balance,
+		cooldown: cooldown,
+	}
+}
+
+// respondCooldown rechaza un reset antes de que termine el cooldown
+func respondCooldown(c *gin.Context, nextResetAt time.Time) {
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":         "Ya reiniciaste tu cuenta demo recientemente",
+		"code":          "DEMO_RESET_COOLDOWN",
+		"next_reset_at": nextResetAt,
+	})
+}
+
+// ResetDemoAccount restablece el balance demo al valor inicial, anula los trades
+// demo abiertos (sin crédito) y cancela las órdenes de entrada demo pendientes
+// (sin devolución)
+func (h *DemoHandler) ResetDemoAccount(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+	ctx := c.Request.Context()
+
+	// Verificación previa para no retirar trades del motor si el reset no procede;
+	// ResetDemoAccount la repite con la fila del usuario bloqueada
+	next, err := h.repo.NextResetAt(ctx, userID.(int64), h.cooldown)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error verificando último reset"})
+		return
+	}
+	if next != nil {
+		respondCooldown(c, *next)
+		return
+	}
+
+	var reset *models.DemoReset
+	err = h.orders.VoidDemoOrders(userID.(int64), func() error {
+		return h.engine.VoidDemoTrades(userID.(int64), "reset de cuenta demo", func(voided []*models.Trade) error {
+			var err error
+			reset, err = h.repo.ResetDemoAccount(ctx, userID.(int64), h.balance, h.cooldown, voided)
+			return err
+		})
+	})
+
+	var cooldownErr *repositories.DemoResetCooldownError
+	switch {
+	case errors.As(err, &cooldownErr):
+		respondCooldown(c, cooldownErr.NextResetAt)
+		return
+	case errors.Is(err, trading.ErrEngineStopped):
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "El trading no está disponible en este momento",
+			"code":  "TRADING_UNAVAILABLE",
+		})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error reiniciando cuenta demo"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Cuenta demo reiniciada",
+		"reset":         reset,
+		"next_reset_at": reset.ResetAt.Add(h.cooldown),
+	})
+}
+
+// GetDemoResets historial de resets de la cuenta demo
+func (h *DemoHandler) GetDemoResets(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	resets, err := h.repo.GetDemoResets(c.Request.Context(), userID.(int64), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo historial de resets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"resets": resets})
+}
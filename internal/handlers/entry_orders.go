@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"tormentus/internal/models"
+	"tormentus/internal/repositories"
+	"tormentus/internal/services"
+	"tormentus/internal/trading"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultOrderValidity ventana de validez si no se indica valid_for
+	defaultOrderValidity = time.Hour
+	// maxPendingOrders órdenes pendientes simultáneas por usuario
+	maxPendingOrders = 20
+)
+
+// EntryOrderRepository persistencia de órdenes de entrada
+type EntryOrderRepository interface {
+	CreateOrder(ctx context.Context, order *models.EntryOrder) error
+	GetUserOrders(ctx context.Context, userID int64, status string, limit, offset int) ([]*models.EntryOrder, error)
+}
+
+// EntryOrderHandler maneja las órdenes de entrada condicionales
+type EntryOrderHandler struct {
+	book         *trading.EntryOrderBook
+	repo         EntryOrderRepository
+	engine       *trading.TradingEngine
+	priceService *services.PriceService
+	payouts      PayoutResolver
+	risk         RiskChecker
+}
+
+// NewEntryOrderHandler crea un nuevo handler de órdenes de entrada
+func NewEntryOrderHandler(book *trading.EntryOrderBook, repo EntryOrderRepository, engine *trading.TradingEngine, priceService *services.PriceService, payouts PayoutResolver, risk RiskChecker) *EntryOrderHandler {
+	return &EntryOrderHandler{
+		book:         book,
+		repo:         repo,
+		engine:       engine,
+		priceService: priceService,
+		payouts:      payouts,
+		risk:         risk,
+	}
+}
+
+// PlaceEntryOrderRequest request para crear una orden de entrada.
+// Ej: abrir "up" 60s en EUR/USD si el precio es <= 1.0820 (condition below).
+type PlaceEntryOrderRequest struct {
+	Symbol       string  `json:"symbol" binding:"required"`
+	Direction    string  `json:"direction" binding:"required,oneof=up down"`
+	Amount       float64 `json:"amount" binding:"required,gt=0"`
+	Duration     int     `json:"duration" binding:"required,min=30,max=3600"` // Duración del trade al activarse
+	TriggerPrice float64 `json:"trigger_price" binding:"required,gt=0"`
+	Condition    string  `json:"condition" binding:"required,oneof=below above"`
+	ValidFor     int     `json:"valid_for" binding:"omitempty,min=60,max=86400"` // Segundos; por defecto 1h
+	IsDemo       bool    `json:"is_demo"`
+}
+
+// PlaceEntryOrder crea una orden de entrada y reserva el monto
+func (h *EntryOrderHandler) PlaceEntryOrder(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var req PlaceEntryOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos", "details": err.Error()})
+		return
+	}
+	ctx := c.Request.Context()
+
+	if !h.engine.Accepting() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "El servidor se está reiniciando, intenta nuevamente en unos segundos",
+			"code":  "TRADING_UNAVAILABLE",
+		})
+		return
+	}
+
+	if _, err := h.priceService.GetPrice(req.Symbol); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Símbolo no válido"})
+		return
+	}
+
+	if h.book.PendingCount(userID.(int64)) >= maxPendingOrders {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Alcanzaste el máximo de órdenes pendientes",
+			"code":  "TOO_MANY_PENDING_ORDERS",
+			"limit": maxPendingOrders,
+		})
+		return
+	}
+
+	// Los límites se validan al crear la orden (el monto queda reservado desde ahora) y
+	// otra vez al activarse
+	if _, err := h.risk.Check(ctx, userID.(int64), req.Symbol, req.Amount, req.IsDemo); err != nil {
+		if !respondRiskError(c, err) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error validando límites de trading"})
+		}
+		return
+	}
+
+	// Payout vigente al crear la orden; queda fijado para el trade
+	payout, err := h.payouts.Resolve(ctx, userID.(int64), req.Symbol, models.OptionHighLow, req.Duration, req.Amount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo payout"})
+		return
+	}
+
+	validFor := defaultOrderValidity
+	if req.ValidFor > 0 {
+		validFor = time.Duration(req.ValidFor) * time.Second
+	}
+	now := time.Now()
+	order := &models.EntryOrder{
+		UserID:       userID.(int64),
+		Symbol:       req.Symbol,
+		Direction:    models.TradeDirection(req.Direction),
+		Amount:       req.Amount,
+		Duration:     req.Duration,
+		Payout:       payout,
+		IsDemo:       req.IsDemo,
+		TriggerPrice: req.TriggerPrice,
+		Condition:    models.OrderCondition(req.Condition),
+		Status:       models.OrderPending,
+		ValidUntil:   now.Add(validFor),
+		CreatedAt:    now,
+	}
+
+	if err := h.repo.CreateOrder(ctx, order); err != nil {
+		if errors.Is(err, repositories.ErrInsufficientBalance) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Balance insuficiente",
+				"code":  "INSUFFICIENT_BALANCE",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creando orden"})
+		return
+	}
+	h.book.Add(order)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Orden de entrada creada",
+		"order":   order,
+	})
+}
+
+// GetEntryOrders lista las órdenes del usuario (filtro opcional ?status=)
+func (h *EntryOrderHandler) GetEntryOrders(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	status := c.DefaultQuery("status", "all")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	orders, err := h.repo.GetUserOrders(c.Request.Context(), userID.(int64), status, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo órdenes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"orders": orders})
+}
+
+// CancelEntryOrder cancela una orden pendiente y devuelve el monto reservado
+func (h *EntryOrderHandler) CancelEntryOrder(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de orden inválido"})
+		return
+	}
+
+	order, err := h.book.Cancel(c.Request.Context(), orderID, userID.(int64))
+	if err != nil {
+		if errors.Is(err, trading.ErrOrderNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Orden no encontrada o ya no está pendiente",
+				"code":  "ORDER_NOT_FOUND",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error cancelando orden"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Orden cancelada",
+		"order":   order,
+	})
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"tormentus/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExposureSource exposición abierta por símbolo (libro del motor de trading)
+type ExposureSource interface {
+	Exposure() []models.SymbolExposure
+}
+
+// ExposureHandler expone la exposición neta de la casa para la mesa de riesgo
+type ExposureHandler struct {
+	source ExposureSource
+}
+
+// NewExposureHandler crea un nuevo handler de exposición
+func NewExposureHandler(source ExposureSource) *ExposureHandler {
+	return &ExposureHandler{source: source}
+}
+
+// GetExposure devuelve stake up/down, exposición neta y tramos de expiración por símbolo
+func (h *ExposureHandler) GetExposure(c *gin.Context) {
+	symbols := h.source.Exposure()
+
+	// Filtro opcional por símbolo
+	if symbol := c.Query("symbol"); symbol != "" {
+		filtered := make([]models.SymbolExposure, 0, 1)
+		for _, s := range symbols {
+			if s.Symbol == symbol {
+				filtered = append(filtered, s)
+			}
+		}
+		symbols = filtered
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"symbols":      symbols,
+		"generated_at": time.Now(),
+	})
+}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"tormentus/internal/models"
+	"tormentus/internal/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JournalRepository persistencia del diario de trading
+type JournalRepository interface {
+	CreateEntry(ctx context.Context, entry *models.JournalEntry) error
+	UpdateEntry(ctx context.Context, entry *models.JournalEntry) (bool, error)
+	DeleteEntry(ctx context.Context, userID, id int64) (bool, error)
+	GetEntry(ctx context.Context, userID, id int64) (*models.JournalEntry, error)
+	GetEntries(ctx context.Context, userID int64, filter models.JournalFilter) ([]*models.JournalEntry, error)
+	GetAnalytics(ctx context.Context, userID int64, isDemo *bool) (*models.JournalAnalytics, error)
+}
+
+// JournalTradeSource trades a los que se asocian las entradas
+type JournalTradeSource interface {
+	GetTradeByID(ctx context.Context, id int64) (*models.Trade, error)
+}
+
+// JournalHandler maneja el diario de trading del usuario
+type JournalHandler struct {
+	repo   JournalRepository
+	trades JournalTradeSource
+}
+
+// NewJournalHandler crea un nuevo handler del diario
+func NewJournalHandler(repo JournalRepository, trades JournalTradeSource) *JournalHandler {
+	return &JournalHandler{
+		repo:   repo,
+		trades: trades,
+	}
+}
+
+// JournalEntryRequest campos editables de una entrada del diario
+type JournalEntryRequest struct {
+	EntryReason    string   `json:"entry_reason" binding:"max=2000"`
+	ExitReason     string   `json:"exit_reason" binding:"max=2000"`
+	Emotion        string   `json:"emotion" binding:"omitempty,oneof=confident calm excited fearful anxious greedy frustrated impatient bored neutral"`
+	LessonsLearned string   `json:"lessons_learned" binding:"max=2000"`
+	Rating         *int     `json:"rating" binding:"omitempty,min=1,max=5"`
+	Tags           []string `json:"tags" binding:"max=10,dive,max=30"`
+}
+
+// CreateJournalEntryRequest request para registrar un trade en el diario
+type CreateJournalEntryRequest struct {
+	TradeID int64 `json:"trade_id" binding:"required,gt=0"`
+	JournalEntryRequest
+}
+
+// apply copia los campos editables a la entrada normalizando las etiquetas
+func (req *JournalEntryRequest) apply(entry *models.JournalEntry) {
+	entry.EntryReason = strings.TrimSpace(req.EntryReason)
+	entry.ExitReason = strings.TrimSpace(req.ExitReason)
+	entry.Emotion = models.JournalEmotion(req.Emotion)
+	entry.LessonsLearned = strings.TrimSpace(req.LessonsLearned)
+	entry.Rating = req.Rating
+	entry.Tags = normalizeJournalTags(req.Tags)
+}
+
+// normalizeJournalTags etiquetas en minúsculas, sin vacías ni repetidas
+func normalizeJournalTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// journalAccountFilter lee ?account=real|demo (sin filtro por defecto)
+func journalAccountFilter(c *gin.Context) (*bool, bool) {
+	switch c.Query("account") {
+	case "":
+		return nil, true
+	case "real":
+		isDemo := false
+		return &isDemo, true
+	case "demo":
+		isDemo := true
+		return &isDemo, true
+	default:
+		return nil, false
+	}
+}
+
+// CreateEntry registra en el diario un trade propio (una entrada por trade)
+func (h *JournalHandler) CreateEntry(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var req CreateJournalEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos", "details": err.Error()})
+		return
+	}
+	ctx := c.Request.Context()
+
+	trade, err := h.trades.GetTradeByID(ctx, req.TradeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo trade"})
+		return
+	}
+	if trade == nil || trade.UserID != userID.(int64) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Trade no encontrado", "code": "TRADE_NOT_FOUND"})
+		return
+	}
+
+	entry := &models.JournalEntry{
+		UserID:  userID.(int64),
+		TradeID: trade.ID,
+	}
+	req.apply(entry)
+
+	if err := h.repo.CreateEntry(ctx, entry); err != nil {
+		if errors.Is(err, repositories.ErrJournalEntryExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": "El trade ya tiene una entrada en el diario", "code": "JOURNAL_ENTRY_EXISTS"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error guardando entrada"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"entry": entry})
+}
+
+// GetEntries lista el diario (filtros opcionales: tag, emotion, account)
+func (h *JournalHandler) GetEntries(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	isDemo, ok := journalAccountFilter(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account debe ser real o demo"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	entries, err := h.repo.GetEntries(c.Request.Context(), userID.(int64), models.JournalFilter{
+		Tag:     strings.ToLower(strings.TrimSpace(c.Query("tag"))),
+		Emotion: models.JournalEmotion(c.Query("emotion")),
+		IsDemo:  isDemo,
+		Limit:   limit,
+		Offset:  offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo diario"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+// GetEntry obtiene una entrada del diario
+func (h *JournalHandler) GetEntry(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	entryID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de entrada inválido"})
+		return
+	}
+
+	entry, err := h.repo.GetEntry(c.Request.Context(), userID.(int64), entryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo entrada"})
+		return
+	}
+	if entry == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entrada no encontrada", "code": "JOURNAL_ENTRY_NOT_FOUND"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entry": entry})
+}
+
+// UpdateEntry reemplaza los campos editables de una entrada; el trade no cambia
+func (h *JournalHandler) UpdateEntry(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	entryID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de entrada inválido"})
+		return
+	}
+
+	var req JournalEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos", "details": err.Error()})
+		return
+	}
+
+	entry := &models.JournalEntry{
+		ID:     entryID,
+		UserID: userID.(int64),
+	}
+	req.apply(entry)
+
+	updated, err := h.repo.UpdateEntry(c.Request.Context(), entry)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error actualizando entrada"})
+		return
+	}
+	if !updated {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entrada no encontrada", "code": "JOURNAL_ENTRY_NOT_FOUND"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entry": entry})
+}
+
+// DeleteEntry elimina una entrada del diario
+func (h *JournalHandler) DeleteEntry(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	entryID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de entrada inválido"})
+		return
+	}
+
+	deleted, err := h.repo.DeleteEntry(c.Request.Context(), userID.(int64), entryID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error eliminando entrada"})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entrada no encontrada", "code": "JOURNAL_ENTRY_NOT_FOUND"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Entrada eliminada"})
+}
+
+// GetAnalytics win rate y P&L de los trades cerrados del diario por etiqueta, emoción y calificación
+func (h *JournalHandler) GetAnalytics(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	isDemo, ok := journalAccountFilter(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account debe ser real o demo"})
+		return
+	}
+
+	analytics, err := h.repo.GetAnalytics(c.Request.Context(), userID.(int64), isDemo)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo análisis del diario"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"analytics": analytics})
+}
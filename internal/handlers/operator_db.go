@@ -1889,13 +1889,19 @@ func (h *OperatorDBHandler) UpdateTradingAsset(c *gin.Context) {
 		RiskLevel   string  `json:"risk_level"`
 		IsActive    bool    `json:"is_active"`
 		IsFeatured  bool    `json:"is_featured"`
+		// Exposición neta máxima (stake up - stake down abierto); null: sin límite
+		MaxNetExposure *float64 `json:"max_net_exposure"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos"})
 		return
 	}
+	if req.MaxNetExposure != nil && *req.MaxNetExposure < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "La exposición máxima no puede ser negativa"})
+		return
+	}
 
-	if err := h.repo.UpdateTradingAsset(c.Request.Context(), assetID, req.Name, req.CategoryID, req.MinAmount, req.MaxAmount, req.MinDuration, req.MaxDuration, req.Payout, req.Spread, req.RiskLevel, req.IsActive, req.IsFeatured); err != nil {
+	if err := h.repo.UpdateTradingAsset(c.Request.Context(), assetID, req.Name, req.CategoryID, req.MinAmount, req.MaxAmount, req.MinDuration, req.MaxDuration, req.Payout, req.Spread, req.RiskLevel, req.IsActive, req.IsFeatured, req.MaxNetExposure); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error actualizando activo"})
 		return
 	}
@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"tormentus/internal/models"
+	"tormentus/internal/repositories"
+	"tormentus/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SignalRepository persistencia de señales, proveedores y suscripciones
+type SignalRepository interface {
+	UpsertProvider(ctx context.Context, provider *models.SignalProvider, verifiedBy int64) error
+	RevokeProvider(ctx context.Context, userID int64) (bool, error)
+	GetProvider(ctx context.Context, userID int64) (*models.SignalProvider, error)
+	GetProviders(ctx context.Context, limit, offset int) ([]*models.SignalProvider, error)
+	GetSignal(ctx context.Context, id int64) (*models.TradingSignal, error)
+	GetFeed(ctx context.Context, userID int64, limit, offset int) ([]*models.TradingSignal, error)
+	GetProviderSignals(ctx context.Context, providerID int64, includeActive bool, limit, offset int) ([]*models.TradingSignal, error)
+	Subscribe(ctx context.Context, userID, providerID int64) (*models.SignalSubscription, error)
+	Unsubscribe(ctx context.Context, userID, providerID int64) (bool, error)
+	IsSubscribed(ctx context.Context, userID, providerID int64) (bool, error)
+	GetUserSubscriptions(ctx context.Context, userID int64) ([]*models.SignalSubscription, error)
+}
+
+// SignalPublisher publica señales y las evalúa al vencer su timeframe
+type SignalPublisher interface {
+	Publish(ctx context.Context, signal *models.TradingSignal) error
+}
+
+// SignalHandler maneja señales de trading de proveedores verificados
+type SignalHandler struct {
+	repo      SignalRepository
+	publisher SignalPublisher
+}
+
+// NewSignalHandler crea un nuevo handler de señales
+func NewSignalHandler(repo SignalRepository, publisher SignalPublisher) *SignalHandler {
+	return &SignalHandler{
+		repo:      repo,
+		publisher: publisher,
+	}
+}
+
+// PublishSignalRequest request para publicar una señal; el precio de entrada lo fija el servidor
+type PublishSignalRequest struct {
+	Symbol     string `json:"symbol" binding:"required,max=20"`
+	Direction  string `json:"direction" binding:"required,oneof=up down"`
+	Timeframe  string `json:"timeframe" binding:"required,oneof=1m 5m 15m 30m 1h 4h 1d"`
+	Confidence int    `json:"confidence" binding:"required,min=1,max=100"`
+	Analysis   string `json:"analysis" binding:"max=2000"`
+}
+
+// SignalProviderRequest request del operador para registrar o verificar un analista
+type SignalProviderRequest struct {
+	UserID      int64  `json:"user_id" binding:"required,gt=0"`
+	DisplayName string `json:"display_name" binding:"required,max=100"`
+	Bio         string `json:"bio" binding:"max=1000"`
+}
+
+// signalPagination lee limit/offset del query string
+func signalPagination(c *gin.Context) (int, int) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// PublishSignal publica una señal del proveedor verificado autenticado
+func (h *SignalHandler) PublishSignal(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var req PublishSignalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos", "details": err.Error()})
+		return
+	}
+
+	signal := &models.TradingSignal{
+		ProviderID: userID.(int64),
+		Symbol:     strings.ToUpper(req.Symbol),
+		Direction:  models.TradeDirection(req.Direction),
+		Timeframe:  req.Timeframe,
+		Confidence: req.Confidence,
+		Analysis:   req.Analysis,
+	}
+	if err := h.publisher.Publish(c.Request.Context(), signal); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrSignalProviderNotFound):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Solo los proveedores verificados pueden publicar señales", "code": "NOT_A_SIGNAL_PROVIDER"})
+		case errors.Is(err, services.ErrSignalNoPrice):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Símbolo sin precio disponible", "code": "SYMBOL_NOT_AVAILABLE"})
+		case errors.Is(err, services.ErrInvalidSignalTimeframe):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Timeframe no válido", "code": "INVALID_TIMEFRAME"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error publicando señal"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Señal publicada",
+		"signal":  signal,
+	})
+}
+
+// GetFeed señales de los proveedores a los que el usuario está suscrito
+func (h *SignalHandler) GetFeed(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	limit, offset := signalPagination(c)
+	signals, err := h.repo.GetFeed(c.Request.Context(), userID.(int64), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo señales"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"signals": signals})
+}
+
+// GetSignal detalle de una señal. Mientras está activa solo la ven el proveedor y sus suscriptores.
+func (h *SignalHandler) GetSignal(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	signalID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de señal inválido"})
+		return
+	}
+	ctx := c.Request.Context()
+
+	signal, err := h.repo.GetSignal(ctx, signalID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo señal"})
+		return
+	}
+	if signal == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Señal no encontrada", "code": "SIGNAL_NOT_FOUND"})
+		return
+	}
+
+	if signal.Status == models.SignalActive && signal.ProviderID != userID.(int64) {
+		subscribed, err := h.repo.IsSubscribed(ctx, userID.(int64), signal.ProviderID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo señal"})
+			return
+		}
+		if !subscribed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Suscríbete al proveedor para ver sus señales activas", "code": "NOT_SUBSCRIBED"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"signal": signal})
+}
+
+// GetProviders lista los proveedores verificados ordenados por tasa de acierto
+func (h *SignalHandler) GetProviders(c *gin.Context) {
+	limit, offset := signalPagination(c)
+	providers, err := h.repo.GetProviders(c.Request.Context(), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo proveedores"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"providers": providers})
+}
+
+// GetProvider historial del proveedor y sus señales. Las señales evaluadas son públicas;
+// las activas solo se incluyen para el propio proveedor y sus suscriptores.
+func (h *SignalHandler) GetProvider(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	providerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de proveedor inválido"})
+		return
+	}
+	ctx := c.Request.Context()
+
+	provider, err := h.repo.GetProvider(ctx, providerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo proveedor"})
+		return
+	}
+	if provider == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proveedor no encontrado", "code": "PROVIDER_NOT_FOUND"})
+		return
+	}
+
+	subscribed := false
+	if providerID != userID.(int64) {
+		subscribed, err = h.repo.IsSubscribed(ctx, userID.(int64), providerID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo proveedor"})
+			return
+		}
+	}
+
+	limit, offset := signalPagination(c)
+	signals, err := h.repo.GetProviderSignals(ctx, providerID, subscribed || providerID == userID.(int64), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo señales"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"provider":   provider,
+		"subscribed": subscribed,
+		"signals":    signals,
+	})
+}
+
+// Subscribe suscribe al usuario a las señales de un proveedor verificado
+func (h *SignalHandler) Subscribe(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	providerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de proveedor inválido"})
+		return
+	}
+	if providerID == userID.(int64) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No puedes suscribirte a tus propias señales", "code": "CANNOT_SUBSCRIBE_SELF"})
+		return
+	}
+
+	sub, err := h.repo.Subscribe(c.Request.Context(), userID.(int64), providerID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrSignalProviderNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Proveedor no encontrado", "code": "PROVIDER_NOT_FOUND"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creando suscripción"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Suscripción activa",
+		"subscription": sub,
+	})
+}
+
+// Unsubscribe cancela la suscripción a un proveedor
+func (h *SignalHandler) Unsubscribe(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	providerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de proveedor inválido"})
+		return
+	}
+
+	canceled, err := h.repo.Unsubscribe(c.Request.Context(), userID.(int64), providerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error cancelando suscripción"})
+		return
+	}
+	if !canceled {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No estás suscrito a este proveedor", "code": "NOT_SUBSCRIBED"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Suscripción cancelada"})
+}
+
+// GetSubscriptions suscripciones del usuario
+func (h *SignalHandler) GetSubscriptions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	subs, err := h.repo.GetUserSubscriptions(c.Request.Context(), userID.(int64))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo suscripciones"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subscriptions": subs})
+}
+
+// VerifyProvider registra (o actualiza) a un analista como proveedor verificado
+func (h *SignalHandler) VerifyProvider(c *gin.Context) {
+	operatorUserID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var req SignalProviderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos", "details": err.Error()})
+		return
+	}
+
+	provider := &models.SignalProvider{
+		UserID:      req.UserID,
+		DisplayName: req.DisplayName,
+		Bio:         req.Bio,
+	}
+	if err := h.repo.UpsertProvider(c.Request.Context(), provider, operatorUserID.(int64)); err != nil {
+		if errors.Is(err, repositories.ErrSignalProviderNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Usuario no encontrado", "code": "USER_NOT_FOUND"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error guardando proveedor"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Proveedor verificado",
+		"provider": provider,
+	})
+}
+
+// RevokeProvider retira la verificación: el proveedor ya no puede publicar señales.
+// Sus señales activas se siguen evaluando y el historial se conserva.
+func (h *SignalHandler) RevokeProvider(c *gin.Context) {
+	providerID, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+
+	revoked, err := h.repo.RevokeProvider(c.Request.Context(), providerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error revocando proveedor"})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Proveedor no encontrado", "code": "PROVIDER_NOT_FOUND"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verificación revocada"})
+}
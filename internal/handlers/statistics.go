@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"tormentus/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultCalendarDays días del calendario de P&L si no se indica rango
+	defaultCalendarDays = 30
+	// maxCalendarDays rango máximo del calendario de P&L
+	maxCalendarDays = 366
+)
+
+// StatisticsRepository estadísticas de trading del usuario
+type StatisticsRepository interface {
+	GetDailyStatistics(ctx context.Context, userID int64, isDemo bool, from, to time.Time) ([]*models.DailyStatistics, error)
+	GetSymbolStatistics(ctx context.Context, userID int64, isDemo bool) ([]*models.SymbolStatistics, error)
+	GetHourlyStatistics(ctx context.Context, userID int64, isDemo bool) ([]*models.HourlyStatistics, error)
+	GetStreaks(ctx context.Context, userID int64, isDemo bool) (*models.TradeStreaks, error)
+}
+
+// StatisticsHandler maneja las estadísticas detalladas del perfil
+type StatisticsHandler struct {
+	repo StatisticsRepository
+}
+
+// NewStatisticsHandler crea un nuevo handler de estadísticas
+func NewStatisticsHandler(repo StatisticsRepository) *StatisticsHandler {
+	return &StatisticsHandler{repo: repo}
+}
+
+// statsAccount lee ?account=real|demo (real por defecto)
+func statsAccount(c *gin.Context) (isDemo bool, ok bool) {
+	switch c.DefaultQuery("account", "real") {
+	case "real":
+		return false, true
+	case "demo":
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+// GetCalendar calendario de P&L por día de cierre (UTC) entre from y to (YYYY-MM-DD;
+// por defecto los últimos 30 días)
+func (h *StatisticsHandler) GetCalendar(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	isDemo, ok := statsAccount(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account debe ser real o demo"})
+		return
+	}
+
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	from := to.AddDate(0, 0, -(defaultCalendarDays - 1))
+	var err error
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to debe tener formato YYYY-MM-DD"})
+			return
+		}
+		from = to.AddDate(0, 0, -(defaultCalendarDays - 1))
+	}
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from debe tener formato YYYY-MM-DD"})
+			return
+		}
+	}
+	if from.After(to) || to.Sub(from) > maxCalendarDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Rango de fechas inválido (máximo 366 días)"})
+		return
+	}
+
+	days, err := h.repo.GetDailyStatistics(c.Request.Context(), userID.(int64), isDemo, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo calendario"})
+		return
+	}
+
+	net := 0.0
+	greenDays, redDays := 0, 0
+	for _, day := range days {
+		net += day.Net
+		if day.Net > 0 {
+			greenDays++
+		} else if day.Net < 0 {
+			redDays++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":       from.Format("2006-01-02"),
+		"to":         to.Format("2006-01-02"),
+		"days":       days,
+		"net":        net,
+		"green_days": greenDays,
+		"red_days":   redDays,
+	})
+}
+
+// GetSymbols resultado por símbolo
+func (h *StatisticsHandler) GetSymbols(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	isDemo, ok := statsAccount(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account debe ser real o demo"})
+		return
+	}
+
+	symbols, err := h.repo.GetSymbolStatistics(c.Request.Context(), userID.(int64), isDemo)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo estadísticas por símbolo"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"symbols": symbols})
+}
+
+// GetHours resultado por hora de apertura (UTC) con la mejor y la peor hora por P&L neto
+func (h *StatisticsHandler) GetHours(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	isDemo, ok := statsAccount(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account debe ser real o demo"})
+		return
+	}
+
+	hours, err := h.repo.GetHourlyStatistics(c.Request.Context(), userID.(int64), isDemo)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo estadísticas por hora"})
+		return
+	}
+
+	var best, worst *models.HourlyStatistics
+	for _, hour := range hours {
+		if best == nil || hour.Net > best.Net {
+			best = hour
+		}
+		if worst == nil || hour.Net < worst.Net {
+			worst = hour
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"hours":      hours,
+		"best_hour":  best,
+		"worst_hour": worst,
+	})
+}
+
+// GetStreaks racha actual, mejor racha ganadora y peor racha perdedora
+func (h *StatisticsHandler) GetStreaks(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	isDemo, ok := statsAccount(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account debe ser real o demo"})
+		return
+	}
+
+	streaks, err := h.repo.GetStreaks(c.Request.Context(), userID.(int64), isDemo)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo rachas"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"streaks": streaks})
+}
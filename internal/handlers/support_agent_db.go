@@ -516,6 +516,33 @@ func (h *SupportAgentDBHandler) GetUserByID(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"user": user, "tickets": tickets, "notes": notes})
 }
 
+// GetUserTradeSettlement obtiene el registro de liquidación de un trade del usuario (disputas)
+func (h *SupportAgentDBHandler) GetUserTradeSettlement(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+	tradeID, err := strconv.ParseInt(c.Param("tradeId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de operación inválido"})
+		return
+	}
+
+	settlement, err := h.repo.GetUserTradeSettlement(ctx, userID, tradeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo liquidación"})
+		return
+	}
+	if settlement == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Liquidación no encontrada"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"settlement": settlement})
+}
+
 // AddUserNote agrega una nota a un usuario
 func (h *SupportAgentDBHandler) AddUserNote(c *gin.Context) {
 	ctx := c.Request.Context()
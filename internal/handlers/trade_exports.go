@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"tormentus/internal/export"
+	"tormentus/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TradeExportRepository consultas de las exportaciones del usuario
+type TradeExportRepository interface {
+	GetExport(ctx context.Context, id int64) (*models.TradeExport, error)
+	GetUserExports(ctx context.Context, userID int64, limit int) ([]*models.TradeExport, error)
+}
+
+// TradeExportHandler maneja las exportaciones del historial de trades
+type TradeExportHandler struct {
+	service *export.Service
+	repo    TradeExportRepository
+}
+
+// NewTradeExportHandler crea un nuevo handler de exportaciones
+func NewTradeExportHandler(service *export.Service, repo TradeExportRepository) *TradeExportHandler {
+	return &TradeExportHandler{
+		service: service,
+		repo:    repo,
+	}
+}
+
+// TradeExportRequest request para exportar el historial
+type TradeExportRequest struct {
+	Format  string `json:"format" binding:"required,oneof=csv xlsx pdf"`
+	From    string `json:"from"` // YYYY-MM-DD por fecha de cierre (opcional)
+	To      string `json:"to"`   // YYYY-MM-DD inclusive (opcional)
+	Symbol  string `json:"symbol" binding:"max=20"`
+	Account string `json:"account" binding:"omitempty,oneof=real demo"` // real por defecto
+}
+
+// RequestExport encola la exportación del historial; el archivo se genera en segundo plano
+func (h *TradeExportHandler) RequestExport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var req TradeExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos", "details": err.Error()})
+		return
+	}
+
+	var from, to time.Time
+	var err error
+	if req.From != "" {
+		if from, err = time.Parse("2006-01-02", req.From); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from debe tener formato YYYY-MM-DD"})
+			return
+		}
+	}
+	if req.To != "" {
+		if to, err = time.Parse("2006-01-02", req.To); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to debe tener formato YYYY-MM-DD"})
+			return
+		}
+	}
+	if req.From != "" && req.To != "" && from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Rango de fechas inválido"})
+		return
+	}
+
+	exp := &models.TradeExport{
+		UserID:   userID.(int64),
+		Format:   models.ExportFormat(req.Format),
+		DateFrom: req.From,
+		DateTo:   req.To,
+		Symbol:   strings.ToUpper(strings.TrimSpace(req.Symbol)),
+		IsDemo:   req.Account == "demo",
+	}
+	if err := h.service.Request(c.Request.Context(), exp); err != nil {
+		if errors.Is(err, export.ErrTooManyExports) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "code": "TOO_MANY_EXPORTS"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creando exportación"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"export": exp})
+}
+
+// GetExports lista las exportaciones del usuario; las listas incluyen su enlace de descarga
+func (h *TradeExportHandler) GetExports(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	exports, err := h.repo.GetUserExports(c.Request.Context(), userID.(int64), 50)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo exportaciones"})
+		return
+	}
+	for _, exp := range exports {
+		h.service.SignDownload(exp)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"exports": exports})
+}
+
+// GetExport estado de una exportación y, si está lista, su enlace de descarga
+func (h *TradeExportHandler) GetExport(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	exportID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de exportación inválido"})
+		return
+	}
+
+	exp, err := h.repo.GetExport(c.Request.Context(), exportID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo exportación"})
+		return
+	}
+	if exp == nil || exp.UserID != userID.(int64) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Exportación no encontrada", "code": "EXPORT_NOT_FOUND"})
+		return
+	}
+	h.service.SignDownload(exp)
+
+	c.JSON(http.StatusOK, gin.H{"export": exp})
+}
+
+// Download entrega el archivo de un enlace firmado (?expires=&signature=); no requiere
+// sesión para que funcione desde el navegador o un gestor de descargas
+func (h *TradeExportHandler) Download(c *gin.Context) {
+	exportID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de exportación inválido"})
+		return
+	}
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": export.ErrInvalidLink.Error(), "code": "INVALID_DOWNLOAD_LINK"})
+		return
+	}
+
+	exp, err := h.service.OpenDownload(c.Request.Context(), exportID, expires, c.Query("signature"))
+	if err != nil {
+		if errors.Is(err, export.ErrInvalidLink) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "INVALID_DOWNLOAD_LINK"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo exportación"})
+		return
+	}
+
+	c.FileAttachment(exp.FilePath, export.Filename(exp))
+}
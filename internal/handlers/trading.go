@@ -2,11 +2,16 @@ package handlers
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
 
 	"tormentus/internal/models"
+	"tormentus/internal/repositories"
 	"tormentus/internal/services"
 	"tormentus/internal/trading"
 
@@ -16,10 +21,13 @@ import (
 // TradeRepository interface para persistencia
 type TradeRepository interface {
 	CreateTrade(ctx context.Context, trade *models.Trade) error
+	PlaceTrade(ctx context.Context, trade *models.Trade, idempotencyKey string, checkUsage func(openTrades int, dailyVolume float64) error) (*models.Trade, bool, error)
+	GetTradeByIdempotencyKey(ctx context.Context, userID int64, key string) (*models.Trade, error)
 	GetTradeByID(ctx context.Context, id int64) (*models.Trade, error)
 	GetUserTrades(ctx context.Context, userID int64, limit, offset int) ([]*models.Trade, error)
 	GetActiveTrades(ctx context.Context, userID int64) ([]*models.Trade, error)
 	GetUserTradeStats(ctx context.Context, userID int64) (*models.TradeStats, error)
+	GetSettlement(ctx context.Context, tradeID int64) (*models.SettlementRecord, error)
 }
 
 // UserRepository interface para balances
@@ -28,102 +36,558 @@ type UserRepository interface {
 	UpdateBalance(ctx context.Context, userID int64, amount float64, isDemo bool) error
 }
 
+// PayoutResolver resuelve el payout aplicable a un trade
+type PayoutResolver interface {
+	Resolve(ctx context.Context, userID int64, symbol string, optionType models.OptionType, duration int, amount float64) (float64, error)
+	ResolveOption(ctx context.Context, userID int64, symbol string, optionType models.OptionType, duration int, amount, entry float64, high, low *float64) (float64, error)
+}
+
+// RiskChecker valida bloqueos y límites antes de aceptar una operación
+type RiskChecker interface {
+	Check(ctx context.Context, userID int64, symbol string, amount float64, isDemo bool) (trading.UsageCheck, error)
+}
+
+// MarketCalendar horarios de mercado y ventanas de mantenimiento
+type MarketCalendar interface {
+	CheckTrade(symbol string, openAt, expiresAt time.Time) error
+	Status(market models.MarketType, at time.Time) models.MarketStatus
+}
+
+// TournamentLookup torneos y participantes para operar dentro de un torneo
+type TournamentLookup interface {
+	GetByID(id int64) (*models.Tournament, error)
+	GetParticipant(tournamentID, userID int64) (*models.TournamentParticipant, error)
+}
+
 // TradingHandler maneja las operaciones de trading
 type TradingHandler struct {
 	engine       *trading.TradingEngine
 	priceService *services.PriceService
 	tradeRepo    TradeRepository
 	userRepo     UserRepository
+	payouts      PayoutResolver
+	quotes       *trading.QuoteBook
+	risk         RiskChecker
+	calendar     MarketCalendar
+	tournaments  TournamentLookup
 }
 
 // NewTradingHandler crea un nuevo handler de trading
-func NewTradingHandler(engine *trading.TradingEngine, priceService *services.PriceService, tradeRepo TradeRepository, userRepo UserRepository) *TradingHandler {
+func NewTradingHandler(engine *trading.TradingEngine, priceService *services.PriceService, tradeRepo TradeRepository, userRepo UserRepository, payouts PayoutResolver, quotes *trading.QuoteBook, risk RiskChecker, calendar MarketCalendar, tournaments TournamentLookup) *TradingHandler {
 	return &TradingHandler{
 		engine:       engine,
 		priceService: priceService,
 		tradeRepo:    tradeRepo,
 		userRepo:     userRepo,
+		payouts:      payouts,
+		quotes:       quotes,
+		risk:         risk,
+		calendar:     calendar,
+		tournaments:  tournaments,
 	}
 }
 
+// TradeQuoteRequest request para cotizar una operación
+type TradeQuoteRequest struct {
+	Symbol     string  `json:"symbol" binding:"required"`
+	OptionType string  `json:"option_type" binding:"omitempty,oneof=high_low touch no_touch range_in range_out"` // Por defecto high_low
+	Direction  string  `json:"direction" binding:"omitempty,oneof=up down"`                                      // Requerido en high_low
+	Amount     float64 `json:"amount" binding:"required,gt=0"`
+	Duration   int     `json:"duration" binding:"omitempty,min=30,max=3600"` // 30s a 1h, requerido en expiry_mode duration
+	IsDemo     bool    `json:"is_demo"`
+	// duration (por defecto) o candle: expira al cierre de la vela de timeframe (1m, 5m, 15m)
+	ExpiryMode string `json:"expiry_mode" binding:"omitempty,oneof=duration candle"`
+	Timeframe  string `json:"timeframe"`
+	// Opcional: opera con el balance del participante en el torneo
+	TournamentID *int64 `json:"tournament_id"`
+	// Barrera de touch/no_touch; debe estar por encima o por debajo del precio actual
+	Barrier *float64 `json:"barrier"`
+	// Banda de range_in/range_out; el precio actual debe quedar dentro
+	BarrierLow  *float64 `json:"barrier_low"`
+	BarrierHigh *float64 `json:"barrier_high"`
+}
+
 // PlaceTradeRequest request para colocar una operación
 type PlaceTradeRequest struct {
-	Symbol    string  `json:"symbol" binding:"required"`
-	Direction string  `json:"direction" binding:"required,oneof=up down"`
-	Amount    float64 `json:"amount" binding:"required,gt=0"`
-	Duration  int     `json:"duration" binding:"required,min=30,max=3600"` // 30s a 1h
-	IsDemo    bool    `json:"is_demo"`
+	TradeQuoteRequest
+	QuoteID string `json:"quote_id"` // Opcional: confirma una cotización de /trades/quote
 }
 
-// PlaceTrade coloca una nueva operación
-func (h *TradingHandler) PlaceTrade(c *gin.Context) {
+// maxIdempotencyKeyLength longitud máxima del header Idempotency-Key
+const maxIdempotencyKeyLength = 64
+
+var (
+	// errInvalidSymbol el símbolo no tiene precio disponible
+	errInvalidSymbol = errors.New("símbolo no válido")
+	// errInvalidOption los parámetros no corresponden al tipo de opción
+	errInvalidOption = errors.New("parámetros de opción no válidos")
+	// errInvalidExpiry la duración o el timeframe de expiración no son válidos
+	errInvalidExpiry = errors.New("expiración no válida")
+)
+
+// expiryTerms calcula la expiración y la duración resultante del trade
+func expiryTerms(req TradeQuoteRequest, now time.Time) (models.ExpiryMode, time.Time, int, error) {
+	if req.ExpiryMode != string(models.ExpiryCandle) {
+		if req.Duration == 0 {
+			return "", time.Time{}, 0, fmt.Errorf("%w: duration es requerido", errInvalidExpiry)
+		}
+		return models.ExpiryDuration, now.Add(time.Duration(req.Duration) * time.Second), req.Duration, nil
+	}
+
+	timeframe, ok := trading.CandleTimeframes[req.Timeframe]
+	if !ok {
+		return "", time.Time{}, 0, fmt.Errorf("%w: timeframe debe ser 1m, 5m o 15m", errInvalidExpiry)
+	}
+	expiresAt := trading.CandleExpiry(now, timeframe)
+	duration := int(math.Ceil(expiresAt.Sub(now).Seconds()))
+	return models.ExpiryCandle, expiresAt, duration, nil
+}
+
+// optionTerms resuelve dirección y barreras según el tipo de opción y el precio de entrada.
+// En touch/no_touch la dirección indica el lado de la barrera (up = por encima).
+func optionTerms(req TradeQuoteRequest, optionType models.OptionType, entry float64) (direction models.TradeDirection, high, low *float64, err error) {
+	switch {
+	case optionType.IsBarrier():
+		if req.Barrier == nil || *req.Barrier <= 0 || *req.Barrier == entry {
+			return "", nil, nil, fmt.Errorf("%w: barrier debe ser distinta del precio actual", errInvalidOption)
+		}
+		if *req.Barrier > entry {
+			return models.TradeUp, req.Barrier, nil, nil
+		}
+		return models.TradeDown, nil, req.Barrier, nil
+	case optionType.IsRange():
+		if req.BarrierLow == nil || req.BarrierHigh == nil || *req.BarrierLow >= entry || *req.BarrierHigh <= entry {
+			return "", nil, nil, fmt.Errorf("%w: barrier_low y barrier_high deben rodear el precio actual", errInvalidOption)
+		}
+		return "", req.BarrierHigh, req.BarrierLow, nil
+	default:
+		if req.Direction == "" {
+			return "", nil, nil, fmt.Errorf("%w: direction es requerido", errInvalidOption)
+		}
+		return models.TradeDirection(req.Direction), nil, nil, nil
+	}
+}
+
+// requestOptionType tipo de opción solicitado (high_low si se omite)
+func requestOptionType(req TradeQuoteRequest) models.OptionType {
+	if req.OptionType == "" {
+		return models.OptionHighLow
+	}
+	return models.OptionType(req.OptionType)
+}
+
+// buildQuote calcula precio de entrada, payout y expiración con los datos actuales
+func (h *TradingHandler) buildQuote(ctx context.Context, userID int64, req TradeQuoteRequest) (*models.TradeQuote, error) {
+	priceData, err := h.priceService.GetPrice(req.Symbol)
+	if err != nil {
+		return nil, errInvalidSymbol
+	}
+
+	optionType := requestOptionType(req)
+	direction, high, low, err := optionTerms(req, optionType, priceData.Price)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiryMode, expiresAt, duration, err := expiryTerms(req, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Payout vigente para activo, tipo de opción, duración y usuario; queda fijado en el trade.
+	// En touch/range valida también la distancia de las barreras al precio actual.
+	payout, err := h.payouts.ResolveOption(ctx, userID, req.Symbol, optionType, duration, req.Amount, priceData.Price, high, low)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TradeQuote{
+		UserID:       userID,
+		Symbol:       req.Symbol,
+		Direction:    direction,
+		Amount:       req.Amount,
+		Duration:     duration,
+		IsDemo:       req.IsDemo,
+		TournamentID: req.TournamentID,
+		OptionType:   optionType,
+		BarrierHigh:  high,
+		BarrierLow:   low,
+		EntryPrice:   priceData.Price,
+		Payout:       payout,
+		CreatedAt:    now,
+		ExpiresAt:    expiresAt,
+		ExpiryMode:   expiryMode,
+		Timeframe:    req.Timeframe,
+	}, nil
+}
+
+// respondQuoteError traduce errores de cotización a respuestas HTTP
+func respondQuoteError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errInvalidSymbol):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Símbolo no válido"})
+	case errors.Is(err, errInvalidOption):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "INVALID_OPTION",
+		})
+	case errors.Is(err, trading.ErrOptionNotOffered):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Tipo de opción no disponible para este activo",
+			"code":  "OPTION_NOT_OFFERED",
+		})
+	case errors.Is(err, trading.ErrBarrierDistance):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "INVALID_BARRIER",
+		})
+	case errors.Is(err, errInvalidExpiry):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+			"code":  "INVALID_EXPIRY",
+		})
+	case errors.Is(err, trading.ErrQuoteNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Cotización no encontrada",
+			"code":  "QUOTE_NOT_FOUND",
+		})
+	case errors.Is(err, trading.ErrQuoteExpired):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "La cotización expiró, solicita una nueva",
+			"code":  "QUOTE_EXPIRED",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo payout"})
+	}
+}
+
+// respondRiskError responde el rechazo de la validación pre-trade.
+// Devuelve false si err no es un rechazo de riesgo.
+func respondRiskError(c *gin.Context, err error) bool {
+	var riskErr *trading.RiskError
+	if !errors.As(err, &riskErr) {
+		return false
+	}
+
+	status := http.StatusBadRequest
+	if riskErr.Code == trading.RiskTradingBlocked {
+		status = http.StatusForbidden
+	}
+	body := gin.H{
+		"error": riskErr.Message,
+		"code":  riskErr.Code,
+	}
+	if riskErr.Limit != "" {
+		body["limit"] = riskErr.Limit
+		body["limit_value"] = riskErr.Value
+	}
+	c.JSON(status, body)
+	return true
+}
+
+// checkMarket verifica que el mercado esté abierto durante toda la operación
+func (h *TradingHandler) checkMarket(c *gin.Context, symbol string, openAt, expiresAt time.Time) bool {
+	err := h.calendar.CheckTrade(symbol, openAt, expiresAt)
+	if err == nil {
+		return true
+	}
+
+	var marketErr *trading.MarketError
+	if !errors.As(err, &marketErr) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error verificando horario de mercado"})
+		return false
+	}
+	body := gin.H{
+		"error": marketErr.Message,
+		"code":  marketErr.Code,
+	}
+	if marketErr.NextOpen != nil {
+		body["next_open"] = marketErr.NextOpen
+	}
+	if marketErr.ClosesAt != nil {
+		body["closes_at"] = marketErr.ClosesAt
+	}
+	c.JSON(http.StatusBadRequest, body)
+	return false
+}
+
+// checkTournament verifica que el torneo esté en curso durante toda la operación
+// y que el usuario participe en él
+func (h *TradingHandler) checkTournament(c *gin.Context, userID int64, tournamentID *int64, openAt, expiresAt time.Time) bool {
+	if tournamentID == nil {
+		return true
+	}
+
+	tournament, err := h.tournaments.GetByID(*tournamentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Torneo no encontrado", "code": "TOURNAMENT_NOT_FOUND"})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo torneo"})
+		return false
+	}
+
+	if tournament.Status != models.TournamentActive || openAt.Before(tournament.StartsAt) || !openAt.Before(tournament.EndsAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "El torneo no está en curso", "code": "TOURNAMENT_NOT_ACTIVE"})
+		return false
+	}
+	if expiresAt.After(tournament.EndsAt) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "La operación expiraría después del fin del torneo",
+			"code":    "EXPIRY_AFTER_TOURNAMENT_END",
+			"ends_at": tournament.EndsAt,
+		})
+		return false
+	}
+
+	if _, err := h.tournaments.GetParticipant(*tournamentID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "No participas en este torneo", "code": "NOT_TOURNAMENT_PARTICIPANT"})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo participante"})
+		return false
+	}
+	return true
+}
+
+// reserveExposure reserva la exposición del trade y responde el rechazo si
+// supera la exposición neta máxima del símbolo
+func (h *TradingHandler) reserveExposure(c *gin.Context, trade *models.Trade) bool {
+	err := h.engine.ReserveExposure(trade)
+	if err == nil {
+		return true
+	}
+
+	var exposureErr *trading.ExposureError
+	if !errors.As(err, &exposureErr) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error verificando exposición"})
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":       "El símbolo alcanzó su exposición máxima, intenta más tarde o en la dirección contraria",
+		"code":        trading.RiskExposureLimit,
+		"limit":       "max_net_exposure",
+		"limit_value": exposureErr.Limit,
+	})
+	return false
+}
+
+// checkAccepting rechaza nuevas operaciones mientras el servidor se apaga
+func (h *TradingHandler) checkAccepting(c *gin.Context) bool {
+	if h.engine.Accepting() {
+		return true
+	}
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"error": "El servidor se está reiniciando, intenta nuevamente en unos segundos",
+		"code":  "TRADING_UNAVAILABLE",
+	})
+	return false
+}
+
+// checkRisk aplica la validación pre-trade y responde si la operación se rechaza.
+// Los trades demo y de torneo no cuentan para límites de dinero real (isDemo).
+// Devuelve la verificación de uso que se repite al persistir el trade.
+func (h *TradingHandler) checkRisk(c *gin.Context, userID int64, symbol string, amount float64, isDemo bool) (trading.UsageCheck, bool) {
+	checkUsage, err := h.risk.Check(c.Request.Context(), userID, symbol, amount, isDemo)
+	if err == nil {
+		return checkUsage, true
+	}
+	if !respondRiskError(c, err) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error validando límites de trading"})
+	}
+	return nil, false
+}
+
+// QuoteTrade cotiza una operación. El precio, el payout y la expiración devueltos
+// se respetan si se confirma con PlaceTrade (quote_id) antes de valid_until.
+func (h *TradingHandler) QuoteTrade(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
 		return
 	}
 
-	var req PlaceTradeRequest
+	var req TradeQuoteRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx := c.Request.Context()
+	if !h.checkAccepting(c) {
+		return
+	}
 
-	// Verificar balance
-	balance, err := h.userRepo.GetBalance(ctx, userID.(int64), req.IsDemo)
+	if _, ok := h.checkRisk(c, userID.(int64), req.Symbol, req.Amount, req.IsDemo || req.TournamentID != nil); !ok {
+		return
+	}
+
+	quote, err := h.buildQuote(c.Request.Context(), userID.(int64), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error obteniendo balance"})
+		respondQuoteError(c, err)
+		return
+	}
+
+	if !h.checkMarket(c, quote.Symbol, quote.CreatedAt, quote.ExpiresAt) {
+		return
+	}
+
+	if !h.checkTournament(c, userID.(int64), quote.TournamentID, quote.CreatedAt, quote.ExpiresAt) {
 		return
 	}
 
-	if req.Amount > balance {
+	c.JSON(http.StatusOK, gin.H{"quote": h.quotes.Issue(quote)})
+}
+
+// PlaceTrade coloca una nueva operación.
+// Con quote_id se ejecuta exactamente con los términos cotizados o se rechaza si expiró.
+// Acepta el header Idempotency-Key: un reintento con la misma clave devuelve el trade original.
+func (h *TradingHandler) PlaceTrade(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var req PlaceTradeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Balance insuficiente",
-			"code":  "INSUFFICIENT_BALANCE",
+			"error": "Idempotency-Key demasiado largo",
+			"code":  "INVALID_IDEMPOTENCY_KEY",
 		})
 		return
 	}
 
-	// Obtener precio actual
-	priceData, err := h.priceService.GetPrice(req.Symbol)
+	ctx := c.Request.Context()
+
+	// Reintento de una operación ya colocada (antes de consumir la cotización)
+	if idempotencyKey != "" {
+		existing, err := h.tradeRepo.GetTradeByIdempotencyKey(ctx, userID.(int64), idempotencyKey)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creando operación"})
+			return
+		}
+		if existing != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"message":  "Operación ya colocada",
+				"trade":    existing,
+				"replayed": true,
+			})
+			return
+		}
+	}
+
+	if !h.checkAccepting(c) {
+		return
+	}
+
+	var quote *models.TradeQuote
+	var err error
+	if req.QuoteID != "" {
+		quote, err = h.quotes.Take(req.QuoteID, userID.(int64), time.Now())
+		if err == nil && !quoteMatches(quote, req.TradeQuoteRequest) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "La operación no coincide con la cotización",
+				"code":  "QUOTE_MISMATCH",
+			})
+			return
+		}
+	} else {
+		quote, err = h.buildQuote(ctx, userID.(int64), req.TradeQuoteRequest)
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Símbolo no válido"})
+		respondQuoteError(c, err)
 		return
 	}
 
-	// Crear trade
+	// Horario de mercado al momento de ejecutar
+	if !h.checkMarket(c, quote.Symbol, time.Now(), quote.ExpiresAt) {
+		return
+	}
+
+	if !h.checkTournament(c, userID.(int64), quote.TournamentID, time.Now(), quote.ExpiresAt) {
+		return
+	}
+
+	// Bloqueos y límites se revalidan al confirmar: pueden cambiar tras la cotización
+	checkUsage, ok := h.checkRisk(c, userID.(int64), quote.Symbol, quote.Amount, quote.IsDemo || quote.TournamentID != nil)
+	if !ok {
+		return
+	}
+
+	// Crear trade con los términos cotizados
 	trade := &models.Trade{
-		UserID:     userID.(int64),
-		Symbol:     req.Symbol,
-		Direction:  models.TradeDirection(req.Direction),
-		Amount:     req.Amount,
-		EntryPrice: priceData.Price,
-		Duration:   req.Duration,
-		Status:     models.TradePending,
-		Payout:     85.0, // 85% de ganancia
-		IsDemo:     req.IsDemo,
-		CreatedAt:  time.Now(),
-		ExpiresAt:  time.Now().Add(time.Duration(req.Duration) * time.Second),
-	}
-
-	// Persistir trade en DB
-	if err := h.tradeRepo.CreateTrade(ctx, trade); err != nil {
+		UserID:       userID.(int64),
+		Symbol:       quote.Symbol,
+		Direction:    quote.Direction,
+		Amount:       quote.Amount,
+		EntryPrice:   quote.EntryPrice,
+		Duration:     quote.Duration,
+		Status:       models.TradePending,
+		Payout:       quote.Payout,
+		IsDemo:       quote.IsDemo,
+		TournamentID: quote.TournamentID,
+		OptionType:   quote.OptionType,
+		BarrierHigh:  quote.BarrierHigh,
+		BarrierLow:   quote.BarrierLow,
+		ExpiryMode:   quote.ExpiryMode,
+		Timeframe:    quote.Timeframe,
+		CreatedAt:    quote.CreatedAt,
+		ExpiresAt:    quote.ExpiresAt,
+	}
+
+	// Exposición neta del símbolo: se reserva antes de persistir para que dos
+	// órdenes simultáneas no superen juntas el límite
+	if !h.reserveExposure(c, trade) {
+		return
+	}
+	reserved := trade
+
+	// Persistir trade, descontar balance y verificar límites de uso en una sola transacción
+	trade, replayed, err := h.tradeRepo.PlaceTrade(ctx, trade, idempotencyKey, checkUsage)
+	if err != nil {
+		h.engine.ReleaseExposure(reserved)
+		if respondRiskError(c, err) {
+			return
+		}
+		if errors.Is(err, repositories.ErrInsufficientBalance) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Balance insuficiente",
+				"code":  "INSUFFICIENT_BALANCE",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creando operación"})
 		return
 	}
 
-	// Descontar del balance inmediatamente
-	if err := h.userRepo.UpdateBalance(ctx, userID.(int64), -req.Amount, req.IsDemo); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error actualizando balance"})
+	// Reintento con la misma clave: el trade ya está en el motor
+	if replayed {
+		h.engine.ReleaseExposure(reserved)
+		c.JSON(http.StatusOK, gin.H{
+			"message":  "Operación ya colocada",
+			"trade":    trade,
+			"replayed": true,
+		})
 		return
 	}
 
-	// Colocar trade en el motor
+	// Colocar trade en el motor. Si se está apagando, el trade ya persistido
+	// queda pending y se recupera al reiniciar.
 	if err := h.engine.PlaceTrade(trade); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al colocar operación"})
-		return
+		h.engine.ReleaseExposure(reserved)
+		if !errors.Is(err, trading.ErrEngineStopped) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al colocar operación"})
+			return
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -132,6 +596,47 @@ func (h *TradingHandler) PlaceTrade(c *gin.Context) {
 	})
 }
 
+// quoteMatches verifica que la orden confirmada sea la cotizada
+func quoteMatches(quote *models.TradeQuote, req TradeQuoteRequest) bool {
+	if quote.Symbol != req.Symbol ||
+		quote.OptionType != requestOptionType(req) ||
+		quote.Amount != req.Amount ||
+		!expiryMatches(quote, req) ||
+		quote.IsDemo != req.IsDemo ||
+		!samePtr(quote.TournamentID, req.TournamentID) {
+		return false
+	}
+
+	switch {
+	case quote.OptionType.IsBarrier():
+		barrier := quote.BarrierHigh
+		if barrier == nil {
+			barrier = quote.BarrierLow
+		}
+		return samePtr(barrier, req.Barrier)
+	case quote.OptionType.IsRange():
+		return samePtr(quote.BarrierLow, req.BarrierLow) && samePtr(quote.BarrierHigh, req.BarrierHigh)
+	default:
+		return string(quote.Direction) == req.Direction
+	}
+}
+
+// expiryMatches compara duración (modo duration) o timeframe (modo candle)
+func expiryMatches(quote *models.TradeQuote, req TradeQuoteRequest) bool {
+	if quote.ExpiryMode == models.ExpiryCandle {
+		return req.ExpiryMode == string(models.ExpiryCandle) && quote.Timeframe == req.Timeframe
+	}
+	return req.ExpiryMode != string(models.ExpiryCandle) && quote.Duration == req.Duration
+}
+
+// samePtr compara dos valores opcionales
+func samePtr[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
 // GetActiveTrades obtiene las operaciones activas del usuario
 func (h *TradingHandler) GetActiveTrades(c *gin.Context) {
 	userID, exists := c.Get("userID")
@@ -194,7 +699,7 @@ func (h *TradingHandler) GetTradeStats(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"stats": stats})
 }
 
-// CancelTrade cancela una operación activa
+// CancelTrade cierra una operación activa antes de expirar y devuelve el monto acreditado
 func (h *TradingHandler) CancelTrade(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -209,12 +714,77 @@ func (h *TradingHandler) CancelTrade(c *gin.Context) {
 		return
 	}
 
-	if err := h.engine.CancelTrade(tradeID, userID.(int64)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al cancelar operación"})
+	trade, err := h.engine.CancelTrade(c.Request.Context(), tradeID, userID.(int64))
+	if err != nil {
+		switch {
+		case errors.Is(err, trading.ErrTradeNotFound):
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Operación no encontrada o ya cerrada",
+				"code":  "TRADE_NOT_FOUND",
+			})
+		case errors.Is(err, trading.ErrEngineStopped):
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "El servidor se está reiniciando, intenta nuevamente en unos segundos",
+				"code":  "TRADING_UNAVAILABLE",
+			})
+		case errors.Is(err, trading.ErrSellBackClosed):
+			c.JSON(http.StatusConflict, gin.H{
+				"error": "La operación está por expirar y ya no puede cerrarse",
+				"code":  "SELL_BACK_UNAVAILABLE",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al cerrar operación"})
+		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Operación cancelada"})
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Operación cerrada",
+		"trade":           trade,
+		"realized_amount": trade.Amount + trade.Profit,
+	})
+}
+
+// GetTradeSettlement devuelve el registro de liquidación de una operación cerrada del usuario
+// GET /api/protected/trades/:id/settlement
+func (h *TradingHandler) GetTradeSettlement(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	tradeID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de operación inválido"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	trade, err := h.tradeRepo.GetTradeByID(ctx, tradeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener operación"})
+		return
+	}
+	if trade == nil || trade.UserID != userID.(int64) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Operación no encontrada", "code": "TRADE_NOT_FOUND"})
+		return
+	}
+
+	settlement, err := h.tradeRepo.GetSettlement(ctx, tradeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener liquidación"})
+		return
+	}
+	if settlement == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "La operación no tiene registro de liquidación",
+			"code":  "SETTLEMENT_NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"trade": trade, "settlement": settlement})
 }
 
 // GetPrices obtiene todos los precios
@@ -226,7 +796,7 @@ func (h *TradingHandler) GetPrices(c *gin.Context) {
 // GetPricesByMarket obtiene precios por mercado
 func (h *TradingHandler) GetPricesByMarket(c *gin.Context) {
 	marketType := c.Param("market")
-	
+
 	var mt models.MarketType
 	switch marketType {
 	case "crypto":
@@ -249,7 +819,7 @@ func (h *TradingHandler) GetPricesByMarket(c *gin.Context) {
 // GetPrice obtiene el precio de un símbolo específico
 func (h *TradingHandler) GetPrice(c *gin.Context) {
 	symbol := c.Param("symbol")
-	
+
 	price, err := h.priceService.GetPrice(symbol)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -259,7 +829,7 @@ func (h *TradingHandler) GetPrice(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"price": price})
 }
 
-// GetMarkets obtiene la lista de mercados disponibles
+// GetMarkets obtiene la lista de mercados disponibles con su estado (abierto/cerrado)
 func (h *TradingHandler) GetMarkets(c *gin.Context) {
 	markets := []gin.H{
 		{
@@ -292,6 +862,11 @@ func (h *TradingHandler) GetMarkets(c *gin.Context) {
 		},
 	}
 
+	now := time.Now()
+	for _, market := range markets {
+		market["status"] = h.calendar.Status(models.MarketType(market["id"].(string)), now)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"markets": markets})
 }
 
@@ -365,7 +940,7 @@ func generateHistoricalCandles(basePrice float64, count int, intervalMs int64) [
 	for i := count - 1; i >= 0; i-- {
 		volatility := basePrice * 0.003
 		trend := float64(i%20-10) / 100 * volatility
-		change := (float64(time.Now().UnixNano()%1000)/1000 - 0.48) * volatility + trend
+		change := (float64(time.Now().UnixNano()%1000)/1000-0.48)*volatility + trend
 
 		open := price
 		close := price + change
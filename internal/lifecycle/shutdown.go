@@ -0,0 +1,43 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Step paso del apagado ordenado
+type Step struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Shutdown ejecuta los pasos en orden dentro de un plazo total. Un paso que falla
+// o vence no impide ejecutar los siguientes (ej. cerrar la DB aunque queden
+// sockets abiertos); los errores se devuelven combinados.
+func Shutdown(ctx context.Context, timeout time.Duration, steps ...Step) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var errs []error
+	for i, step := range steps {
+		start := time.Now()
+		if err := step.Run(ctx); err != nil {
+			log.Printf("[shutdown] %d/%d %s: error tras %s: %v", i+1, len(steps), step.Name, time.Since(start), err)
+			errs = append(errs, fmt.Errorf("%s: %w", step.Name, err))
+			continue
+		}
+		log.Printf("[shutdown] %d/%d %s (%s)", i+1, len(steps), step.Name, time.Since(start))
+	}
+	return errors.Join(errs...)
+}
+
+// Func adapta una función sin error ni contexto a Step.Run
+func Func(fn func()) func(ctx context.Context) error {
+	return func(context.Context) error {
+		fn()
+		return nil
+	}
+}
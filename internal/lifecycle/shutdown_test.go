@@ -0,0 +1,79 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestShutdownRunsStepsInOrder(t *testing.T) {
+	var order []string
+	step := func(name string, err error) Step {
+		return Step{Name: name, Run: func(context.Context) error {
+			order = append(order, name)
+			return err
+		}}
+	}
+	errEngine := errors.New("cierres pendientes")
+
+	err := Shutdown(context.Background(), time.Second,
+		step("http", nil),
+		step("engine", errEngine),
+		Step{Name: "hub", Run: Func(func() { order = append(order, "hub") })},
+		step("db", nil),
+	)
+
+	want := []string{"http", "engine", "hub", "db"}
+	if !reflect.DeepEqual(order, want) {
+		t.Fatalf("orden = %v, se esperaba %v", order, want)
+	}
+	// Un paso que falla no impide los siguientes y su error se devuelve con su nombre
+	if !errors.Is(err, errEngine) {
+		t.Fatalf("err = %v, se esperaba %v", err, errEngine)
+	}
+	if got := err.Error(); got != "engine: cierres pendientes" {
+		t.Errorf("err = %q", got)
+	}
+}
+
+func TestShutdownTimeoutIsShared(t *testing.T) {
+	var ran []string
+	var lastErr error
+
+	start := time.Now()
+	err := Shutdown(context.Background(), 50*time.Millisecond,
+		Step{Name: "lento", Run: func(ctx context.Context) error {
+			ran = append(ran, "lento")
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+		Step{Name: "db", Run: func(ctx context.Context) error {
+			// Los pasos posteriores corren aunque el plazo total ya venció
+			ran = append(ran, "db")
+			lastErr = ctx.Err()
+			return nil
+		}},
+	)
+	elapsed := time.Since(start)
+
+	if elapsed < 50*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Shutdown tardó %s, se esperaba ~50ms", elapsed)
+	}
+	if !reflect.DeepEqual(ran, []string{"lento", "db"}) {
+		t.Fatalf("pasos ejecutados = %v", ran)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, se esperaba DeadlineExceeded", err)
+	}
+	if !errors.Is(lastErr, context.DeadlineExceeded) {
+		t.Errorf("ctx del paso siguiente = %v, se esperaba el plazo vencido", lastErr)
+	}
+}
+
+func TestShutdownWithoutErrors(t *testing.T) {
+	if err := Shutdown(context.Background(), time.Second, Step{Name: "noop", Run: Func(func() {})}); err != nil {
+		t.Fatalf("err = %v, se esperaba nil", err)
+	}
+}
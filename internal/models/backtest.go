@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// BacktestStatus estado de una sesión de backtesting
+type BacktestStatus string
+
+const (
+	BacktestActive      BacktestStatus = "active"      // Reproduciendo velas
+	BacktestPaused      BacktestStatus = "paused"      // Reproducción en pausa
+	BacktestCompleted   BacktestStatus = "completed"   // Se reprodujo todo el rango
+	BacktestStopped     BacktestStatus = "stopped"     // Detenida por el usuario
+	BacktestInterrupted BacktestStatus = "interrupted" // Detenida por un reinicio del servidor
+)
+
+// IsOpen indica si la sesión sigue reproduciéndose (activa o en pausa)
+func (s BacktestStatus) IsOpen() bool {
+	return s == BacktestActive || s == BacktestPaused
+}
+
+// BacktestSession reproducción de velas históricas (price_history) en la que el
+// usuario opera con un balance simulado, sin afectar su cuenta real ni demo
+type BacktestSession struct {
+	ID             int64          `json:"id"`
+	UserID         int64          `json:"user_id"`
+	Symbol         string         `json:"symbol"`
+	Timeframe      string         `json:"timeframe"`
+	StartDate      time.Time      `json:"start_date"`
+	EndDate        time.Time      `json:"end_date"`
+	Speed          float64        `json:"speed"` // Multiplicador del tiempo real (60 = 1 vela de 1m por segundo)
+	InitialBalance float64        `json:"initial_balance"`
+	Balance        float64        `json:"balance"` // Balance simulado
+	TradesMade     int            `json:"trades_made"`
+	ProfitLoss     float64        `json:"profit_loss"`
+	Status         BacktestStatus `json:"status"`
+	ReplayTime     *time.Time     `json:"replay_time"` // Momento de mercado reproducido
+	CreatedAt      time.Time      `json:"created_at"`
+	FinishedAt     *time.Time     `json:"finished_at"`
+}
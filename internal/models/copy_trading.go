@@ -0,0 +1,85 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// CopyMode forma en que un seguidor dimensiona los trades copiados
+type CopyMode string
+
+const (
+	CopyFixed      CopyMode = "fixed"      // Monto fijo por trade (copy_amount)
+	CopyPercentage CopyMode = "percentage" // Porcentaje del monto del líder (copy_percentage)
+)
+
+// CopyStatus estado de la suscripción de un seguidor
+type CopyStatus string
+
+const (
+	CopyActive  CopyStatus = "active"
+	CopyStopped CopyStatus = "stopped"
+)
+
+// CopyTrader perfil de un líder de copy trading. Las estadísticas cuentan solo sus
+// propios trades en cuenta real (no los copiados).
+type CopyTrader struct {
+	ID                int64     `json:"id"`
+	UserID            int64     `json:"user_id"`
+	DisplayName       string    `json:"display_name"`
+	Bio               string    `json:"bio"`
+	AvatarURL         string    `json:"avatar_url"`
+	MinCopyAmount     float64   `json:"min_copy_amount"` // Monto mínimo por trade copiado (modo fixed)
+	ProfitShare       float64   `json:"profit_share"`    // Porcentaje de la ganancia de los seguidores (ej: 20)
+	TotalCopiers      int       `json:"total_copiers"`   // Seguidores activos
+	TotalProfit       float64   `json:"total_profit"`
+	WinRate           float64   `json:"win_rate"`
+	TotalTrades       int       `json:"total_trades"`
+	WinningTrades     int       `json:"winning_trades"`
+	ProfitShareEarned float64   `json:"profit_share_earned"` // Comisiones cobradas a seguidores
+	IsVerified        bool      `json:"is_verified"`
+	IsActive          bool      `json:"is_active"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// CopyRelationship suscripción de un seguidor a un líder
+type CopyRelationship struct {
+	ID              int64      `json:"id"`
+	CopierID        int64      `json:"copier_id"`
+	TraderID        int64      `json:"trader_id"`      // copy_traders.id
+	LeaderUserID    int64      `json:"leader_user_id"` // users.id del líder
+	LeaderName      string     `json:"leader_name"`
+	CopyMode        CopyMode   `json:"copy_mode"`
+	CopyAmount      float64    `json:"copy_amount"`
+	CopyPercentage  float64    `json:"copy_percentage"`
+	TotalProfit     float64    `json:"total_profit"` // Resultado neto del seguidor (tras comisiones)
+	TotalTrades     int        `json:"total_trades"`
+	ProfitSharePaid float64    `json:"profit_share_paid"`
+	Status          CopyStatus `json:"status"`
+	StartedAt       time.Time  `json:"started_at"`
+	StoppedAt       *time.Time `json:"stopped_at"`
+}
+
+// Stake monto del trade copiado a partir del monto del líder
+func (r *CopyRelationship) Stake(leaderAmount float64) float64 {
+	if r.CopyMode == CopyPercentage {
+		return math.Round(leaderAmount*r.CopyPercentage) / 100
+	}
+	return r.CopyAmount
+}
+
+// CopyLeaderStats estadísticas de un líder
+type CopyLeaderStats struct {
+	Trader         *CopyTrader `json:"trader"`
+	ActiveCopiers  int         `json:"active_copiers"`
+	CopiedTrades   int         `json:"copied_trades"`   // Trades abiertos por seguidores
+	FollowerProfit float64     `json:"follower_profit"` // Resultado neto acumulado de los seguidores
+}
+
+// CopyFollowerStats estadísticas de un seguidor
+type CopyFollowerStats struct {
+	ActiveLeaders   int     `json:"active_leaders"`
+	CopiedTrades    int     `json:"copied_trades"`
+	TotalProfit     float64 `json:"total_profit"` // Neto tras comisiones
+	ProfitSharePaid float64 `json:"profit_share_paid"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// ExportFormat formato del archivo de historial exportado
+type ExportFormat string
+
+const (
+	ExportCSV  ExportFormat = "csv"
+	ExportXLSX ExportFormat = "xlsx"
+	ExportPDF  ExportFormat = "pdf"
+)
+
+// IsValid indica si el formato se puede generar
+func (f ExportFormat) IsValid() bool {
+	return f == ExportCSV || f == ExportXLSX || f == ExportPDF
+}
+
+// ExportStatus estado de una exportación: pending → processing → ready → expired
+// (failed si no se pudo generar el archivo)
+type ExportStatus string
+
+const (
+	ExportPending    ExportStatus = "pending"    // En cola
+	ExportProcessing ExportStatus = "processing" // Generando el archivo
+	ExportReady      ExportStatus = "ready"      // Archivo disponible hasta ExpiresAt
+	ExportFailed     ExportStatus = "failed"     // Error al generar el archivo
+	ExportExpired    ExportStatus = "expired"    // Archivo eliminado
+)
+
+// TradeExport exportación del historial de trades (trade_history_exports)
+type TradeExport struct {
+	ID          int64        `json:"id"`
+	UserID      int64        `json:"user_id"`
+	Format      ExportFormat `json:"format"`
+	DateFrom    string       `json:"date_from,omitempty"` // YYYY-MM-DD, por fecha de cierre
+	DateTo      string       `json:"date_to,omitempty"`   // YYYY-MM-DD, inclusive
+	Symbol      string       `json:"symbol,omitempty"`    // Vacío: todos los símbolos
+	IsDemo      bool         `json:"is_demo"`
+	Status      ExportStatus `json:"status"`
+	FilePath    string       `json:"-"`
+	FileSize    int64        `json:"file_size,omitempty"`
+	RowsCount   int          `json:"rows_count"`
+	Error       string       `json:"error,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	CompletedAt *time.Time   `json:"completed_at"`
+	ExpiresAt   *time.Time   `json:"expires_at"` // Hasta cuándo se conserva el archivo
+
+	// DownloadURL enlace firmado de descarga; solo en exportaciones listas
+	DownloadURL       string     `json:"download_url,omitempty"`
+	DownloadExpiresAt *time.Time `json:"download_expires_at,omitempty"`
+}
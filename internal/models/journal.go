@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// JournalEmotion estado emocional registrado en una entrada del diario
+type JournalEmotion string
+
+const (
+	EmotionConfident  JournalEmotion = "confident"
+	EmotionCalm       JournalEmotion = "calm"
+	EmotionExcited    JournalEmotion = "excited"
+	EmotionFearful    JournalEmotion = "fearful"
+	EmotionAnxious    JournalEmotion = "anxious"
+	EmotionGreedy     JournalEmotion = "greedy"
+	EmotionFrustrated JournalEmotion = "frustrated"
+	EmotionImpatient  JournalEmotion = "impatient"
+	EmotionBored      JournalEmotion = "bored"
+	EmotionNeutral    JournalEmotion = "neutral"
+)
+
+// JournalEntry entrada del diario de trading ligada a un trade del usuario (una por trade)
+type JournalEntry struct {
+	ID             int64          `json:"id"`
+	UserID         int64          `json:"user_id"`
+	TradeID        int64          `json:"trade_id"`
+	EntryReason    string         `json:"entry_reason"`
+	ExitReason     string         `json:"exit_reason"`
+	Emotion        JournalEmotion `json:"emotion,omitempty"`
+	LessonsLearned string         `json:"lessons_learned"`
+	Rating         *int           `json:"rating"` // 1-5, autoevaluación de la ejecución
+	Tags           []string       `json:"tags"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+
+	// Datos del trade (solo lectura)
+	Symbol      string         `json:"symbol"`
+	Direction   TradeDirection `json:"direction"`
+	Amount      float64        `json:"amount"`
+	TradeStatus TradeStatus    `json:"trade_status"`
+	Profit      float64        `json:"profit"`
+	IsDemo      bool           `json:"is_demo"`
+	TradedAt    time.Time      `json:"traded_at"`
+}
+
+// JournalFilter filtros del listado del diario
+type JournalFilter struct {
+	Tag     string
+	Emotion JournalEmotion
+	IsDemo  *bool
+	Limit   int
+	Offset  int
+}
+
+// JournalBucket resultado de los trades cerrados con entrada en el diario, agrupados
+// por etiqueta, emoción o calificación. WinRate se calcula sobre won + lost.
+type JournalBucket struct {
+	Key        string  `json:"key"`
+	Trades     int     `json:"trades"`
+	Wins       int     `json:"wins"`
+	Losses     int     `json:"losses"`
+	WinRate    float64 `json:"win_rate"`
+	ProfitLoss float64 `json:"profit_loss"`
+}
+
+// JournalAnalytics win rate y P&L del diario por etiqueta, emoción y calificación
+type JournalAnalytics struct {
+	Overall   JournalBucket   `json:"overall"`
+	ByTag     []JournalBucket `json:"by_tag"`
+	ByEmotion []JournalBucket `json:"by_emotion"`
+	ByRating  []JournalBucket `json:"by_rating"`
+}
+
+// JournalPrompt invitación a completar el diario enviada al cerrar un trade
+type JournalPrompt struct {
+	TradeID   int64          `json:"trade_id"`
+	Symbol    string         `json:"symbol"`
+	Direction TradeDirection `json:"direction"`
+	Status    TradeStatus    `json:"status"`
+	Profit    float64        `json:"profit"`
+	EntryID   *int64         `json:"entry_id"` // Entrada existente (sin motivo de salida)
+}
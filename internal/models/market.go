@@ -22,6 +22,32 @@ type Asset struct {
 	CreatedAt  time.Time  `json:"created_at"`
 }
 
+// TradingSession horario de un mercado para un día de la semana (trading_hours).
+// Los horarios están en UTC; si CloseSecond <= OpenSecond la sesión termina al día siguiente.
+type TradingSession struct {
+	MarketType  MarketType   `json:"market_type"`
+	DayOfWeek   time.Weekday `json:"day_of_week"`  // 0 = domingo
+	OpenSecond  int          `json:"open_second"`  // Segundos desde medianoche
+	CloseSecond int          `json:"close_second"` // Segundos desde medianoche
+	IsClosed    bool         `json:"is_closed"`
+}
+
+// MaintenanceWindow ventana de mantenimiento que suspende el trading
+type MaintenanceWindow struct {
+	ID       int64     `json:"id"`
+	Title    string    `json:"title"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// MarketStatus estado de un mercado en un instante
+type MarketStatus struct {
+	IsOpen   bool       `json:"is_open"`
+	Reason   string     `json:"reason,omitempty"`    // closed | maintenance
+	ClosesAt *time.Time `json:"closes_at,omitempty"` // Próximo cierre si está abierto
+	NextOpen *time.Time `json:"next_open,omitempty"` // Próxima apertura si está cerrado
+}
+
 // PriceData representa datos de precio en tiempo real
 type PriceData struct {
 	Symbol    string    `json:"symbol"`
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// OrderCondition condición de precio que activa una orden de entrada
+type OrderCondition string
+
+const (
+	OrderBelow OrderCondition = "below" // Se activa cuando el precio es <= trigger_price
+	OrderAbove OrderCondition = "above" // Se activa cuando el precio es >= trigger_price
+)
+
+// OrderStatus estado de una orden de entrada
+type OrderStatus string
+
+const (
+	OrderPending  OrderStatus = "pending"  // Esperando el precio de activación
+	OrderFilled   OrderStatus = "filled"   // Convertida en trade
+	OrderExpired  OrderStatus = "expired"  // Venció sin activarse (monto devuelto)
+	OrderCanceled OrderStatus = "canceled" // Cancelada por el usuario (monto devuelto)
+	OrderRejected OrderStatus = "rejected" // Activada pero no se pudo abrir el trade (monto devuelto)
+)
+
+// OrderReasonDemoReset motivo de las órdenes demo canceladas por el reset de la cuenta (sin devolución)
+const OrderReasonDemoReset = "demo_reset"
+
+// EntryOrder orden condicional que abre un trade high_low cuando el precio cruza
+// TriggerPrice. El monto se reserva (descuenta del balance) al crear la orden.
+type EntryOrder struct {
+	ID           int64          `json:"id"`
+	UserID       int64          `json:"user_id"`
+	Symbol       string         `json:"symbol"`
+	Direction    TradeDirection `json:"direction"`
+	Amount       float64        `json:"amount"`
+	Duration     int            `json:"duration"` // Duración del trade en segundos desde la activación
+	Payout       float64        `json:"payout"`   // Payout fijado al crear la orden
+	IsDemo       bool           `json:"is_demo"`
+	TriggerPrice float64        `json:"trigger_price"`
+	Condition    OrderCondition `json:"condition"`
+	Status       OrderStatus    `json:"status"`
+	TradeID      *int64         `json:"trade_id"`   // Trade abierto al activarse
+	FillPrice    *float64       `json:"fill_price"` // Precio del tick que activó la orden
+	Reason       string         `json:"reason,omitempty"`
+	ValidUntil   time.Time      `json:"valid_until"`
+	CreatedAt    time.Time      `json:"created_at"`
+	ClosedAt     *time.Time     `json:"closed_at"`
+}
+
+// Triggered indica si el precio cumple la condición de la orden
+func (o *EntryOrder) Triggered(price float64) bool {
+	if o.Condition == OrderAbove {
+		return price >= o.TriggerPrice
+	}
+	return price <= o.TriggerPrice
+}
@@ -0,0 +1,30 @@
+package models
+
+// PayoutBracket configuración de payout por símbolo y duración (operation_payout_config)
+type PayoutBracket struct {
+	DurationSeconds      int      `json:"duration_seconds"`
+	BasePayout           float64  `json:"base_payout"`
+	MinPayout            *float64 `json:"min_payout"`
+	MaxPayout            *float64 `json:"max_payout"`
+	VolatilityAdjustment float64  `json:"volatility_adjustment"`
+}
+
+// PayoutRule regla de ajuste de payout de un activo (operator_asset_payout_rules)
+type PayoutRule struct {
+	RuleName       string             `json:"rule_name"`
+	ConditionType  string             `json:"condition_type"` // duration, amount
+	ConditionValue map[string]float64 `json:"condition_value"`
+	Adjustment     float64            `json:"payout_adjustment"`
+	Priority       int                `json:"priority"`
+}
+
+// PayoutSources configuración vigente que determina el payout de un trade
+type PayoutSources struct {
+	AssetPayout        *float64       `json:"asset_payout"`         // payout_percentage del activo
+	OptionPayout       *float64       `json:"option_payout"`        // payout del activo para el tipo de opción
+	Bracket            *PayoutBracket `json:"bracket"`              // tramo de duración aplicable
+	Rules              []PayoutRule   `json:"rules"`                // reglas activas del activo
+	UserAdjustment     *float64       `json:"user_adjustment"`      // override del usuario
+	MinBarrierDistance float64        `json:"min_barrier_distance"` // % del precio de entrada (con OptionPayout)
+	MaxBarrierDistance float64        `json:"max_barrier_distance"` // % del precio de entrada (con OptionPayout)
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// TradingBlockRule bloqueo de trading vigente de un usuario (user_trading_blocks)
+type TradingBlockRule struct {
+	BlockType      string     `json:"block_type"`
+	BlockedSymbols []string   `json:"blocked_symbols"` // Vacío: aplica a todos los símbolos
+	MaxAmount      *float64   `json:"max_amount"`      // Si se define, solo limita el monto
+	Reason         string     `json:"reason"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+}
+
+// RiskProfile datos que consulta la validación pre-trade para un usuario y símbolo.
+// Los límites nil no aplican.
+type RiskProfile struct {
+	Blocks        []TradingBlockRule `json:"blocks"`
+	MinStake      *float64           `json:"min_stake"`       // Mínimo por operación
+	MaxStake      *float64           `json:"max_stake"`       // Máximo por operación
+	MaxOpenTrades *int               `json:"max_open_trades"` // Operaciones abiertas simultáneas
+	DailyLimit    *float64           `json:"daily_limit"`     // Volumen diario (solo cuenta real)
+	OpenTrades    int                `json:"open_trades"`
+	DailyVolume   float64            `json:"daily_volume"`
+}
+
+// ExposureBucket stake abierto de un símbolo para un tramo de expiración
+type ExposureBucket struct {
+	ExpiresAt   time.Time `json:"expires_at"` // Inicio del tramo (minuto)
+	UpStake     float64   `json:"up_stake"`
+	DownStake   float64   `json:"down_stake"`
+	OtherStake  float64   `json:"other_stake"` // Opciones touch/range (sin dirección)
+	NetExposure float64   `json:"net_exposure"`
+	OpenTrades  int       `json:"open_trades"`
+}
+
+// SymbolExposure exposición neta de la casa en un símbolo (solo cuenta real).
+// NetExposure = UpStake - DownStake; positivo si hay más stake en "up".
+type SymbolExposure struct {
+	Symbol         string           `json:"symbol"`
+	UpStake        float64          `json:"up_stake"`
+	DownStake      float64          `json:"down_stake"`
+	OtherStake     float64          `json:"other_stake"`
+	NetExposure    float64          `json:"net_exposure"`
+	UpPayout       float64          `json:"up_payout"`   // Pago total si ganan los trades "up"
+	DownPayout     float64          `json:"down_payout"` // Pago total si ganan los trades "down"
+	OpenTrades     int              `json:"open_trades"`
+	MaxNetExposure *float64         `json:"max_net_exposure"` // nil: sin límite
+	Buckets        []ExposureBucket `json:"buckets"`
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// SignalStatus estado de una señal de trading
+type SignalStatus string
+
+const (
+	SignalActive SignalStatus = "active" // Esperando su evaluación
+	SignalClosed SignalStatus = "closed" // Evaluada (ver Result)
+)
+
+// SignalResult resultado de una señal evaluada al cumplirse su timeframe
+type SignalResult string
+
+const (
+	SignalHit  SignalResult = "hit"  // El precio se movió en la dirección indicada
+	SignalMiss SignalResult = "miss" // El precio se movió en contra o no cambió
+	SignalVoid SignalResult = "void" // Sin precio para evaluarla; no cuenta en el historial
+)
+
+// TradingSignal señal publicada por un proveedor verificado. El precio de entrada
+// y la fecha los fija el servidor al publicarla.
+type TradingSignal struct {
+	ID           int64          `json:"id"`
+	ProviderID   int64          `json:"provider_id"` // users.id del proveedor
+	ProviderName string         `json:"provider_name"`
+	Symbol       string         `json:"symbol"`
+	Direction    TradeDirection `json:"direction"`
+	Timeframe    string         `json:"timeframe"`
+	Confidence   int            `json:"confidence"` // 1-100
+	Analysis     string         `json:"analysis,omitempty"`
+	EntryPrice   float64        `json:"entry_price"`
+	ExitPrice    *float64       `json:"exit_price"`
+	Status       SignalStatus   `json:"status"`
+	Result       *SignalResult  `json:"result"`
+	CreatedAt    time.Time      `json:"created_at"`
+	ExpiresAt    time.Time      `json:"expires_at"` // Momento de evaluación (created_at + timeframe)
+	ClosedAt     *time.Time     `json:"closed_at"`
+}
+
+// Score evalúa la señal con el precio al vencimiento
+func (s *TradingSignal) Score(exitPrice float64) SignalResult {
+	if s.Direction == TradeUp && exitPrice > s.EntryPrice {
+		return SignalHit
+	}
+	if s.Direction == TradeDown && exitPrice < s.EntryPrice {
+		return SignalHit
+	}
+	return SignalMiss
+}
+
+// SignalProvider proveedor de señales con su historial verificable. Las rachas se
+// cuentan en señales evaluadas consecutivas: CurrentStreak es positiva en aciertos
+// y negativa en fallos.
+type SignalProvider struct {
+	UserID        int64      `json:"user_id"`
+	DisplayName   string     `json:"display_name"`
+	Bio           string     `json:"bio"`
+	IsVerified    bool       `json:"is_verified"`
+	VerifiedAt    *time.Time `json:"verified_at"`
+	TotalSignals  int        `json:"total_signals"` // Evaluadas (sin contar void)
+	Hits          int        `json:"hits"`
+	Misses        int        `json:"misses"`
+	HitRate       float64    `json:"hit_rate"`
+	CurrentStreak int        `json:"current_streak"`
+	BestStreak    int        `json:"best_streak"`  // Mayor racha de aciertos
+	WorstStreak   int        `json:"worst_streak"` // Mayor racha de fallos
+	Subscribers   int        `json:"subscribers"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Record suma una señal evaluada al historial. Las señales void no cuentan.
+func (p *SignalProvider) Record(result SignalResult) {
+	switch result {
+	case SignalHit:
+		p.Hits++
+		if p.CurrentStreak > 0 {
+			p.CurrentStreak++
+		} else {
+			p.CurrentStreak = 1
+		}
+		if p.CurrentStreak > p.BestStreak {
+			p.BestStreak = p.CurrentStreak
+		}
+	case SignalMiss:
+		p.Misses++
+		if p.CurrentStreak < 0 {
+			p.CurrentStreak--
+		} else {
+			p.CurrentStreak = -1
+		}
+		if -p.CurrentStreak > p.WorstStreak {
+			p.WorstStreak = -p.CurrentStreak
+		}
+	default:
+		return
+	}
+	p.TotalSignals++
+	p.HitRate = math.Round(float64(p.Hits)*10000/float64(p.TotalSignals)) / 100
+}
+
+// Estados de una suscripción a señales
+const (
+	SignalSubscriptionActive   = "active"
+	SignalSubscriptionCanceled = "canceled"
+)
+
+// SignalSubscription suscripción de un usuario a un proveedor
+type SignalSubscription struct {
+	ID           int64      `json:"id"`
+	UserID       int64      `json:"user_id"`
+	ProviderID   int64      `json:"provider_id"`
+	ProviderName string     `json:"provider_name"`
+	Status       string     `json:"status"`
+	SubscribedAt time.Time  `json:"subscribed_at"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+}
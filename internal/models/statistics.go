@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// DailyStatistics resultado de los trades cerrados en un día (calendario de P&L)
+type DailyStatistics struct {
+	Date   string  `json:"date"` // YYYY-MM-DD
+	Trades int     `json:"trades"`
+	Wins   int     `json:"wins"`
+	Losses int     `json:"losses"`
+	Volume float64 `json:"volume"`
+	Profit float64 `json:"profit"` // Suma de las ganancias
+	Loss   float64 `json:"loss"`   // Suma de las pérdidas (positiva)
+	Net    float64 `json:"net"`
+}
+
+// SymbolStatistics resultado acumulado por símbolo
+type SymbolStatistics struct {
+	Symbol     string  `json:"symbol"`
+	Trades     int     `json:"trades"`
+	Wins       int     `json:"wins"`
+	Losses     int     `json:"losses"`
+	WinRate    float64 `json:"win_rate"`
+	Volume     float64 `json:"volume"`
+	NetProfit  float64 `json:"net_profit"`
+	BestTrade  float64 `json:"best_trade"`
+	WorstTrade float64 `json:"worst_trade"`
+}
+
+// HourlyStatistics resultado de los trades abiertos en una hora del día (UTC)
+type HourlyStatistics struct {
+	Hour    int     `json:"hour"`
+	Trades  int     `json:"trades"`
+	Wins    int     `json:"wins"`
+	Losses  int     `json:"losses"`
+	WinRate float64 `json:"win_rate"`
+	Net     float64 `json:"net"`
+}
+
+// TradeStreaks rachas de trades ganados/perdidos consecutivos (empates y recompras no
+// las cortan). CurrentStreak es positiva en ganadas y negativa en perdidas.
+type TradeStreaks struct {
+	CurrentStreak   int        `json:"current_streak"`
+	BestWinStreak   int        `json:"best_win_streak"`
+	WorstLossStreak int        `json:"worst_loss_streak"`
+	LastTradeAt     *time.Time `json:"last_trade_at"`
+}
+
+// Record suma un trade ganado o perdido a las rachas. Un trade cerrado antes del último
+// registrado (historial cargado por el backfill) no modifica la racha actual.
+func (s *TradeStreaks) Record(won bool, at time.Time) {
+	if s.LastTradeAt != nil && at.Before(*s.LastTradeAt) {
+		return
+	}
+	if won {
+		if s.CurrentStreak > 0 {
+			s.CurrentStreak++
+		} else {
+			s.CurrentStreak = 1
+		}
+		if s.CurrentStreak > s.BestWinStreak {
+			s.BestWinStreak = s.CurrentStreak
+		}
+	} else {
+		if s.CurrentStreak < 0 {
+			s.CurrentStreak--
+		} else {
+			s.CurrentStreak = -1
+		}
+		if -s.CurrentStreak > s.WorstLossStreak {
+			s.WorstLossStreak = -s.CurrentStreak
+		}
+	}
+	s.LastTradeAt = &at
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 // TradeDirection representa la dirección de la operación
 type TradeDirection string
@@ -10,6 +13,35 @@ const (
 	TradeDown TradeDirection = "down" // Put/Venta
 )
 
+// OptionType tipo de opción binaria
+type OptionType string
+
+const (
+	OptionHighLow  OptionType = "high_low"  // Sube/baja respecto al precio de entrada
+	OptionTouch    OptionType = "touch"     // Gana si el precio toca la barrera antes de expirar
+	OptionNoTouch  OptionType = "no_touch"  // Gana si el precio nunca toca la barrera
+	OptionRangeIn  OptionType = "range_in"  // Gana si expira dentro de la banda
+	OptionRangeOut OptionType = "range_out" // Gana si expira fuera de la banda
+)
+
+// IsBarrier indica si la opción se evalúa contra una barrera en cada tick
+func (o OptionType) IsBarrier() bool {
+	return o == OptionTouch || o == OptionNoTouch
+}
+
+// IsRange indica si la opción se liquida contra una banda de precios
+func (o OptionType) IsRange() bool {
+	return o == OptionRangeIn || o == OptionRangeOut
+}
+
+// ExpiryMode forma en que se calcula la expiración del trade
+type ExpiryMode string
+
+const (
+	ExpiryDuration ExpiryMode = "duration" // Duración relativa en segundos
+	ExpiryCandle   ExpiryMode = "candle"   // Cierre de vela del timeframe indicado
+)
+
 // TradeStatus representa el estado de la operación
 type TradeStatus string
 
@@ -18,39 +50,97 @@ const (
 	TradeWon      TradeStatus = "won"      // Ganada
 	TradeLost     TradeStatus = "lost"     // Perdida
 	TradeCanceled TradeStatus = "canceled" // Cancelada
+	TradeDraw     TradeStatus = "draw"     // Empate (monto reembolsado)
+	TradeSold     TradeStatus = "sold"     // Cerrada antes de expirar (recompra)
 )
 
+// ErrTradeAlreadySettled el trade ya no está pending: otra vía lo liquidó antes
+var ErrTradeAlreadySettled = errors.New("trade ya liquidado")
+
 // Trade representa una operación de trading
 type Trade struct {
-	ID            int64          `json:"id"`
-	UserID        int64          `json:"user_id"`
-	Symbol        string         `json:"symbol"`
-	Direction     TradeDirection `json:"direction"`
-	Amount        float64        `json:"amount"`         // Monto invertido
-	EntryPrice    float64        `json:"entry_price"`    // Precio de entrada
-	ExitPrice     float64        `json:"exit_price"`     // Precio de salida
-	Duration      int            `json:"duration"`       // Duración en segundos
-	Status        TradeStatus    `json:"status"`
-	Payout        float64        `json:"payout"`         // Porcentaje de ganancia (ej: 85%)
-	Profit        float64        `json:"profit"`         // Ganancia/Pérdida real
-	IsManipulated bool           `json:"is_manipulated"` // Si fue manipulado por el algoritmo
-	IsDemo        bool           `json:"is_demo"`        // Si es cuenta demo
-	TournamentID  *int64         `json:"tournament_id"`  // ID del torneo (si aplica)
-	CreatedAt     time.Time      `json:"created_at"`
-	ExpiresAt     time.Time      `json:"expires_at"`
-	ClosedAt      *time.Time     `json:"closed_at"`
+	ID           int64          `json:"id"`
+	UserID       int64          `json:"user_id"`
+	Symbol       string         `json:"symbol"`
+	Direction    TradeDirection `json:"direction"`
+	Amount       float64        `json:"amount"`      // Monto invertido
+	EntryPrice   float64        `json:"entry_price"` // Precio de entrada
+	ExitPrice    float64        `json:"exit_price"`  // Precio de salida
+	Duration     int            `json:"duration"`    // Duración en segundos
+	Status       TradeStatus    `json:"status"`
+	Payout       float64        `json:"payout"`        // Porcentaje de ganancia (ej: 85%)
+	Profit       float64        `json:"profit"`        // Ganancia/Pérdida real
+	IsDemo       bool           `json:"is_demo"`       // Si es cuenta demo
+	TournamentID *int64         `json:"tournament_id"` // ID del torneo (si aplica)
+	OptionType   OptionType     `json:"option_type"`
+	BarrierHigh  *float64       `json:"barrier_high"` // Barrera superior (touch) o techo de la banda
+	BarrierLow   *float64       `json:"barrier_low"`  // Barrera inferior (touch) o piso de la banda
+	TouchedAt    *time.Time     `json:"touched_at"`   // Momento en que se tocó la barrera
+	ExpiryMode   ExpiryMode     `json:"expiry_mode"`
+	Timeframe    string         `json:"timeframe,omitempty"` // Solo en expiry_mode candle (1m, 5m, 15m)
+	CreatedAt    time.Time      `json:"created_at"`
+	ExpiresAt    time.Time      `json:"expires_at"`
+	ClosedAt     *time.Time     `json:"closed_at"`
+	CopiedFrom   *int64         `json:"copied_from,omitempty"` // Trade del líder (solo al colocar un trade copiado; ver copy_trades)
+
+	// Settlement registro de liquidación; se asigna al cerrar el trade
+	Settlement *SettlementRecord `json:"settlement,omitempty"`
 }
 
-// TradeResult para el algoritmo de manipulación
-type TradeResult struct {
-	TradeID       int64   `json:"trade_id"`
-	UserID        int64   `json:"user_id"`
-	Amount        float64 `json:"amount"`
-	IsWinner      bool    `json:"is_winner"`
-	WinnerType    string  `json:"winner_type"` // "small_investor", "big_investor"
-	ManipulatedAt time.Time
+// SettlementRule regla con la que se cerró un trade
+type SettlementRule string
+
+const (
+	RuleExpiryPrice  SettlementRule = "expiry_price"  // Precio vigente al expirar
+	RuleBarrierTouch SettlementRule = "barrier_touch" // Barrera tocada antes de expirar
+	RuleSellBack     SettlementRule = "sell_back"     // Cierre anticipado con valor de recompra
+	RuleRefund       SettlementRule = "refund"        // Sin precio válido: se reembolsa el monto
+	RuleVoid         SettlementRule = "void"          // Anulado sin crédito (reset de cuenta demo)
+)
+
+// Fuentes del precio usado en la liquidación
+const (
+	PriceSourceLiveFeed    = "live_feed"     // Historial en memoria del feed de precios
+	PriceSourceStoredTicks = "price_ticks"   // Ticks persistidos (recuperación tras reinicio)
+	PriceSourceHistory     = "price_history" // Velas históricas reproducidas (backtesting)
+)
+
+// SettlementRecord prueba auditable de cómo se liquidó un trade
+type SettlementRecord struct {
+	TradeID     int64          `json:"trade_id"`
+	Rule        SettlementRule `json:"rule"`
+	PriceSource string         `json:"price_source,omitempty"` // Vacío en reembolsos sin precio
+	ExitPrice   float64        `json:"exit_price"`             // Precio del tick de salida
+	Bid         float64        `json:"bid"`
+	Ask         float64        `json:"ask"`
+	TickAt      *time.Time     `json:"tick_at"` // Timestamp del tick de salida
+	SettledAt   time.Time      `json:"settled_at"`
+	Status      TradeStatus    `json:"status"`
+	Profit      float64        `json:"profit"`
+	Note        string         `json:"note,omitempty"` // Motivo del reembolso
 }
 
+// TradeQuote cotización firme de una operación, válida hasta ValidUntil
+type TradeQuote struct {
+	ID           string         `json:"quote_id"`
+	UserID       int64          `json:"-"`
+	Symbol       string         `json:"symbol"`
+	Direction    TradeDirection `json:"direction"`
+	Amount       float64        `json:"amount"`
+	Duration     int            `json:"duration"`
+	IsDemo       bool           `json:"is_demo"`
+	TournamentID *int64         `json:"tournament_id,omitempty"`
+	OptionType   OptionType     `json:"option_type"`
+	BarrierHigh  *float64       `json:"barrier_high,omitempty"`
+	BarrierLow   *float64       `json:"barrier_low,omitempty"`
+	ExpiryMode   ExpiryMode     `json:"expiry_mode"`
+	Timeframe    string         `json:"timeframe,omitempty"`
+	EntryPrice   float64        `json:"entry_price"`
+	Payout       float64        `json:"payout"`
+	CreatedAt    time.Time      `json:"created_at"`
+	ExpiresAt    time.Time      `json:"expires_at"`  // Expiración del trade si se confirma
+	ValidUntil   time.Time      `json:"valid_until"` // Límite para confirmar la cotización
+}
 
 // TradeStats estadísticas de trading de un usuario
 type TradeStats struct {
@@ -102,3 +102,13 @@ type WalletSummary struct {
 	TotalDeposits     float64 `json:"total_deposits"`
 	TotalWithdrawals  float64 `json:"total_withdrawals"`
 }
+
+// DemoReset reset del balance demo al valor inicial (demo_resets)
+type DemoReset struct {
+	ID              int64     `json:"id"`
+	UserID          int64     `json:"user_id"`
+	PreviousBalance float64   `json:"previous_balance"`
+	NewBalance      float64   `json:"new_balance"`
+	VoidedTrades    int       `json:"voided_trades"` // Trades demo abiertos anulados
+	ResetAt         time.Time `json:"reset_at"`
+}
@@ -2,12 +2,19 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"tormentus/internal/events"
+
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrRequestAlreadyProcessed la solicitud no existe o ya no está pendiente
+var ErrRequestAlreadyProcessed = errors.New("solicitud ya procesada")
+
 // AccountantRepository maneja las operaciones de BD para el contador
 type AccountantRepository struct {
 	pool *pgxpool.Pool
@@ -193,12 +200,13 @@ func (r *AccountantRepository) GetWithdrawalByID(ctx context.Context, id int64)
 	return w, err
 }
 
-// ApproveWithdrawal aprueba un retiro
+// ApproveWithdrawal aprueba un retiro y emite WithdrawalProcessed en la misma transacción
 func (r *AccountantRepository) ApproveWithdrawal(ctx context.Context, id, accountantID int64, txHash, notes string) error {
-	_, err := r.pool.Exec(ctx, `
+	err := r.processWithdrawal(ctx, id, accountantID, "approved", `
 		UPDATE withdrawal_requests 
 		SET status = 'approved', processed_by = $1, processed_at = NOW(), tx_hash = $2, notes = $3, updated_at = NOW()
-		WHERE id = $4
+		WHERE id = $4 AND status = 'pending'
+		RETURNING user_id, amount
 	`, accountantID, txHash, notes, id)
 	if err != nil {
 		return err
@@ -211,12 +219,13 @@ func (r *AccountantRepository) ApproveWithdrawal(ctx context.Context, id, accoun
 	return nil
 }
 
-// RejectWithdrawal rechaza un retiro
+// RejectWithdrawal rechaza un retiro y emite WithdrawalProcessed en la misma transacción
 func (r *AccountantRepository) RejectWithdrawal(ctx context.Context, id, accountantID int64, reason string) error {
-	_, err := r.pool.Exec(ctx, `
+	err := r.processWithdrawal(ctx, id, accountantID, "rejected", `
 		UPDATE withdrawal_requests 
 		SET status = 'rejected', processed_by = $1, processed_at = NOW(), rejection_reason = $2, updated_at = NOW()
-		WHERE id = $3
+		WHERE id = $3 AND status = 'pending'
+		RETURNING user_id, amount
 	`, accountantID, reason, id)
 	if err != nil {
 		return err
@@ -228,6 +237,31 @@ func (r *AccountantRepository) RejectWithdrawal(ctx context.Context, id, account
 	return nil
 }
 
+// processWithdrawal ejecuta el cambio de estado (que devuelve user_id y amount)
+// junto con el evento WithdrawalProcessed. Devuelve ErrRequestAlreadyProcessed si
+// el retiro ya no estaba pendiente.
+func (r *AccountantRepository) processWithdrawal(ctx context.Context, id, accountantID int64, status, query string, args ...interface{}) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	event := events.WithdrawalProcessedEvent{WithdrawalID: id, Status: status, ProcessedBy: accountantID}
+	err = tx.QueryRow(ctx, query, args...).Scan(&event.UserID, &event.Amount)
+	if err == pgx.ErrNoRows {
+		return ErrRequestAlreadyProcessed
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := insertOutboxEvents(ctx, tx, event); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
 // ========== DEPOSITS ==========
 
 // DepositRequest solicitud de depósito
@@ -308,16 +342,35 @@ func (r *AccountantRepository) GetDeposits(ctx context.Context, status string, l
 	return deposits, nil
 }
 
-// ConfirmDeposit confirma un depósito
+// ConfirmDeposit confirma un depósito y emite DepositConfirmed en la misma transacción.
+// Devuelve ErrRequestAlreadyProcessed si el depósito ya no estaba pendiente.
 func (r *AccountantRepository) ConfirmDeposit(ctx context.Context, id, accountantID int64, creditedAmount float64, notes string) error {
-	_, err := r.pool.Exec(ctx, `
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	event := events.DepositConfirmedEvent{DepositID: id, CreditedAmount: creditedAmount, ConfirmedBy: accountantID}
+	err = tx.QueryRow(ctx, `
 		UPDATE deposit_requests 
 		SET status = 'confirmed', confirmed_by = $1, confirmed_at = NOW(), credited_amount = $2, notes = $3, updated_at = NOW()
-		WHERE id = $4
-	`, accountantID, creditedAmount, notes, id)
+		WHERE id = $4 AND status = 'pending'
+		RETURNING user_id
+	`, accountantID, creditedAmount, notes, id).Scan(&event.UserID)
+	if err == pgx.ErrNoRows {
+		return ErrRequestAlreadyProcessed
+	}
 	if err != nil {
 		return err
 	}
+	if err := insertOutboxEvents(ctx, tx, event); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
 	r.pool.Exec(ctx, `
 		INSERT INTO deposit_confirmations (deposit_id, accountant_id, action, verified_tx_hash, verified_amount, notes)
 		VALUES ($1, $2, 'confirmed', true, true, $3)
@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+// BacktestRepository sesiones de backtesting y velas históricas (price_history)
+type BacktestRepository interface {
+	GetCandles(ctx context.Context, symbol, timeframe string, from, to time.Time, limit int) ([]models.CandleData, error)
+	CreateSession(ctx context.Context, session *models.BacktestSession) error
+	GetSession(ctx context.Context, id int64) (*models.BacktestSession, error)
+	GetUserSessions(ctx context.Context, userID int64, limit, offset int) ([]*models.BacktestSession, error)
+	GetSessionTrades(ctx context.Context, sessionID int64) ([]*models.Trade, error)
+	// RecordTrade guarda un trade simulado liquidado y actualiza los totales de la sesión
+	RecordTrade(ctx context.Context, session *models.BacktestSession, trade *models.Trade) error
+	// UpdateSessionState guarda estado, balance y posición de la reproducción
+	UpdateSessionState(ctx context.Context, session *models.BacktestSession) error
+	// InterruptOpenSessions marca como interrupted las sesiones abiertas (tras un reinicio)
+	InterruptOpenSessions(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+// CalendarRepository horarios de mercado y ventanas de mantenimiento
+type CalendarRepository interface {
+	GetTradingSessions(ctx context.Context) ([]models.TradingSession, error)
+	GetMaintenanceWindows(ctx context.Context, until time.Time) ([]models.MaintenanceWindow, error)
+}
@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"tormentus/internal/models"
+)
+
+// ErrCopyLeaderNotFound el líder no existe o no está activo
+var ErrCopyLeaderNotFound = errors.New("líder de copy trading no encontrado")
+
+// CopyTradingRepository líderes, seguidores y trades copiados
+type CopyTradingRepository interface {
+	// UpsertLeader crea el perfil de líder del usuario o lo actualiza y reactiva
+	UpsertLeader(ctx context.Context, trader *models.CopyTrader) error
+	// DeactivateLeader deja de replicar los trades del líder; false si no era líder activo
+	DeactivateLeader(ctx context.Context, userID int64) (bool, error)
+	GetLeader(ctx context.Context, id int64) (*models.CopyTrader, error)
+	GetLeaderByUserID(ctx context.Context, userID int64) (*models.CopyTrader, error)
+	GetLeaders(ctx context.Context, limit, offset int) ([]*models.CopyTrader, error)
+	GetActiveLeaderUserIDs(ctx context.Context) ([]int64, error)
+
+	// Follow crea o reactiva la suscripción (ErrCopyLeaderNotFound si el líder no está activo)
+	Follow(ctx context.Context, rel *models.CopyRelationship) error
+	// Unfollow detiene la suscripción; false si no estaba activa
+	Unfollow(ctx context.Context, copierID, traderID int64) (bool, error)
+	GetUserRelationships(ctx context.Context, copierID int64) ([]*models.CopyRelationship, error)
+	GetActiveFollowers(ctx context.Context, leaderUserID int64) ([]*models.CopyRelationship, error)
+
+	// PlaceCopyTrade inserta el trade del seguidor, descuenta el monto, verifica los límites
+	// de uso y registra la copia en una sola transacción. placed es false si el balance no alcanza.
+	PlaceCopyTrade(ctx context.Context, rel *models.CopyRelationship, trade *models.Trade, checkUsage func(openTrades int, dailyVolume float64) error) (placed bool, err error)
+
+	GetLeaderStats(ctx context.Context, userID int64) (*models.CopyLeaderStats, error)
+	GetFollowerStats(ctx context.Context, copierID int64) (*models.CopyFollowerStats, error)
+}
@@ -0,0 +1,29 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+// DemoResetCooldownError reset rechazado por no haber pasado el tiempo de espera
+type DemoResetCooldownError struct {
+	NextResetAt time.Time
+}
+
+func (e *DemoResetCooldownError) Error() string {
+	return fmt.Sprintf("reset de cuenta demo disponible a partir de %s", e.NextResetAt.Format(time.RFC3339))
+}
+
+// DemoRepository resets del balance demo
+type DemoRepository interface {
+	// NextResetAt devuelve cuándo se puede volver a resetear (nil: ya disponible)
+	NextResetAt(ctx context.Context, userID int64, cooldown time.Duration) (*time.Time, error)
+	// ResetDemoAccount fija el balance demo, persiste los trades anulados, cancela sin
+	// devolución las órdenes de entrada demo pendientes y registra el reset en una sola
+	// transacción. Devuelve *DemoResetCooldownError si no pasó cooldown.
+	ResetDemoAccount(ctx context.Context, userID int64, balance float64, cooldown time.Duration, voided []*models.Trade) (*models.DemoReset, error)
+	GetDemoResets(ctx context.Context, userID int64, limit, offset int) ([]*models.DemoReset, error)
+}
@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+
+	"tormentus/internal/models"
+)
+
+// EntryOrderRepository persistencia de órdenes de entrada condicionales
+type EntryOrderRepository interface {
+	// CreateOrder inserta la orden y reserva el monto (ErrInsufficientBalance si no alcanza)
+	CreateOrder(ctx context.Context, order *models.EntryOrder) error
+	GetOrderByID(ctx context.Context, id int64) (*models.EntryOrder, error)
+	GetUserOrders(ctx context.Context, userID int64, status string, limit, offset int) ([]*models.EntryOrder, error)
+	GetPendingOrders(ctx context.Context) ([]*models.EntryOrder, error)
+	// FillOrder marca la orden como activada e inserta el trade con el monto ya reservado.
+	// filled es false si la orden ya no estaba pendiente.
+	FillOrder(ctx context.Context, order *models.EntryOrder, trade *models.Trade) (filled bool, err error)
+	// CloseOrder cierra una orden pendiente (expired, canceled o rejected) y devuelve el monto.
+	// closed es false si la orden ya no estaba pendiente.
+	CloseOrder(ctx context.Context, order *models.EntryOrder) (closed bool, err error)
+}
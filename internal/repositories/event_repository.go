@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"tormentus/internal/events"
+)
+
+// EventRepository outbox transaccional de eventos de dominio
+type EventRepository interface {
+	Append(ctx context.Context, eventType events.Type, payload []byte) (int64, error)
+	RegisterSubscriber(ctx context.Context, name string) error
+	Pending(ctx context.Context, subscriber string, types []events.Type, limit int) ([]events.Event, error)
+	MarkDelivered(ctx context.Context, subscriber string, eventID int64) error
+	MarkFailed(ctx context.Context, subscriber string, eventID int64, errMsg string, retryIn time.Duration, dead bool) error
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
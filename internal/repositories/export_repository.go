@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+// ExportRepository exportaciones del historial de trades (trade_history_exports)
+type ExportRepository interface {
+	CreateExport(ctx context.Context, export *models.TradeExport) error
+	GetExport(ctx context.Context, id int64) (*models.TradeExport, error)
+	GetUserExports(ctx context.Context, userID int64, limit int) ([]*models.TradeExport, error)
+	// CountActiveExports exportaciones del usuario en cola o en proceso
+	CountActiveExports(ctx context.Context, userID int64) (int, error)
+	// ClaimPendingExport pasa la exportación más antigua en cola a processing (nil si no hay)
+	ClaimPendingExport(ctx context.Context) (*models.TradeExport, error)
+	// RequeueProcessingExports devuelve a la cola las exportaciones que quedaron en proceso
+	// (tras un reinicio)
+	RequeueProcessingExports(ctx context.Context) (int64, error)
+	// StreamExportTrades recorre en orden de cierre los trades cerrados que cumplen los
+	// filtros de la exportación
+	StreamExportTrades(ctx context.Context, export *models.TradeExport, fn func(*models.Trade) error) error
+	// CompleteExport marca la exportación como lista con su archivo; se conserva retention
+	CompleteExport(ctx context.Context, export *models.TradeExport, retention time.Duration) error
+	FailExport(ctx context.Context, id int64, message string) error
+	// ExpireExports marca como expired las exportaciones listas vencidas y las devuelve
+	// para eliminar sus archivos
+	ExpireExports(ctx context.Context) ([]*models.TradeExport, error)
+}
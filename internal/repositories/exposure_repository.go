@@ -0,0 +1,8 @@
+package repositories
+
+import "context"
+
+// ExposureRepository límites de exposición neta por símbolo configurados por operadores
+type ExposureRepository interface {
+	GetExposureLimits(ctx context.Context) (map[string]float64, error)
+}
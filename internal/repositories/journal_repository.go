@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"tormentus/internal/models"
+)
+
+// ErrJournalEntryExists el trade ya tiene una entrada en el diario
+var ErrJournalEntryExists = errors.New("el trade ya tiene una entrada en el diario")
+
+// JournalRepository diario de trading
+type JournalRepository interface {
+	// CreateEntry inserta la entrada (ErrJournalEntryExists si el trade ya tiene una)
+	CreateEntry(ctx context.Context, entry *models.JournalEntry) error
+	// UpdateEntry actualiza los campos editables; false si no existe o no es del usuario
+	UpdateEntry(ctx context.Context, entry *models.JournalEntry) (bool, error)
+	DeleteEntry(ctx context.Context, userID, id int64) (bool, error)
+	GetEntry(ctx context.Context, userID, id int64) (*models.JournalEntry, error)
+	GetEntryByTrade(ctx context.Context, tradeID int64) (*models.JournalEntry, error)
+	GetEntries(ctx context.Context, userID int64, filter models.JournalFilter) ([]*models.JournalEntry, error)
+	GetAnalytics(ctx context.Context, userID int64, isDemo *bool) (*models.JournalAnalytics, error)
+}
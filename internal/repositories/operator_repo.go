@@ -2282,6 +2282,7 @@ type TradingAsset struct {
 	RiskLevel        string   `json:"risk_level"`
 	VolatilityIndex  *float64 `json:"volatility_index"`
 	IconURL          *string  `json:"icon_url"`
+	MaxNetExposure   *float64 `json:"max_net_exposure"` // Exposición neta máxima; nil: sin límite
 }
 
 // GetTradingAssets obtiene activos de trading
@@ -2293,7 +2294,8 @@ func (r *OperatorRepository) GetTradingAssets(ctx context.Context, categoryID in
 		SELECT a.id, a.symbol, a.name, a.category_id, c.name as category_name, a.asset_type,
 			a.base_currency, a.quote_currency, a.min_trade_amount, a.max_trade_amount,
 			a.min_duration_seconds, a.max_duration_seconds, a.payout_percentage, a.spread,
-			a.is_active, a.is_featured, a.risk_level, a.volatility_index, a.icon_url
+			a.is_active, a.is_featured, a.risk_level, a.volatility_index, a.icon_url,
+			a.max_net_exposure
 		FROM operator_trading_assets a
 		LEFT JOIN operator_asset_categories c ON a.category_id = c.id
 		WHERE 1=1
@@ -2328,7 +2330,8 @@ func (r *OperatorRepository) GetTradingAssets(ctx context.Context, categoryID in
 		if err := rows.Scan(&a.ID, &a.Symbol, &a.Name, &a.CategoryID, &a.CategoryName, &a.AssetType,
 			&a.BaseCurrency, &a.QuoteCurrency, &a.MinTradeAmount, &a.MaxTradeAmount,
 			&a.MinDuration, &a.MaxDuration, &a.PayoutPercentage, &a.Spread,
-			&a.IsActive, &a.IsFeatured, &a.RiskLevel, &a.VolatilityIndex, &a.IconURL); err != nil {
+			&a.IsActive, &a.IsFeatured, &a.RiskLevel, &a.VolatilityIndex, &a.IconURL,
+			&a.MaxNetExposure); err != nil {
 			return nil, err
 		}
 		assets = append(assets, a)
@@ -2347,13 +2350,13 @@ func (r *OperatorRepository) CreateTradingAsset(ctx context.Context, symbol, nam
 }
 
 // UpdateTradingAsset actualiza un activo
-func (r *OperatorRepository) UpdateTradingAsset(ctx context.Context, assetID int64, name string, categoryID *int64, minAmount, maxAmount float64, minDuration, maxDuration int, payout, spread float64, riskLevel string, isActive, isFeatured bool) error {
+func (r *OperatorRepository) UpdateTradingAsset(ctx context.Context, assetID int64, name string, categoryID *int64, minAmount, maxAmount float64, minDuration, maxDuration int, payout, spread float64, riskLevel string, isActive, isFeatured bool, maxNetExposure *float64) error {
 	_, err := r.pool.Exec(ctx, `
 		UPDATE operator_trading_assets SET name = $1, category_id = $2, min_trade_amount = $3, max_trade_amount = $4,
 			min_duration_seconds = $5, max_duration_seconds = $6, payout_percentage = $7, spread = $8,
-			risk_level = $9, is_active = $10, is_featured = $11, updated_at = NOW()
-		WHERE id = $12
-	`, name, categoryID, minAmount, maxAmount, minDuration, maxDuration, payout, spread, riskLevel, isActive, isFeatured, assetID)
+			risk_level = $9, is_active = $10, is_featured = $11, max_net_exposure = $12, updated_at = NOW()
+		WHERE id = $13
+	`, name, categoryID, minAmount, maxAmount, minDuration, maxDuration, payout, spread, riskLevel, isActive, isFeatured, maxNetExposure, assetID)
 	return err
 }
 
@@ -0,0 +1,12 @@
+package repositories
+
+import (
+	"context"
+
+	"tormentus/internal/models"
+)
+
+// PayoutRepository lee la configuración de payout definida por los operadores
+type PayoutRepository interface {
+	GetPayoutSources(ctx context.Context, userID int64, symbol string, optionType models.OptionType, duration int) (*models.PayoutSources, error)
+}
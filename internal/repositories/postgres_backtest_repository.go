@@ -0,0 +1,205 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"tormentus/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresBacktestRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresBacktestRepository(pool *pgxpool.Pool) *PostgresBacktestRepository {
+	return &PostgresBacktestRepository{pool: pool}
+}
+
+const backtestSessionColumns = `id, user_id, symbol, timeframe, start_date, end_date, speed, initial_balance,
+	balance, trades_made, profit_loss, status, replay_time, created_at, finished_at`
+
+// GetCandles velas de price_history del rango [from, to], en orden cronológico
+func (r *PostgresBacktestRepository) GetCandles(ctx context.Context, symbol, timeframe string, from, to time.Time, limit int) ([]models.CandleData, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT open, high, low, close, COALESCE(volume, 0), timestamp
+		FROM price_history
+		WHERE symbol = $1 AND timeframe = $2 AND timestamp >= $3 AND timestamp <= $4
+		ORDER BY timestamp
+		LIMIT $5
+	`, symbol, timeframe, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting price history: %w", err)
+	}
+	defer rows.Close()
+
+	var candles []models.CandleData
+	for rows.Next() {
+		candle := models.CandleData{Symbol: symbol}
+		if err := rows.Scan(&candle.Open, &candle.High, &candle.Low, &candle.Close, &candle.Volume, &candle.Timestamp); err != nil {
+			return nil, fmt.Errorf("error scanning price history: %w", err)
+		}
+		candles = append(candles, candle)
+	}
+	return candles, rows.Err()
+}
+
+// CreateSession inserta la sesión y asigna ID y fecha de creación
+func (r *PostgresBacktestRepository) CreateSession(ctx context.Context, session *models.BacktestSession) error {
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO backtesting_sessions (user_id, symbol, timeframe, start_date, end_date, speed,
+		                                  initial_balance, balance, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`, session.UserID, session.Symbol, session.Timeframe, session.StartDate, session.EndDate, session.Speed,
+		session.InitialBalance, session.Balance, string(session.Status)).Scan(&session.ID, &session.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating backtesting session: %w", err)
+	}
+	return nil
+}
+
+// GetSession obtiene una sesión por ID
+func (r *PostgresBacktestRepository) GetSession(ctx context.Context, id int64) (*models.BacktestSession, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+backtestSessionColumns+` FROM backtesting_sessions WHERE id = $1`, id)
+	session, err := scanBacktestSession(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting backtesting session: %w", err)
+	}
+	return session, nil
+}
+
+// GetUserSessions sesiones del usuario, de la más reciente a la más antigua
+func (r *PostgresBacktestRepository) GetUserSessions(ctx context.Context, userID int64, limit, offset int) ([]*models.BacktestSession, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+backtestSessionColumns+`
+		FROM backtesting_sessions
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error getting backtesting sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []*models.BacktestSession{}
+	for rows.Next() {
+		session, err := scanBacktestSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning backtesting session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// GetSessionTrades trades simulados liquidados de la sesión. El ID de cada trade es
+// su número dentro de la sesión.
+func (r *PostgresBacktestRepository) GetSessionTrades(ctx context.Context, sessionID int64) ([]*models.Trade, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT t.trade_no, s.user_id, s.symbol, t.direction, t.amount, t.entry_price, COALESCE(t.exit_price, 0),
+		       t.payout_percentage, t.duration, t.status, t.profit, t.opened_at, t.expires_at, t.closed_at
+		FROM backtesting_trades t
+		JOIN backtesting_sessions s ON s.id = t.session_id
+		WHERE t.session_id = $1
+		ORDER BY t.trade_no
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting backtesting trades: %w", err)
+	}
+	defer rows.Close()
+
+	trades := []*models.Trade{}
+	for rows.Next() {
+		trade := &models.Trade{OptionType: models.OptionHighLow, ExpiryMode: models.ExpiryDuration}
+		var direction, status string
+		if err := rows.Scan(&trade.ID, &trade.UserID, &trade.Symbol, &direction, &trade.Amount, &trade.EntryPrice,
+			&trade.ExitPrice, &trade.Payout, &trade.Duration, &status, &trade.Profit, &trade.CreatedAt,
+			&trade.ExpiresAt, &trade.ClosedAt); err != nil {
+			return nil, fmt.Errorf("error scanning backtesting trade: %w", err)
+		}
+		trade.Direction = models.TradeDirection(direction)
+		trade.Status = models.TradeStatus(status)
+		trades = append(trades, trade)
+	}
+	return trades, rows.Err()
+}
+
+// RecordTrade inserta el trade liquidado y actualiza totales y balance de la sesión
+// en una sola transacción
+func (r *PostgresBacktestRepository) RecordTrade(ctx context.Context, session *models.BacktestSession, trade *models.Trade) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO backtesting_trades (session_id, trade_no, direction, amount, entry_price, exit_price,
+		                                payout_percentage, duration, status, profit, opened_at, expires_at, closed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (session_id, trade_no) DO NOTHING
+	`, session.ID, trade.ID, string(trade.Direction), trade.Amount, trade.EntryPrice, trade.ExitPrice,
+		trade.Payout, trade.Duration, string(trade.Status), trade.Profit, trade.CreatedAt, trade.ExpiresAt, trade.ClosedAt)
+	if err != nil {
+		return fmt.Errorf("error recording backtesting trade: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE backtesting_sessions SET trades_made = $1, profit_loss = $2, balance = $3
+		WHERE id = $4
+	`, session.TradesMade, session.ProfitLoss, session.Balance, session.ID)
+	if err != nil {
+		return fmt.Errorf("error updating backtesting session: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing backtesting trade: %w", err)
+	}
+	return nil
+}
+
+// UpdateSessionState guarda estado, balance y posición de la reproducción
+func (r *PostgresBacktestRepository) UpdateSessionState(ctx context.Context, session *models.BacktestSession) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE backtesting_sessions SET status = $1, speed = $2, balance = $3, replay_time = $4, finished_at = $5
+		WHERE id = $6
+	`, string(session.Status), session.Speed, session.Balance, session.ReplayTime, session.FinishedAt, session.ID)
+	if err != nil {
+		return fmt.Errorf("error updating backtesting session: %w", err)
+	}
+	return nil
+}
+
+// InterruptOpenSessions marca como interrupted las sesiones que quedaron abiertas
+func (r *PostgresBacktestRepository) InterruptOpenSessions(ctx context.Context) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE backtesting_sessions SET status = $1, finished_at = NOW()
+		WHERE status IN ($2, $3)
+	`, string(models.BacktestInterrupted), string(models.BacktestActive), string(models.BacktestPaused))
+	if err != nil {
+		return 0, fmt.Errorf("error interrupting backtesting sessions: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func scanBacktestSession(row pgx.Row) (*models.BacktestSession, error) {
+	session := &models.BacktestSession{}
+	var status string
+	err := row.Scan(&session.ID, &session.UserID, &session.Symbol, &session.Timeframe, &session.StartDate,
+		&session.EndDate, &session.Speed, &session.InitialBalance, &session.Balance, &session.TradesMade,
+		&session.ProfitLoss, &status, &session.ReplayTime, &session.CreatedAt, &session.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	session.Status = models.BacktestStatus(status)
+	return session, nil
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tormentus/internal/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresCalendarRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresCalendarRepository(pool *pgxpool.Pool) *PostgresCalendarRepository {
+	return &PostgresCalendarRepository{pool: pool}
+}
+
+// GetTradingSessions obtiene los horarios de los mercados activos.
+// Si hay varios mercados del mismo tipo se usa el de menor id.
+func (r *PostgresCalendarRepository) GetTradingSessions(ctx context.Context) ([]models.TradingSession, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT DISTINCT ON (m.type, th.day_of_week)
+			m.type, th.day_of_week,
+			COALESCE(EXTRACT(EPOCH FROM th.open_time)::int, 0),
+			COALESCE(EXTRACT(EPOCH FROM th.close_time)::int, 86400),
+			COALESCE(th.is_closed, false)
+		FROM trading_hours th
+		JOIN markets m ON m.id = th.market_id
+		WHERE m.is_active = true
+		ORDER BY m.type, th.day_of_week, m.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error getting trading hours: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []models.TradingSession
+	for rows.Next() {
+		var s models.TradingSession
+		var marketType string
+		var day int
+		if err := rows.Scan(&marketType, &day, &s.OpenSecond, &s.CloseSecond, &s.IsClosed); err != nil {
+			return nil, err
+		}
+		s.MarketType = models.MarketType(marketType)
+		s.DayOfWeek = time.Weekday(day)
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// GetMaintenanceWindows obtiene las ventanas activas o futuras que afectan al trading
+// y comienzan antes de until. affected_services vacío aplica a todos los servicios.
+func (r *PostgresCalendarRepository) GetMaintenanceWindows(ctx context.Context, until time.Time) ([]models.MaintenanceWindow, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, title, starts_at, ends_at
+		FROM maintenance_windows
+		WHERE is_active = true AND ends_at > NOW() AND starts_at < $1
+		  AND (affected_services IS NULL
+		       OR affected_services = '[]'::jsonb
+		       OR affected_services ? 'trading')
+		ORDER BY starts_at
+	`, until)
+	if err != nil {
+		return nil, fmt.Errorf("error getting maintenance windows: %w", err)
+	}
+	defer rows.Close()
+
+	var windows []models.MaintenanceWindow
+	for rows.Next() {
+		var w models.MaintenanceWindow
+		if err := rows.Scan(&w.ID, &w.Title, &w.StartsAt, &w.EndsAt); err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+	return windows, rows.Err()
+}
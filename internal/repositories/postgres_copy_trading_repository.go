@@ -0,0 +1,466 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"tormentus/internal/events"
+	"tormentus/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresCopyTradingRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresCopyTradingRepository(pool *pgxpool.Pool) *PostgresCopyTradingRepository {
+	return &PostgresCopyTradingRepository{pool: pool}
+}
+
+const copyTraderColumns = `id, user_id, COALESCE(display_name, ''), COALESCE(bio, ''), COALESCE(avatar_url, ''),
+	COALESCE(min_copy_amount, 0), COALESCE(profit_share, 0), COALESCE(total_copiers, 0), COALESCE(total_profit, 0),
+	COALESCE(win_rate, 0), COALESCE(total_trades, 0), COALESCE(winning_trades, 0), COALESCE(profit_share_earned, 0),
+	COALESCE(is_verified, false), COALESCE(is_active, false), COALESCE(created_at, NOW())`
+
+const copyRelationshipQuery = `
+	SELECT r.id, r.copier_id, r.trader_id, t.user_id, COALESCE(t.display_name, ''), r.copy_mode, r.copy_amount,
+	       COALESCE(r.copy_percentage, 0), COALESCE(r.total_profit, 0), COALESCE(r.total_trades, 0),
+	       COALESCE(r.profit_share_paid, 0), COALESCE(r.status, 'active'), COALESCE(r.started_at, NOW()), r.stopped_at
+	FROM copy_relationships r
+	JOIN copy_traders t ON t.id = r.trader_id`
+
+// UpsertLeader crea el perfil de líder o lo actualiza y reactiva
+func (r *PostgresCopyTradingRepository) UpsertLeader(ctx context.Context, trader *models.CopyTrader) error {
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO copy_traders (user_id, display_name, bio, avatar_url, min_copy_amount, profit_share, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, TRUE)
+		ON CONFLICT (user_id) DO UPDATE SET
+			display_name = EXCLUDED.display_name,
+			bio = EXCLUDED.bio,
+			avatar_url = EXCLUDED.avatar_url,
+			min_copy_amount = EXCLUDED.min_copy_amount,
+			profit_share = EXCLUDED.profit_share,
+			is_active = TRUE
+		RETURNING `+copyTraderColumns,
+		trader.UserID, trader.DisplayName, trader.Bio, trader.AvatarURL, trader.MinCopyAmount, trader.ProfitShare)
+	saved, err := scanCopyTrader(row)
+	if err != nil {
+		return fmt.Errorf("error saving copy trader: %w", err)
+	}
+	*trader = *saved
+	return nil
+}
+
+// DeactivateLeader desactiva el perfil de líder del usuario
+func (r *PostgresCopyTradingRepository) DeactivateLeader(ctx context.Context, userID int64) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `UPDATE copy_traders SET is_active = FALSE WHERE user_id = $1 AND is_active`, userID)
+	if err != nil {
+		return false, fmt.Errorf("error deactivating copy trader: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetLeader obtiene un líder por ID
+func (r *PostgresCopyTradingRepository) GetLeader(ctx context.Context, id int64) (*models.CopyTrader, error) {
+	return r.getLeader(ctx, `SELECT `+copyTraderColumns+` FROM copy_traders WHERE id = $1`, id)
+}
+
+// GetLeaderByUserID obtiene el perfil de líder de un usuario
+func (r *PostgresCopyTradingRepository) GetLeaderByUserID(ctx context.Context, userID int64) (*models.CopyTrader, error) {
+	return r.getLeader(ctx, `SELECT `+copyTraderColumns+` FROM copy_traders WHERE user_id = $1`, userID)
+}
+
+func (r *PostgresCopyTradingRepository) getLeader(ctx context.Context, query string, arg int64) (*models.CopyTrader, error) {
+	trader, err := scanCopyTrader(r.pool.QueryRow(ctx, query, arg))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting copy trader: %w", err)
+	}
+	return trader, nil
+}
+
+// GetLeaders líderes activos ordenados por ganancia total
+func (r *PostgresCopyTradingRepository) GetLeaders(ctx context.Context, limit, offset int) ([]*models.CopyTrader, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+copyTraderColumns+`
+		FROM copy_traders
+		WHERE is_active
+		ORDER BY total_profit DESC NULLS LAST, id
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error getting copy traders: %w", err)
+	}
+	defer rows.Close()
+
+	traders := []*models.CopyTrader{}
+	for rows.Next() {
+		trader, err := scanCopyTrader(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning copy trader: %w", err)
+		}
+		traders = append(traders, trader)
+	}
+	return traders, rows.Err()
+}
+
+// GetActiveLeaderUserIDs usuarios con perfil de líder activo
+func (r *PostgresCopyTradingRepository) GetActiveLeaderUserIDs(ctx context.Context) ([]int64, error) {
+	rows, err := r.pool.Query(ctx, `SELECT user_id FROM copy_traders WHERE is_active`)
+	if err != nil {
+		return nil, fmt.Errorf("error getting copy traders: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("error scanning copy trader: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+// Follow crea o reactiva la suscripción con los montos indicados
+func (r *PostgresCopyTradingRepository) Follow(ctx context.Context, rel *models.CopyRelationship) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var active bool
+	err = tx.QueryRow(ctx, `SELECT COALESCE(is_active, false) FROM copy_traders WHERE id = $1 FOR UPDATE`, rel.TraderID).Scan(&active)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && !active) {
+		return ErrCopyLeaderNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error getting copy trader: %w", err)
+	}
+
+	var id int64
+	err = tx.QueryRow(ctx, `
+		INSERT INTO copy_relationships (copier_id, trader_id, copy_mode, copy_amount, copy_percentage, status, started_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (copier_id, trader_id) DO UPDATE SET
+			copy_mode = EXCLUDED.copy_mode,
+			copy_amount = EXCLUDED.copy_amount,
+			copy_percentage = EXCLUDED.copy_percentage,
+			started_at = CASE WHEN copy_relationships.status = EXCLUDED.status
+			                  THEN copy_relationships.started_at ELSE NOW() END,
+			status = EXCLUDED.status,
+			stopped_at = NULL
+		RETURNING id
+	`, rel.CopierID, rel.TraderID, string(rel.CopyMode), rel.CopyAmount, rel.CopyPercentage,
+		string(models.CopyActive)).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("error saving copy relationship: %w", err)
+	}
+
+	if err := updateCopierCount(ctx, tx, rel.TraderID); err != nil {
+		return err
+	}
+
+	saved, err := scanCopyRelationship(tx.QueryRow(ctx, copyRelationshipQuery+` WHERE r.id = $1`, id))
+	if err != nil {
+		return fmt.Errorf("error getting copy relationship: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing copy relationship: %w", err)
+	}
+	*rel = *saved
+	return nil
+}
+
+// Unfollow detiene la suscripción activa del seguidor al líder
+func (r *PostgresCopyTradingRepository) Unfollow(ctx context.Context, copierID, traderID int64) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE copy_relationships SET status = $1, stopped_at = NOW()
+		WHERE copier_id = $2 AND trader_id = $3 AND status = $4
+	`, string(models.CopyStopped), copierID, traderID, string(models.CopyActive))
+	if err != nil {
+		return false, fmt.Errorf("error stopping copy relationship: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if err := updateCopierCount(ctx, tx, traderID); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("error committing copy relationship: %w", err)
+	}
+	return true, nil
+}
+
+// updateCopierCount recalcula los seguidores activos del líder
+func updateCopierCount(ctx context.Context, tx pgx.Tx, traderID int64) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE copy_traders SET total_copiers = (
+			SELECT COUNT(*) FROM copy_relationships WHERE trader_id = $1 AND status = $2
+		)
+		WHERE id = $1
+	`, traderID, string(models.CopyActive))
+	if err != nil {
+		return fmt.Errorf("error updating copier count: %w", err)
+	}
+	return nil
+}
+
+// GetUserRelationships suscripciones del seguidor (activas y detenidas)
+func (r *PostgresCopyTradingRepository) GetUserRelationships(ctx context.Context, copierID int64) ([]*models.CopyRelationship, error) {
+	return r.queryRelationships(ctx, copyRelationshipQuery+`
+		WHERE r.copier_id = $1
+		ORDER BY r.status, r.started_at DESC
+	`, copierID)
+}
+
+// GetActiveFollowers suscripciones activas a un líder activo
+func (r *PostgresCopyTradingRepository) GetActiveFollowers(ctx context.Context, leaderUserID int64) ([]*models.CopyRelationship, error) {
+	return r.queryRelationships(ctx, copyRelationshipQuery+`
+		WHERE t.user_id = $1 AND t.is_active AND r.status = $2
+	`, leaderUserID, string(models.CopyActive))
+}
+
+func (r *PostgresCopyTradingRepository) queryRelationships(ctx context.Context, query string, args ...interface{}) ([]*models.CopyRelationship, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting copy relationships: %w", err)
+	}
+	defer rows.Close()
+
+	relationships := []*models.CopyRelationship{}
+	for rows.Next() {
+		rel, err := scanCopyRelationship(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning copy relationship: %w", err)
+		}
+		relationships = append(relationships, rel)
+	}
+	return relationships, rows.Err()
+}
+
+// PlaceCopyTrade inserta el trade copiado y descuenta el monto del balance real del
+// seguidor, verificando checkUsage en la misma transacción. Los eventos TradePlaced y
+// BalanceChanged se escriben en el outbox.
+func (r *PostgresCopyTradingRepository) PlaceCopyTrade(ctx context.Context, rel *models.CopyRelationship, trade *models.Trade, checkUsage func(openTrades int, dailyVolume float64) error) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertTrade(ctx, tx, trade); err != nil {
+		return false, err
+	}
+
+	tag, err := tx.Exec(ctx, `UPDATE users SET balance = balance - $1 WHERE id = $2 AND balance >= $1`, trade.Amount, trade.UserID)
+	if err != nil {
+		return false, fmt.Errorf("error debiting balance: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if err := checkTradeUsage(ctx, tx, trade, checkUsage); err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO copy_trades (original_trade_id, copied_trade_id, follower_id, trader_id, copy_amount)
+		VALUES ($1, $2, $3, $4, $5)
+	`, trade.CopiedFrom, trade.ID, rel.CopierID, rel.TraderID, trade.Amount)
+	if err != nil {
+		return false, fmt.Errorf("error recording copy trade: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE copy_relationships SET total_trades = COALESCE(total_trades, 0) + 1 WHERE id = $1`, rel.ID)
+	if err != nil {
+		return false, fmt.Errorf("error updating copy relationship: %w", err)
+	}
+
+	err = insertOutboxEvents(ctx, tx,
+		events.TradePlacedEvent{Trade: trade},
+		events.BalanceChangedEvent{
+			UserID: trade.UserID,
+			Amount: -trade.Amount,
+			Reason: events.ReasonTradePlaced,
+			RefID:  trade.ID,
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("error committing copy trade: %w", err)
+	}
+	return true, nil
+}
+
+// settleCopy registra dentro de la transacción de cierre un trade en cuenta real. Si es
+// un trade propio de un líder activo actualiza sus estadísticas; si es una copia,
+// transfiere la comisión (profit_share % de la ganancia) del seguidor al líder una sola vez.
+func settleCopy(ctx context.Context, tx pgx.Tx, trade *models.Trade) error {
+	var copyID, followerID, traderID, leaderUserID int64
+	var profitShare float64
+	var settled bool
+	err := tx.QueryRow(ctx, `
+		SELECT ct.id, ct.follower_id, ct.trader_id, t.user_id, COALESCE(t.profit_share, 0), ct.settled_at IS NOT NULL
+		FROM copy_trades ct
+		JOIN copy_traders t ON t.id = ct.trader_id
+		WHERE ct.copied_trade_id = $1
+		FOR UPDATE OF ct
+	`, trade.ID).Scan(&copyID, &followerID, &traderID, &leaderUserID, &profitShare, &settled)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// Trade propio: solo cuentan los trades con resultado
+		if trade.Status != models.TradeWon && trade.Status != models.TradeLost {
+			return nil
+		}
+		won := 0
+		if trade.Status == models.TradeWon {
+			won = 1
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE copy_traders SET
+				total_trades = COALESCE(total_trades, 0) + 1,
+				winning_trades = COALESCE(winning_trades, 0) + $2,
+				total_profit = COALESCE(total_profit, 0) + $3,
+				win_rate = ROUND(100.0 * (COALESCE(winning_trades, 0) + $2) / (COALESCE(total_trades, 0) + 1), 2)
+			WHERE user_id = $1 AND is_active
+		`, trade.UserID, won, trade.Profit)
+		if err != nil {
+			return fmt.Errorf("error updating copy trader stats: %w", err)
+		}
+		return nil
+	case err != nil:
+		return fmt.Errorf("error getting copy trade: %w", err)
+	case settled:
+		return nil
+	}
+
+	share := 0.0
+	if trade.Profit > 0 {
+		share = math.Round(trade.Profit*profitShare) / 100
+	}
+	return settleCopyShare(ctx, tx, trade, copyID, followerID, traderID, leaderUserID, share)
+}
+
+// settleCopyShare marca la copia como liquidada y transfiere la comisión al líder
+func settleCopyShare(ctx context.Context, tx pgx.Tx, trade *models.Trade, copyID, followerID, traderID, leaderUserID int64, share float64) error {
+	_, err := tx.Exec(ctx, `UPDATE copy_trades SET profit_share_amount = $1, settled_at = NOW() WHERE id = $2`, share, copyID)
+	if err != nil {
+		return fmt.Errorf("error settling copy trade: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE copy_relationships SET
+			total_profit = COALESCE(total_profit, 0) + $1,
+			profit_share_paid = COALESCE(profit_share_paid, 0) + $2
+		WHERE copier_id = $3 AND trader_id = $4
+	`, trade.Profit-share, share, followerID, traderID)
+	if err != nil {
+		return fmt.Errorf("error updating copy relationship: %w", err)
+	}
+
+	if share <= 0 {
+		return nil
+	}
+
+	// El crédito del trade ya se acreditó completo al seguidor
+	if _, err := tx.Exec(ctx, `UPDATE users SET balance = balance - $1 WHERE id = $2`, share, followerID); err != nil {
+		return fmt.Errorf("error debiting profit share: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE users SET balance = balance + $1 WHERE id = $2`, share, leaderUserID); err != nil {
+		return fmt.Errorf("error crediting profit share: %w", err)
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE copy_traders SET profit_share_earned = COALESCE(profit_share_earned, 0) + $1 WHERE id = $2
+	`, share, traderID)
+	if err != nil {
+		return fmt.Errorf("error updating copy trader earnings: %w", err)
+	}
+
+	return insertOutboxEvents(ctx, tx,
+		events.BalanceChangedEvent{UserID: followerID, Amount: -share, Reason: events.ReasonProfitShare, RefID: trade.ID},
+		events.BalanceChangedEvent{UserID: leaderUserID, Amount: share, Reason: events.ReasonProfitShare, RefID: trade.ID},
+	)
+}
+
+// GetLeaderStats estadísticas del líder; nil si el usuario no es líder
+func (r *PostgresCopyTradingRepository) GetLeaderStats(ctx context.Context, userID int64) (*models.CopyLeaderStats, error) {
+	trader, err := r.GetLeaderByUserID(ctx, userID)
+	if err != nil || trader == nil {
+		return nil, err
+	}
+
+	stats := &models.CopyLeaderStats{Trader: trader}
+	err = r.pool.QueryRow(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM copy_relationships WHERE trader_id = $1 AND status = $2),
+			(SELECT COUNT(*) FROM copy_trades WHERE trader_id = $1),
+			(SELECT COALESCE(SUM(total_profit), 0) FROM copy_relationships WHERE trader_id = $1)
+	`, trader.ID, string(models.CopyActive)).Scan(&stats.ActiveCopiers, &stats.CopiedTrades, &stats.FollowerProfit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting copy trader stats: %w", err)
+	}
+	return stats, nil
+}
+
+// GetFollowerStats totales de las suscripciones del seguidor
+func (r *PostgresCopyTradingRepository) GetFollowerStats(ctx context.Context, copierID int64) (*models.CopyFollowerStats, error) {
+	stats := &models.CopyFollowerStats{}
+	err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*) FILTER (WHERE status = $2), COALESCE(SUM(total_trades), 0),
+		       COALESCE(SUM(total_profit), 0), COALESCE(SUM(profit_share_paid), 0)
+		FROM copy_relationships
+		WHERE copier_id = $1
+	`, copierID, string(models.CopyActive)).Scan(&stats.ActiveLeaders, &stats.CopiedTrades, &stats.TotalProfit, &stats.ProfitSharePaid)
+	if err != nil {
+		return nil, fmt.Errorf("error getting copy follower stats: %w", err)
+	}
+	return stats, nil
+}
+
+func scanCopyTrader(row pgx.Row) (*models.CopyTrader, error) {
+	t := &models.CopyTrader{}
+	err := row.Scan(&t.ID, &t.UserID, &t.DisplayName, &t.Bio, &t.AvatarURL, &t.MinCopyAmount, &t.ProfitShare,
+		&t.TotalCopiers, &t.TotalProfit, &t.WinRate, &t.TotalTrades, &t.WinningTrades, &t.ProfitShareEarned,
+		&t.IsVerified, &t.IsActive, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func scanCopyRelationship(row pgx.Row) (*models.CopyRelationship, error) {
+	rel := &models.CopyRelationship{}
+	var mode, status string
+	err := row.Scan(&rel.ID, &rel.CopierID, &rel.TraderID, &rel.LeaderUserID, &rel.LeaderName, &mode, &rel.CopyAmount,
+		&rel.CopyPercentage, &rel.TotalProfit, &rel.TotalTrades, &rel.ProfitSharePaid, &status, &rel.StartedAt, &rel.StoppedAt)
+	if err != nil {
+		return nil, err
+	}
+	rel.CopyMode = models.CopyMode(mode)
+	rel.Status = models.CopyStatus(status)
+	return rel, nil
+}
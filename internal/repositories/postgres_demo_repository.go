@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"tormentus/internal/events"
+	"tormentus/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresDemoRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresDemoRepository(pool *pgxpool.Pool) *PostgresDemoRepository {
+	return &PostgresDemoRepository{pool: pool}
+}
+
+// NextResetAt devuelve el fin del cooldown del último reset, o nil si ya terminó
+func (r *PostgresDemoRepository) NextResetAt(ctx context.Context, userID int64, cooldown time.Duration) (*time.Time, error) {
+	return nextDemoResetAt(ctx, r.pool, userID, cooldown)
+}
+
+func nextDemoResetAt(ctx context.Context, q rowQuerier, userID int64, cooldown time.Duration) (*time.Time, error) {
+	var next time.Time
+	err := q.QueryRow(ctx, `
+		SELECT reset_at + make_interval(secs => $2)
+		FROM demo_resets
+		WHERE user_id = $1 AND reset_at > NOW() - make_interval(secs => $2)
+		ORDER BY reset_at DESC
+		LIMIT 1
+	`, userID, cooldown.Seconds()).Scan(&next)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting last demo reset: %w", err)
+	}
+	return &next, nil
+}
+
+// ResetDemoAccount bloquea al usuario, verifica el cooldown, anula los trades, cancela
+// las órdenes de entrada demo pendientes sin devolver el monto reservado y fija el
+// balance demo. Los eventos de balance y de cierre se escriben en el outbox.
+func (r *PostgresDemoRepository) ResetDemoAccount(ctx context.Context, userID int64, balance float64, cooldown time.Duration, voided []*models.Trade) (*models.DemoReset, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// El bloqueo de la fila serializa resets simultáneos del mismo usuario
+	reset := &models.DemoReset{UserID: userID, NewBalance: balance}
+	err = tx.QueryRow(ctx, `SELECT demo_balance FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&reset.PreviousBalance)
+	if err != nil {
+		return nil, fmt.Errorf("error locking user: %w", err)
+	}
+
+	next, err := nextDemoResetAt(ctx, tx, userID, cooldown)
+	if err != nil {
+		return nil, err
+	}
+	if next != nil {
+		return nil, &DemoResetCooldownError{NextResetAt: *next}
+	}
+
+	// Un trade que ya no está pendiente se liquidó por otra vía: no se registra su anulación
+	evts := make([]events.Payload, 0, len(voided)+1)
+	for _, trade := range voided {
+		tag, err := tx.Exec(ctx, `
+			UPDATE trades SET profit = $1, status = $2, closed_at = $3
+			WHERE id = $4 AND user_id = $5 AND status = $6
+		`, trade.Profit, string(trade.Status), trade.ClosedAt, trade.ID, userID, string(models.TradePending))
+		if err != nil {
+			return nil, fmt.Errorf("error voiding trade %d: %w", trade.ID, err)
+		}
+		if tag.RowsAffected() == 0 {
+			continue
+		}
+		reset.VoidedTrades++
+		if err := insertSettlement(ctx, tx, trade.Settlement); err != nil {
+			return nil, err
+		}
+		evts = append(evts, events.TradeSettledEvent{Trade: trade})
+	}
+
+	// El monto reservado no se devuelve: el balance demo se fija a continuación
+	_, err = tx.Exec(ctx, `
+		UPDATE entry_orders SET status = $1, reason = $2, closed_at = NOW()
+		WHERE user_id = $3 AND is_demo = TRUE AND status = $4
+	`, string(models.OrderCanceled), models.OrderReasonDemoReset, userID, string(models.OrderPending))
+	if err != nil {
+		return nil, fmt.Errorf("error canceling demo entry orders: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE users SET demo_balance = $1 WHERE id = $2`, balance, userID); err != nil {
+		return nil, fmt.Errorf("error resetting demo balance: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO demo_resets (user_id, previous_balance, new_balance, voided_trades)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, reset_at
+	`, userID, reset.PreviousBalance, balance, reset.VoidedTrades).Scan(&reset.ID, &reset.ResetAt)
+	if err != nil {
+		return nil, fmt.Errorf("error recording demo reset: %w", err)
+	}
+
+	evts = append(evts, events.BalanceChangedEvent{
+		UserID: userID,
+		Amount: balance - reset.PreviousBalance,
+		IsDemo: true,
+		Reason: events.ReasonDemoReset,
+		RefID:  reset.ID,
+	})
+	if err := insertOutboxEvents(ctx, tx, evts...); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing demo reset: %w", err)
+	}
+	return reset, nil
+}
+
+// GetDemoResets historial de resets del usuario, del más reciente al más antiguo
+func (r *PostgresDemoRepository) GetDemoResets(ctx context.Context, userID int64, limit, offset int) ([]*models.DemoReset, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, user_id, previous_balance, new_balance, voided_trades, reset_at
+		FROM demo_resets
+		WHERE user_id = $1
+		ORDER BY reset_at DESC
+		LIMIT $2 OFFSET $3
+	`, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error getting demo resets: %w", err)
+	}
+	defer rows.Close()
+
+	resets := []*models.DemoReset{}
+	for rows.Next() {
+		reset := &models.DemoReset{}
+		if err := rows.Scan(&reset.ID, &reset.UserID, &reset.PreviousBalance, &reset.NewBalance,
+			&reset.VoidedTrades, &reset.ResetAt); err != nil {
+			return nil, fmt.Errorf("error scanning demo reset: %w", err)
+		}
+		resets = append(resets, reset)
+	}
+	return resets, rows.Err()
+}
@@ -0,0 +1,230 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"tormentus/internal/events"
+	"tormentus/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresEntryOrderRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresEntryOrderRepository(pool *pgxpool.Pool) *PostgresEntryOrderRepository {
+	return &PostgresEntryOrderRepository{pool: pool}
+}
+
+const entryOrderColumns = `id, user_id, symbol, direction, amount, duration, payout_percentage, is_demo,
+	trigger_price, trigger_condition, status, trade_id, fill_price, COALESCE(reason, ''),
+	valid_until, created_at, closed_at`
+
+// CreateOrder inserta la orden y descuenta el monto del balance en una sola transacción.
+// El débito es condicional: sin saldo suficiente devuelve ErrInsufficientBalance.
+func (r *PostgresEntryOrderRepository) CreateOrder(ctx context.Context, order *models.EntryOrder) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO entry_orders (user_id, symbol, direction, amount, duration, payout_percentage, is_demo,
+		                          trigger_price, trigger_condition, status, valid_until, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id
+	`, order.UserID, order.Symbol, string(order.Direction), order.Amount, order.Duration, order.Payout, order.IsDemo,
+		order.TriggerPrice, string(order.Condition), string(order.Status), order.ValidUntil, order.CreatedAt).Scan(&order.ID)
+	if err != nil {
+		return fmt.Errorf("error creating entry order: %w", err)
+	}
+
+	query := `UPDATE users SET balance = balance - $1 WHERE id = $2 AND balance >= $1`
+	if order.IsDemo {
+		query = `UPDATE users SET demo_balance = demo_balance - $1 WHERE id = $2 AND demo_balance >= $1`
+	}
+	tag, err := tx.Exec(ctx, query, order.Amount, order.UserID)
+	if err != nil {
+		return fmt.Errorf("error reserving order amount: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrInsufficientBalance
+	}
+
+	err = insertOutboxEvents(ctx, tx, events.BalanceChangedEvent{
+		UserID: order.UserID,
+		Amount: -order.Amount,
+		IsDemo: order.IsDemo,
+		Reason: events.ReasonOrderReserve,
+		RefID:  order.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing entry order: %w", err)
+	}
+	return nil
+}
+
+// GetOrderByID obtiene una orden por ID
+func (r *PostgresEntryOrderRepository) GetOrderByID(ctx context.Context, id int64) (*models.EntryOrder, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+entryOrderColumns+` FROM entry_orders WHERE id = $1`, id)
+	order, err := scanEntryOrder(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting entry order: %w", err)
+	}
+	return order, nil
+}
+
+// GetUserOrders órdenes del usuario, de la más reciente a la más antigua.
+// status vacío o "all" no filtra.
+func (r *PostgresEntryOrderRepository) GetUserOrders(ctx context.Context, userID int64, status string, limit, offset int) ([]*models.EntryOrder, error) {
+	query := `SELECT ` + entryOrderColumns + ` FROM entry_orders WHERE user_id = $1`
+	args := []interface{}{userID}
+	if status != "" && status != "all" {
+		query += ` AND status = $2`
+		args = append(args, status)
+	}
+	query += fmt.Sprintf(` ORDER BY created_at DESC LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	return r.queryOrders(ctx, query, args...)
+}
+
+// GetPendingOrders órdenes pendientes de todos los usuarios (carga al iniciar)
+func (r *PostgresEntryOrderRepository) GetPendingOrders(ctx context.Context) ([]*models.EntryOrder, error) {
+	return r.queryOrders(ctx, `SELECT `+entryOrderColumns+` FROM entry_orders WHERE status = $1 ORDER BY created_at`,
+		string(models.OrderPending))
+}
+
+// FillOrder marca la orden como activada e inserta el trade en una sola transacción.
+// El monto ya fue descontado al crear la orden; TradePlaced se escribe en el outbox.
+// checkUsage (opcional) valida los límites de uso con los trades ya confirmados.
+// Si la orden ya no estaba pendiente no inserta nada y devuelve false.
+func (r *PostgresEntryOrderRepository) FillOrder(ctx context.Context, order *models.EntryOrder, trade *models.Trade, checkUsage func(openTrades int, dailyVolume float64) error) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertTrade(ctx, tx, trade); err != nil {
+		return false, err
+	}
+	if err := checkTradeUsage(ctx, tx, trade, checkUsage); err != nil {
+		return false, err
+	}
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE entry_orders SET status = $1, trade_id = $2, fill_price = $3, closed_at = $4
+		WHERE id = $5 AND status = $6
+	`, string(models.OrderFilled), trade.ID, trade.EntryPrice, trade.CreatedAt, order.ID, string(models.OrderPending))
+	if err != nil {
+		return false, fmt.Errorf("error filling entry order: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if err := insertOutboxEvents(ctx, tx, events.TradePlacedEvent{Trade: trade}); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("error committing entry order fill: %w", err)
+	}
+
+	order.Status = models.OrderFilled
+	order.TradeID = &trade.ID
+	order.FillPrice = &trade.EntryPrice
+	order.ClosedAt = &trade.CreatedAt
+	return true, nil
+}
+
+// CloseOrder cierra la orden con order.Status (expired, canceled o rejected) y
+// devuelve el monto reservado. Devuelve false si ya no estaba pendiente.
+func (r *PostgresEntryOrderRepository) CloseOrder(ctx context.Context, order *models.EntryOrder) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE entry_orders SET status = $1, reason = NULLIF($2, ''), closed_at = $3
+		WHERE id = $4 AND status = $5
+	`, string(order.Status), order.Reason, order.ClosedAt, order.ID, string(models.OrderPending))
+	if err != nil {
+		return false, fmt.Errorf("error closing entry order: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	query := `UPDATE users SET balance = balance + $1 WHERE id = $2`
+	if order.IsDemo {
+		query = `UPDATE users SET demo_balance = demo_balance + $1 WHERE id = $2`
+	}
+	if _, err := tx.Exec(ctx, query, order.Amount, order.UserID); err != nil {
+		return false, fmt.Errorf("error releasing order amount: %w", err)
+	}
+
+	err = insertOutboxEvents(ctx, tx, events.BalanceChangedEvent{
+		UserID: order.UserID,
+		Amount: order.Amount,
+		IsDemo: order.IsDemo,
+		Reason: events.ReasonOrderRelease,
+		RefID:  order.ID,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("error committing entry order close: %w", err)
+	}
+	return true, nil
+}
+
+func (r *PostgresEntryOrderRepository) queryOrders(ctx context.Context, query string, args ...interface{}) ([]*models.EntryOrder, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting entry orders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := []*models.EntryOrder{}
+	for rows.Next() {
+		order, err := scanEntryOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning entry order: %w", err)
+		}
+		orders = append(orders, order)
+	}
+	return orders, rows.Err()
+}
+
+func scanEntryOrder(row pgx.Row) (*models.EntryOrder, error) {
+	order := &models.EntryOrder{}
+	var direction, condition, status string
+	err := row.Scan(&order.ID, &order.UserID, &order.Symbol, &direction, &order.Amount, &order.Duration,
+		&order.Payout, &order.IsDemo, &order.TriggerPrice, &condition, &status, &order.TradeID,
+		&order.FillPrice, &order.Reason, &order.ValidUntil, &order.CreatedAt, &order.ClosedAt)
+	if err != nil {
+		return nil, err
+	}
+	order.Direction = models.TradeDirection(direction)
+	order.Condition = models.OrderCondition(condition)
+	order.Status = models.OrderStatus(status)
+	return order, nil
+}
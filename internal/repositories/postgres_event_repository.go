@@ -0,0 +1,182 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tormentus/internal/events"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresEventRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresEventRepository(pool *pgxpool.Pool) *PostgresEventRepository {
+	return &PostgresEventRepository{pool: pool}
+}
+
+// outboxExecutor pool o transacción donde se escribe el evento
+type outboxExecutor interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// insertOutboxEvents escribe eventos en el outbox; dentro de una transacción el
+// evento se publica solo si el cambio de dominio se confirma
+func insertOutboxEvents(ctx context.Context, exec outboxExecutor, payloads ...events.Payload) error {
+	for _, p := range payloads {
+		eventType, data, err := events.Encode(p)
+		if err != nil {
+			return fmt.Errorf("error serializando evento %s: %w", eventType, err)
+		}
+		_, err = exec.Exec(ctx, `INSERT INTO event_outbox (event_type, payload) VALUES ($1, $2)`, string(eventType), data)
+		if err != nil {
+			return fmt.Errorf("error guardando evento %s: %w", eventType, err)
+		}
+	}
+	return nil
+}
+
+// Append guarda un evento fuera de transacción
+func (r *PostgresEventRepository) Append(ctx context.Context, eventType events.Type, payload []byte) (int64, error) {
+	var id int64
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO event_outbox (event_type, payload) VALUES ($1, $2) RETURNING id`,
+		string(eventType), payload,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("error guardando evento %s: %w", eventType, err)
+	}
+	return id, nil
+}
+
+// RegisterSubscriber registra el suscriptor con el cursor en el último evento existente;
+// conserva la fecha y el cursor del primer registro
+func (r *PostgresEventRepository) RegisterSubscriber(ctx context.Context, name string) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO event_subscribers (name, delivered_through)
+		SELECT $1, COALESCE(MAX(id), 0) FROM event_outbox
+		ON CONFLICT (name) DO NOTHING`, name)
+	if err != nil {
+		return fmt.Errorf("error registrando suscriptor: %w", err)
+	}
+	return nil
+}
+
+// cursorLag antigüedad mínima de un evento para que el cursor lo pase sin entrega
+// registrada: un id menor puede confirmarse tarde si su transacción sigue abierta
+const cursorLag = 5 * time.Minute
+
+// advanceCursor mueve el cursor del suscriptor hasta antes del primer evento de sus
+// tipos sin entrega final (sin registro o fallido), sin pasar los eventos recientes
+func (r *PostgresEventRepository) advanceCursor(ctx context.Context, subscriber string, names []string) error {
+	query := `
+		UPDATE event_subscribers s
+		SET delivered_through = GREATEST(s.delivered_through, COALESCE(LEAST(
+			(SELECT MIN(e.id) - 1
+			 FROM event_outbox e
+			 LEFT JOIN event_deliveries d ON d.subscriber = s.name AND d.event_id = e.id
+			 WHERE e.id > s.delivered_through
+			   AND (cardinality($2::text[]) = 0 OR e.event_type = ANY($2))
+			   AND (d.event_id IS NULL OR d.status = 'failed')),
+			COALESCE((SELECT MAX(e.id)
+			 FROM event_outbox e
+			 WHERE e.id > s.delivered_through
+			   AND e.created_at < NOW() - make_interval(secs => $3)), s.delivered_through)
+		), s.delivered_through))
+		WHERE s.name = $1
+	`
+	if _, err := r.pool.Exec(ctx, query, subscriber, names, cursorLag.Seconds()); err != nil {
+		return fmt.Errorf("error advancing event cursor: %w", err)
+	}
+	return nil
+}
+
+// Pending eventos sin entregar al suscriptor (o fallidos con reintento vencido)
+// posteriores a su cursor, en orden de inserción. Sin tipos devuelve todos.
+func (r *PostgresEventRepository) Pending(ctx context.Context, subscriber string, types []events.Type, limit int) ([]events.Event, error) {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+
+	if err := r.advanceCursor(ctx, subscriber, names); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT e.id, e.event_type, e.payload, e.created_at, COALESCE(d.attempts, 0)
+		FROM event_outbox e
+		JOIN event_subscribers s ON s.name = $1
+		LEFT JOIN event_deliveries d ON d.subscriber = s.name AND d.event_id = e.id
+		WHERE e.id > s.delivered_through
+		  AND (cardinality($2::text[]) = 0 OR e.event_type = ANY($2))
+		  AND (d.event_id IS NULL OR (d.status = 'failed' AND d.next_attempt_at <= NOW()))
+		ORDER BY e.id
+		LIMIT $3
+	`
+
+	rows, err := r.pool.Query(ctx, query, subscriber, names, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting pending events: %w", err)
+	}
+	defer rows.Close()
+
+	var pending []events.Event
+	for rows.Next() {
+		var e events.Event
+		var eventType string
+		if err := rows.Scan(&e.ID, &eventType, &e.Payload, &e.CreatedAt, &e.Attempts); err != nil {
+			return nil, fmt.Errorf("error scanning event: %w", err)
+		}
+		e.Type = events.Type(eventType)
+		pending = append(pending, e)
+	}
+	return pending, rows.Err()
+}
+
+// MarkDelivered registra la entrega exitosa de un evento
+func (r *PostgresEventRepository) MarkDelivered(ctx context.Context, subscriber string, eventID int64) error {
+	query := `
+		INSERT INTO event_deliveries (subscriber, event_id, status, attempts, updated_at)
+		VALUES ($1, $2, 'delivered', 1, NOW())
+		ON CONFLICT (subscriber, event_id) DO UPDATE SET
+			status = 'delivered', attempts = event_deliveries.attempts + 1,
+			last_error = NULL, next_attempt_at = NULL, updated_at = NOW()
+	`
+	if _, err := r.pool.Exec(ctx, query, subscriber, eventID); err != nil {
+		return fmt.Errorf("error marking event delivered: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed registra un intento fallido y reprograma el evento tras retryIn (o lo descarta si dead)
+func (r *PostgresEventRepository) MarkFailed(ctx context.Context, subscriber string, eventID int64, errMsg string, retryIn time.Duration, dead bool) error {
+	status := "failed"
+	if dead {
+		status = "dead"
+	}
+	query := `
+		INSERT INTO event_deliveries (subscriber, event_id, status, attempts, last_error, next_attempt_at, updated_at)
+		VALUES ($1, $2, $3, 1, $4, NOW() + make_interval(secs => $5), NOW())
+		ON CONFLICT (subscriber, event_id) DO UPDATE SET
+			status = EXCLUDED.status, attempts = event_deliveries.attempts + 1,
+			last_error = EXCLUDED.last_error, next_attempt_at = EXCLUDED.next_attempt_at, updated_at = NOW()
+	`
+	if _, err := r.pool.Exec(ctx, query, subscriber, eventID, status, errMsg, retryIn.Seconds()); err != nil {
+		return fmt.Errorf("error marking event failed: %w", err)
+	}
+	return nil
+}
+
+// DeleteBefore elimina eventos antiguos (sus entregas se borran en cascada)
+func (r *PostgresEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM event_outbox WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting old events: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
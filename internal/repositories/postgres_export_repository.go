@@ -0,0 +1,214 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"tormentus/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresExportRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresExportRepository(pool *pgxpool.Pool) *PostgresExportRepository {
+	return &PostgresExportRepository{pool: pool}
+}
+
+const exportColumns = `
+	id, user_id, format, COALESCE(to_char(date_from, 'YYYY-MM-DD'), ''), COALESCE(to_char(date_to, 'YYYY-MM-DD'), ''),
+	COALESCE(symbol, ''), is_demo, COALESCE(status, 'pending'), COALESCE(file_path, ''), COALESCE(file_size, 0),
+	rows_count, COALESCE(error_message, ''), COALESCE(created_at, NOW()), completed_at, expires_at`
+
+func scanExport(row pgx.Row) (*models.TradeExport, error) {
+	var export models.TradeExport
+	var format, status string
+	err := row.Scan(&export.ID, &export.UserID, &format, &export.DateFrom, &export.DateTo,
+		&export.Symbol, &export.IsDemo, &status, &export.FilePath, &export.FileSize,
+		&export.RowsCount, &export.Error, &export.CreatedAt, &export.CompletedAt, &export.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	export.Format = models.ExportFormat(format)
+	export.Status = models.ExportStatus(status)
+	return &export, nil
+}
+
+func collectExports(rows pgx.Rows) ([]*models.TradeExport, error) {
+	defer rows.Close()
+
+	var exports []*models.TradeExport
+	for rows.Next() {
+		export, err := scanExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, export)
+	}
+	return exports, rows.Err()
+}
+
+// CreateExport encola la exportación
+func (r *PostgresExportRepository) CreateExport(ctx context.Context, export *models.TradeExport) error {
+	row := r.pool.QueryRow(ctx, `
+		INSERT INTO trade_history_exports (user_id, format, date_from, date_to, symbol, is_demo, status)
+		VALUES ($1, $2, NULLIF($3, '')::date, NULLIF($4, '')::date, NULLIF($5, ''), $6, 'pending')
+		RETURNING `+exportColumns,
+		export.UserID, string(export.Format), export.DateFrom, export.DateTo, export.Symbol, export.IsDemo)
+	saved, err := scanExport(row)
+	if err != nil {
+		return fmt.Errorf("error creating trade export: %w", err)
+	}
+	*export = *saved
+	return nil
+}
+
+func (r *PostgresExportRepository) GetExport(ctx context.Context, id int64) (*models.TradeExport, error) {
+	export, err := scanExport(r.pool.QueryRow(ctx, `SELECT `+exportColumns+` FROM trade_history_exports WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting trade export: %w", err)
+	}
+	return export, nil
+}
+
+// GetUserExports exportaciones del usuario, las más recientes primero
+func (r *PostgresExportRepository) GetUserExports(ctx context.Context, userID int64, limit int) ([]*models.TradeExport, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+exportColumns+`
+		FROM trade_history_exports
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error getting trade exports: %w", err)
+	}
+	return collectExports(rows)
+}
+
+func (r *PostgresExportRepository) CountActiveExports(ctx context.Context, userID int64) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM trade_history_exports
+		WHERE user_id = $1 AND status IN ('pending', 'processing')
+	`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting trade exports: %w", err)
+	}
+	return count, nil
+}
+
+// ClaimPendingExport toma la exportación más antigua en cola; SKIP LOCKED evita que dos
+// instancias procesen la misma
+func (r *PostgresExportRepository) ClaimPendingExport(ctx context.Context) (*models.TradeExport, error) {
+	export, err := scanExport(r.pool.QueryRow(ctx, `
+		UPDATE trade_history_exports SET status = 'processing'
+		WHERE id = (
+			SELECT id FROM trade_history_exports
+			WHERE status = 'pending'
+			ORDER BY created_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+exportColumns))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error claiming trade export: %w", err)
+	}
+	return export, nil
+}
+
+func (r *PostgresExportRepository) RequeueProcessingExports(ctx context.Context) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `UPDATE trade_history_exports SET status = 'pending' WHERE status = 'processing'`)
+	if err != nil {
+		return 0, fmt.Errorf("error requeuing trade exports: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// StreamExportTrades trades cerrados de la cuenta de la exportación (sin torneos); el
+// rango de fechas se aplica a la fecha de cierre
+func (r *PostgresExportRepository) StreamExportTrades(ctx context.Context, export *models.TradeExport, fn func(*models.Trade) error) error {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, user_id, symbol, direction, amount, entry_price, COALESCE(exit_price, 0),
+		       payout_percentage, COALESCE(profit, 0), status, is_demo,
+		       COALESCE(option_type, 'high_low'), created_at, expires_at, closed_at
+		FROM trades
+		WHERE user_id = $1 AND is_demo = $2 AND tournament_id IS NULL
+		  AND status IN ('won', 'lost', 'draw', 'sold', 'canceled')
+		  AND (NULLIF($3::text, '') IS NULL OR closed_at >= NULLIF($3::text, '')::date)
+		  AND (NULLIF($4::text, '') IS NULL OR closed_at < NULLIF($4::text, '')::date + 1)
+		  AND (NULLIF($5::text, '') IS NULL OR symbol = $5::text)
+		ORDER BY closed_at, id
+	`, export.UserID, export.IsDemo, export.DateFrom, export.DateTo, export.Symbol)
+	if err != nil {
+		return fmt.Errorf("error getting export trades: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var trade models.Trade
+		var direction, status, optionType string
+		err := rows.Scan(&trade.ID, &trade.UserID, &trade.Symbol, &direction, &trade.Amount,
+			&trade.EntryPrice, &trade.ExitPrice, &trade.Payout, &trade.Profit, &status, &trade.IsDemo,
+			&optionType, &trade.CreatedAt, &trade.ExpiresAt, &trade.ClosedAt)
+		if err != nil {
+			return fmt.Errorf("error scanning export trade: %w", err)
+		}
+		trade.Direction = models.TradeDirection(direction)
+		trade.Status = models.TradeStatus(status)
+		trade.OptionType = models.OptionType(optionType)
+
+		if err := fn(&trade); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// CompleteExport marca la exportación como lista; el archivo se conserva retention desde ahora
+func (r *PostgresExportRepository) CompleteExport(ctx context.Context, export *models.TradeExport, retention time.Duration) error {
+	err := r.pool.QueryRow(ctx, `
+		UPDATE trade_history_exports SET
+			status = 'ready', file_path = $2, file_size = $3, rows_count = $4,
+			completed_at = NOW(), expires_at = NOW() + make_interval(secs => $5), error_message = NULL
+		WHERE id = $1
+		RETURNING completed_at, expires_at
+	`, export.ID, export.FilePath, export.FileSize, export.RowsCount, retention.Seconds()).Scan(&export.CompletedAt, &export.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("error completing trade export: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresExportRepository) FailExport(ctx context.Context, id int64, message string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE trade_history_exports SET status = 'failed', error_message = $2, completed_at = NOW()
+		WHERE id = $1
+	`, id, message)
+	if err != nil {
+		return fmt.Errorf("error failing trade export: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresExportRepository) ExpireExports(ctx context.Context) ([]*models.TradeExport, error) {
+	rows, err := r.pool.Query(ctx, `
+		UPDATE trade_history_exports SET status = 'expired'
+		WHERE status = 'ready' AND expires_at <= NOW()
+		RETURNING `+exportColumns)
+	if err != nil {
+		return nil, fmt.Errorf("error expiring trade exports: %w", err)
+	}
+	return collectExports(rows)
+}
@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresExposureRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresExposureRepository(pool *pgxpool.Pool) *PostgresExposureRepository {
+	return &PostgresExposureRepository{pool: pool}
+}
+
+// GetExposureLimits devuelve max_net_exposure de los activos que lo definen
+func (r *PostgresExposureRepository) GetExposureLimits(ctx context.Context) (map[string]float64, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT symbol, max_net_exposure
+		FROM operator_trading_assets
+		WHERE max_net_exposure IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error getting exposure limits: %w", err)
+	}
+	defer rows.Close()
+
+	limits := make(map[string]float64)
+	for rows.Next() {
+		var symbol string
+		var limit float64
+		if err := rows.Scan(&symbol, &limit); err != nil {
+			return nil, fmt.Errorf("error scanning exposure limit: %w", err)
+		}
+		limits[symbol] = limit
+	}
+	return limits, rows.Err()
+}
@@ -0,0 +1,236 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"tormentus/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresJournalRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresJournalRepository(pool *pgxpool.Pool) *PostgresJournalRepository {
+	return &PostgresJournalRepository{pool: pool}
+}
+
+const journalEntryQuery = `
+	SELECT j.id, j.user_id, j.trade_id, COALESCE(j.entry_reason, ''), COALESCE(j.exit_reason, ''),
+	       COALESCE(j.emotions, ''), COALESCE(j.lessons_learned, ''), j.rating, COALESCE(j.tags, '{}'),
+	       COALESCE(j.created_at, NOW()), COALESCE(j.updated_at, j.created_at, NOW()),
+	       t.symbol, t.direction, t.amount, t.status, COALESCE(t.profit, 0), t.is_demo, t.created_at
+	FROM trading_journal j
+	JOIN trades t ON t.id = j.trade_id`
+
+// journalClosedTrades entradas del usuario $1 cuyo trade ya cerró, opcionalmente
+// filtradas por cuenta demo/real ($2)
+const journalClosedTrades = `
+	WITH closed AS (
+		SELECT j.tags, j.emotions, j.rating, t.status, COALESCE(t.profit, 0) AS profit
+		FROM trading_journal j
+		JOIN trades t ON t.id = j.trade_id
+		WHERE j.user_id = $1
+		  AND t.status IN ('won', 'lost', 'draw', 'sold')
+		  AND ($2::boolean IS NULL OR t.is_demo = $2)
+	)`
+
+const journalBucketColumns = `COUNT(*), COUNT(*) FILTER (WHERE status = 'won'),
+	COUNT(*) FILTER (WHERE status = 'lost'), COALESCE(SUM(profit), 0)`
+
+// CreateEntry inserta la entrada del diario del trade
+func (r *PostgresJournalRepository) CreateEntry(ctx context.Context, entry *models.JournalEntry) error {
+	var id int64
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO trading_journal (user_id, trade_id, entry_reason, exit_reason, emotions, lessons_learned, rating, tags)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8)
+		ON CONFLICT (trade_id) WHERE trade_id IS NOT NULL DO NOTHING
+		RETURNING id
+	`, entry.UserID, entry.TradeID, entry.EntryReason, entry.ExitReason, string(entry.Emotion),
+		entry.LessonsLearned, entry.Rating, entry.Tags).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrJournalEntryExists
+	}
+	if err != nil {
+		return fmt.Errorf("error inserting journal entry: %w", err)
+	}
+
+	saved, err := r.GetEntry(ctx, entry.UserID, id)
+	if err != nil {
+		return err
+	}
+	*entry = *saved
+	return nil
+}
+
+// UpdateEntry reemplaza motivos, emoción, lecciones, calificación y etiquetas
+func (r *PostgresJournalRepository) UpdateEntry(ctx context.Context, entry *models.JournalEntry) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE trading_journal SET
+			entry_reason = $3, exit_reason = $4, emotions = NULLIF($5, ''), lessons_learned = $6,
+			rating = $7, tags = $8, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+	`, entry.ID, entry.UserID, entry.EntryReason, entry.ExitReason, string(entry.Emotion),
+		entry.LessonsLearned, entry.Rating, entry.Tags)
+	if err != nil {
+		return false, fmt.Errorf("error updating journal entry: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	saved, err := r.GetEntry(ctx, entry.UserID, entry.ID)
+	if err != nil {
+		return false, err
+	}
+	if saved != nil {
+		*entry = *saved
+	}
+	return true, nil
+}
+
+// DeleteEntry elimina una entrada del usuario
+func (r *PostgresJournalRepository) DeleteEntry(ctx context.Context, userID, id int64) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM trading_journal WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting journal entry: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetEntry obtiene una entrada del usuario
+func (r *PostgresJournalRepository) GetEntry(ctx context.Context, userID, id int64) (*models.JournalEntry, error) {
+	return r.getEntry(ctx, journalEntryQuery+` WHERE j.id = $1 AND j.user_id = $2`, id, userID)
+}
+
+// GetEntryByTrade obtiene la entrada de un trade
+func (r *PostgresJournalRepository) GetEntryByTrade(ctx context.Context, tradeID int64) (*models.JournalEntry, error) {
+	return r.getEntry(ctx, journalEntryQuery+` WHERE j.trade_id = $1`, tradeID)
+}
+
+func (r *PostgresJournalRepository) getEntry(ctx context.Context, query string, args ...interface{}) (*models.JournalEntry, error) {
+	entry, err := scanJournalEntry(r.pool.QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting journal entry: %w", err)
+	}
+	return entry, nil
+}
+
+// GetEntries entradas del usuario, más recientes primero
+func (r *PostgresJournalRepository) GetEntries(ctx context.Context, userID int64, filter models.JournalFilter) ([]*models.JournalEntry, error) {
+	rows, err := r.pool.Query(ctx, journalEntryQuery+`
+		WHERE j.user_id = $1
+		  AND ($2 = '' OR $2 = ANY(j.tags))
+		  AND ($3 = '' OR j.emotions = $3)
+		  AND ($4::boolean IS NULL OR t.is_demo = $4)
+		ORDER BY j.created_at DESC, j.id DESC
+		LIMIT $5 OFFSET $6
+	`, userID, filter.Tag, string(filter.Emotion), filter.IsDemo, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("error getting journal entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*models.JournalEntry{}
+	for rows.Next() {
+		entry, err := scanJournalEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning journal entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// GetAnalytics win rate y P&L de los trades cerrados con entrada, en total y por
+// etiqueta, emoción y calificación
+func (r *PostgresJournalRepository) GetAnalytics(ctx context.Context, userID int64, isDemo *bool) (*models.JournalAnalytics, error) {
+	analytics := &models.JournalAnalytics{}
+
+	overall, err := r.queryJournalBuckets(ctx, journalClosedTrades+`
+		SELECT 'all', `+journalBucketColumns+` FROM closed
+	`, userID, isDemo)
+	if err != nil {
+		return nil, err
+	}
+	analytics.Overall = overall[0]
+
+	analytics.ByTag, err = r.queryJournalBuckets(ctx, journalClosedTrades+`
+		SELECT tag, `+journalBucketColumns+`
+		FROM closed, unnest(tags) AS tag
+		GROUP BY tag
+		ORDER BY COUNT(*) DESC, tag
+	`, userID, isDemo)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics.ByEmotion, err = r.queryJournalBuckets(ctx, journalClosedTrades+`
+		SELECT emotions, `+journalBucketColumns+`
+		FROM closed
+		WHERE COALESCE(emotions, '') <> ''
+		GROUP BY emotions
+		ORDER BY COUNT(*) DESC, emotions
+	`, userID, isDemo)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics.ByRating, err = r.queryJournalBuckets(ctx, journalClosedTrades+`
+		SELECT rating::text, `+journalBucketColumns+`
+		FROM closed
+		WHERE rating IS NOT NULL
+		GROUP BY rating
+		ORDER BY rating
+	`, userID, isDemo)
+	if err != nil {
+		return nil, err
+	}
+
+	return analytics, nil
+}
+
+func (r *PostgresJournalRepository) queryJournalBuckets(ctx context.Context, query string, args ...interface{}) ([]models.JournalBucket, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting journal analytics: %w", err)
+	}
+	defer rows.Close()
+
+	buckets := []models.JournalBucket{}
+	for rows.Next() {
+		var b models.JournalBucket
+		if err := rows.Scan(&b.Key, &b.Trades, &b.Wins, &b.Losses, &b.ProfitLoss); err != nil {
+			return nil, fmt.Errorf("error scanning journal analytics: %w", err)
+		}
+		if decided := b.Wins + b.Losses; decided > 0 {
+			b.WinRate = math.Round(float64(b.Wins)*10000/float64(decided)) / 100
+		}
+		b.ProfitLoss = math.Round(b.ProfitLoss*100) / 100
+		buckets = append(buckets, b)
+	}
+	return buckets, rows.Err()
+}
+
+func scanJournalEntry(row pgx.Row) (*models.JournalEntry, error) {
+	e := &models.JournalEntry{}
+	var emotion, direction, status string
+	err := row.Scan(&e.ID, &e.UserID, &e.TradeID, &e.EntryReason, &e.ExitReason, &emotion, &e.LessonsLearned,
+		&e.Rating, &e.Tags, &e.CreatedAt, &e.UpdatedAt,
+		&e.Symbol, &direction, &e.Amount, &status, &e.Profit, &e.IsDemo, &e.TradedAt)
+	if err != nil {
+		return nil, err
+	}
+	e.Emotion = models.JournalEmotion(emotion)
+	e.Direction = models.TradeDirection(direction)
+	e.TradeStatus = models.TradeStatus(status)
+	return e, nil
+}
@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"tormentus/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresPayoutRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresPayoutRepository(pool *pgxpool.Pool) *PostgresPayoutRepository {
+	return &PostgresPayoutRepository{pool: pool}
+}
+
+// GetPayoutSources obtiene la regla del activo, el tramo de duración y el override del usuario
+func (r *PostgresPayoutRepository) GetPayoutSources(ctx context.Context, userID int64, symbol string, optionType models.OptionType, duration int) (*models.PayoutSources, error) {
+	sources := &models.PayoutSources{}
+
+	// Payout base del activo
+	var assetID int64
+	var assetPayout float64
+	err := r.pool.QueryRow(ctx, `
+		SELECT id, payout_percentage FROM operator_trading_assets
+		WHERE symbol = $1 AND is_active = true
+	`, symbol).Scan(&assetID, &assetPayout)
+	switch {
+	case err == nil:
+		sources.AssetPayout = &assetPayout
+	case err != pgx.ErrNoRows:
+		return nil, fmt.Errorf("error getting asset payout: %w", err)
+	}
+
+	// Payout del activo y distancia de barreras para opciones touch/range
+	if sources.AssetPayout != nil && optionType != "" && optionType != models.OptionHighLow {
+		var optionPayout float64
+		err = r.pool.QueryRow(ctx, `
+			SELECT payout_percentage, min_barrier_distance, max_barrier_distance
+			FROM operator_asset_option_payouts
+			WHERE asset_id = $1 AND option_type = $2 AND is_active = true
+		`, assetID, string(optionType)).Scan(&optionPayout, &sources.MinBarrierDistance, &sources.MaxBarrierDistance)
+		switch {
+		case err == nil:
+			sources.OptionPayout = &optionPayout
+		case err != pgx.ErrNoRows:
+			return nil, fmt.Errorf("error getting option payout: %w", err)
+		}
+	}
+
+	// Tramo de duración: el mayor duration_seconds que no supere la duración del trade
+	var bracket models.PayoutBracket
+	err = r.pool.QueryRow(ctx, `
+		SELECT duration_seconds, base_payout, min_payout, max_payout, COALESCE(volatility_adjustment, 0)
+		FROM operation_payout_config
+		WHERE symbol = $1 AND is_active = true AND duration_seconds <= $2
+		ORDER BY duration_seconds DESC
+		LIMIT 1
+	`, symbol, duration).Scan(&bracket.DurationSeconds, &bracket.BasePayout, &bracket.MinPayout, &bracket.MaxPayout, &bracket.VolatilityAdjustment)
+	switch {
+	case err == nil:
+		sources.Bracket = &bracket
+	case err != pgx.ErrNoRows:
+		return nil, fmt.Errorf("error getting payout bracket: %w", err)
+	}
+
+	// Reglas del activo
+	if sources.AssetPayout != nil {
+		rows, err := r.pool.Query(ctx, `
+			SELECT rule_name, condition_type, condition_value, payout_adjustment, priority
+			FROM operator_asset_payout_rules
+			WHERE asset_id = $1 AND is_active = true
+			ORDER BY priority
+		`, assetID)
+		if err != nil {
+			return nil, fmt.Errorf("error getting payout rules: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var rule models.PayoutRule
+			var conditionJSON []byte
+			if err := rows.Scan(&rule.RuleName, &rule.ConditionType, &conditionJSON, &rule.Adjustment, &rule.Priority); err != nil {
+				return nil, err
+			}
+			if len(conditionJSON) > 0 {
+				json.Unmarshal(conditionJSON, &rule.ConditionValue)
+			}
+			sources.Rules = append(sources.Rules, rule)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("error reading payout rules: %w", err)
+		}
+	}
+
+	// Override del usuario: el específico del símbolo tiene prioridad sobre el general
+	var userAdjustment float64
+	err = r.pool.QueryRow(ctx, `
+		SELECT payout_adjustment FROM user_payout_overrides
+		WHERE user_id = $1 AND is_active = true
+		  AND (symbol = $2 OR symbol IS NULL)
+		  AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY symbol IS NULL, created_at DESC
+		LIMIT 1
+	`, userID, symbol).Scan(&userAdjustment)
+	switch {
+	case err == nil:
+		sources.UserAdjustment = &userAdjustment
+	case err != pgx.ErrNoRows:
+		return nil, fmt.Errorf("error getting user payout override: %w", err)
+	}
+
+	return sources, nil
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tormentus/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresPriceTickRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresPriceTickRepository(pool *pgxpool.Pool) *PostgresPriceTickRepository {
+	return &PostgresPriceTickRepository{pool: pool}
+}
+
+// SaveTicks inserta un lote de ticks en price_ticks
+func (r *PostgresPriceTickRepository) SaveTicks(ctx context.Context, ticks []models.PriceData) error {
+	if len(ticks) == 0 {
+		return nil
+	}
+
+	rows := make([][]interface{}, 0, len(ticks))
+	for _, t := range ticks {
+		rows = append(rows, []interface{}{t.Symbol, t.Price, t.Bid, t.Ask, t.Volume, t.Timestamp})
+	}
+
+	_, err := r.pool.CopyFrom(ctx,
+		pgx.Identifier{"price_ticks"},
+		[]string{"symbol", "price", "bid", "ask", "volume", "timestamp"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return fmt.Errorf("error saving price ticks: %w", err)
+	}
+	return nil
+}
+
+// GetTickAt obtiene el último tick de un símbolo en o antes del instante indicado
+func (r *PostgresPriceTickRepository) GetTickAt(ctx context.Context, symbol string, at time.Time) (*models.PriceData, error) {
+	query := `
+		SELECT symbol, price, COALESCE(bid, 0), COALESCE(ask, 0), COALESCE(volume, 0), timestamp
+		FROM price_ticks
+		WHERE symbol = $1 AND timestamp <= $2
+		ORDER BY timestamp DESC
+		LIMIT 1
+	`
+
+	var tick models.PriceData
+	err := r.pool.QueryRow(ctx, query, symbol, at).Scan(
+		&tick.Symbol, &tick.Price, &tick.Bid, &tick.Ask, &tick.Volume, &tick.Timestamp,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting price tick: %w", err)
+	}
+
+	return &tick, nil
+}
+
+// GetFirstBarrierTouch obtiene el primer tick de un símbolo en (from, to] cuyo precio
+// alcanza high o low (nil = sin esa barrera); nil si ninguno la alcanzó
+func (r *PostgresPriceTickRepository) GetFirstBarrierTouch(ctx context.Context, symbol string, from, to time.Time, high, low *float64) (*models.PriceData, error) {
+	query := `
+		SELECT symbol, price, COALESCE(bid, 0), COALESCE(ask, 0), COALESCE(volume, 0), timestamp
+		FROM price_ticks
+		WHERE symbol = $1 AND timestamp > $2 AND timestamp <= $3
+		  AND (price >= $4::float8 OR price <= $5::float8)
+		ORDER BY timestamp
+		LIMIT 1
+	`
+
+	var tick models.PriceData
+	err := r.pool.QueryRow(ctx, query, symbol, from, to, high, low).Scan(
+		&tick.Symbol, &tick.Price, &tick.Bid, &tick.Ask, &tick.Volume, &tick.Timestamp,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting barrier touch: %w", err)
+	}
+
+	return &tick, nil
+}
+
+// DeleteTicksBefore elimina ticks anteriores a la fecha indicada
+func (r *PostgresPriceTickRepository) DeleteTicksBefore(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM price_ticks WHERE timestamp < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting price ticks: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"tormentus/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresRiskRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRiskRepository(pool *pgxpool.Pool) *PostgresRiskRepository {
+	return &PostgresRiskRepository{pool: pool}
+}
+
+// GetRiskProfile combina bloqueos, límites del activo, límites de plataforma y overrides del usuario.
+// Precedencia de montos: activo -> investment_limit_overrides -> transaction_limits (solo real)
+// -> trade_limits_override del usuario.
+func (r *PostgresRiskRepository) GetRiskProfile(ctx context.Context, userID int64, symbol string, isDemo bool) (*models.RiskProfile, error) {
+	profile := &models.RiskProfile{}
+
+	// Bloqueos vigentes
+	rows, err := r.pool.Query(ctx, `
+		SELECT block_type, COALESCE(blocked_symbols, '[]'), max_amount, reason, expires_at
+		FROM user_trading_blocks
+		WHERE user_id = $1 AND is_active = true AND (expires_at IS NULL OR expires_at > NOW())
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting trading blocks: %w", err)
+	}
+	for rows.Next() {
+		var b models.TradingBlockRule
+		if err := rows.Scan(&b.BlockType, &b.BlockedSymbols, &b.MaxAmount, &b.Reason, &b.ExpiresAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		profile.Blocks = append(profile.Blocks, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading trading blocks: %w", err)
+	}
+
+	// Límites del activo (catálogo del operador o, en su defecto, trading_pairs)
+	err = r.pool.QueryRow(ctx, `
+		SELECT min_trade_amount, max_trade_amount FROM operator_trading_assets WHERE symbol = $1
+	`, symbol).Scan(&profile.MinStake, &profile.MaxStake)
+	if err == pgx.ErrNoRows {
+		err = r.pool.QueryRow(ctx, `
+			SELECT min_amount, max_amount FROM trading_pairs WHERE symbol = $1
+		`, symbol).Scan(&profile.MinStake, &profile.MaxStake)
+	}
+	if err != nil && err != pgx.ErrNoRows {
+		return nil, fmt.Errorf("error getting asset limits: %w", err)
+	}
+
+	// Override de inversión vigente para el par
+	var newMin, newMax *float64
+	err = r.pool.QueryRow(ctx, `
+		SELECT o.new_min, o.new_max
+		FROM investment_limit_overrides o
+		JOIN trading_pairs p ON p.id = o.trading_pair_id
+		WHERE p.symbol = $1
+		  AND (o.effective_from IS NULL OR o.effective_from <= NOW())
+		  AND (o.effective_until IS NULL OR o.effective_until > NOW())
+		ORDER BY o.created_at DESC
+		LIMIT 1
+	`, symbol).Scan(&newMin, &newMax)
+	if err != nil && err != pgx.ErrNoRows {
+		return nil, fmt.Errorf("error getting investment limit override: %w", err)
+	}
+	if newMin != nil {
+		profile.MinStake = newMin
+	}
+	if newMax != nil {
+		profile.MaxStake = newMax
+	}
+
+	// Límites de plataforma para operaciones con dinero real
+	if !isDemo {
+		var singleMin, singleMax *float64
+		err = r.pool.QueryRow(ctx, `
+			SELECT daily_limit, single_transaction_min, single_transaction_max
+			FROM transaction_limits
+			WHERE limit_type = 'trade' AND is_active = true
+			  AND (user_type IS NULL OR user_type = 'all' OR user_type = (SELECT role FROM users WHERE id = $1))
+			ORDER BY user_type IS NULL, user_type = 'all'
+			LIMIT 1
+		`, userID).Scan(&profile.DailyLimit, &singleMin, &singleMax)
+		if err != nil && err != pgx.ErrNoRows {
+			return nil, fmt.Errorf("error getting transaction limits: %w", err)
+		}
+		if singleMin != nil && (profile.MinStake == nil || *singleMin > *profile.MinStake) {
+			profile.MinStake = singleMin
+		}
+		if singleMax != nil && (profile.MaxStake == nil || *singleMax < *profile.MaxStake) {
+			profile.MaxStake = singleMax
+		}
+	}
+
+	// Overrides del usuario (el más reciente por tipo)
+	rows, err = r.pool.Query(ctx, `
+		SELECT DISTINCT ON (limit_type) limit_type, new_limit
+		FROM trade_limits_override
+		WHERE user_id = $1 AND is_active = true
+		  AND (starts_at IS NULL OR starts_at <= NOW())
+		  AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY limit_type, created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting trade limit overrides: %w", err)
+	}
+	for rows.Next() {
+		var limitType string
+		var value float64
+		if err := rows.Scan(&limitType, &value); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		v := value
+		switch limitType {
+		case "min_trade_amount":
+			profile.MinStake = &v
+		case "max_trade_amount":
+			profile.MaxStake = &v
+		case "max_open_trades":
+			n := int(v)
+			profile.MaxOpenTrades = &n
+		case "daily_trade_volume":
+			if !isDemo {
+				profile.DailyLimit = &v
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading trade limit overrides: %w", err)
+	}
+
+	// Uso actual de la cuenta (real o demo, fuera de torneos)
+	err = r.pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM trades
+		WHERE user_id = $1 AND is_demo = $2 AND tournament_id IS NULL AND status = 'pending'
+	`, userID, isDemo).Scan(&profile.OpenTrades)
+	if err != nil {
+		return nil, fmt.Errorf("error counting open trades: %w", err)
+	}
+
+	if !isDemo {
+		err = r.pool.QueryRow(ctx, `
+			SELECT COALESCE(SUM(amount), 0) FROM trades
+			WHERE user_id = $1 AND is_demo = false AND tournament_id IS NULL
+			  AND created_at >= date_trunc('day', NOW())
+		`, userID).Scan(&profile.DailyVolume)
+		if err != nil {
+			return nil, fmt.Errorf("error getting daily volume: %w", err)
+		}
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,454 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"tormentus/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresSignalRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresSignalRepository(pool *pgxpool.Pool) *PostgresSignalRepository {
+	return &PostgresSignalRepository{pool: pool}
+}
+
+const signalProviderQuery = `
+	SELECT p.user_id, p.display_name, COALESCE(p.bio, ''), p.is_verified, p.verified_at, p.total_signals,
+	       p.hits, p.misses, p.hit_rate, p.current_streak, p.best_streak, p.worst_streak,
+	       (SELECT COUNT(*) FROM signal_subscriptions s
+	        WHERE s.provider_id = p.user_id AND s.status = 'active' AND (s.expires_at IS NULL OR s.expires_at > NOW())),
+	       COALESCE(p.created_at, NOW())
+	FROM signal_providers p`
+
+const tradingSignalQuery = `
+	SELECT s.id, s.provider_id, COALESCE(p.display_name, ''), s.symbol, s.direction, COALESCE(s.timeframe, ''),
+	       COALESCE(s.confidence, 0), COALESCE(s.analysis, ''), COALESCE(s.entry_price, 0), s.exit_price,
+	       COALESCE(s.status, 'active'), s.result, COALESCE(s.created_at, NOW()),
+	       COALESCE(s.expires_at, s.created_at, NOW()), s.closed_at
+	FROM trading_signals s
+	LEFT JOIN signal_providers p ON p.user_id = s.provider_id`
+
+const signalSubscriptionQuery = `
+	SELECT s.id, s.user_id, s.provider_id, COALESCE(p.display_name, ''), COALESCE(s.status, 'active'),
+	       COALESCE(s.subscribed_at, NOW()), s.expires_at
+	FROM signal_subscriptions s
+	LEFT JOIN signal_providers p ON p.user_id = s.provider_id`
+
+// activeSubscribersQuery suscriptores vigentes del proveedor $1
+const activeSubscribersQuery = `
+	FROM signal_subscriptions
+	WHERE provider_id = $1 AND status = 'active' AND (expires_at IS NULL OR expires_at > NOW())`
+
+// UpsertProvider registra al usuario como proveedor verificado, conservando su historial
+func (r *PostgresSignalRepository) UpsertProvider(ctx context.Context, provider *models.SignalProvider, verifiedBy int64) error {
+	tag, err := r.pool.Exec(ctx, `
+		INSERT INTO signal_providers (user_id, display_name, bio, is_verified, verified_by, verified_at)
+		SELECT id, $2, $3, TRUE, $4, NOW() FROM users WHERE id = $1
+		ON CONFLICT (user_id) DO UPDATE SET
+			display_name = EXCLUDED.display_name,
+			bio = EXCLUDED.bio,
+			verified_by = EXCLUDED.verified_by,
+			verified_at = CASE WHEN signal_providers.is_verified
+			                   THEN signal_providers.verified_at ELSE NOW() END,
+			is_verified = TRUE
+	`, provider.UserID, provider.DisplayName, provider.Bio, verifiedBy)
+	if err != nil {
+		return fmt.Errorf("error saving signal provider: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrSignalProviderNotFound
+	}
+
+	saved, err := r.GetProvider(ctx, provider.UserID)
+	if err != nil {
+		return err
+	}
+	*provider = *saved
+	return nil
+}
+
+// RevokeProvider retira la verificación del proveedor; su historial se conserva
+func (r *PostgresSignalRepository) RevokeProvider(ctx context.Context, userID int64) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `UPDATE signal_providers SET is_verified = FALSE WHERE user_id = $1 AND is_verified`, userID)
+	if err != nil {
+		return false, fmt.Errorf("error revoking signal provider: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetProvider obtiene el perfil y el historial de un proveedor
+func (r *PostgresSignalRepository) GetProvider(ctx context.Context, userID int64) (*models.SignalProvider, error) {
+	provider, err := scanSignalProvider(r.pool.QueryRow(ctx, signalProviderQuery+` WHERE p.user_id = $1`, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting signal provider: %w", err)
+	}
+	return provider, nil
+}
+
+// GetProviders proveedores verificados ordenados por tasa de acierto
+func (r *PostgresSignalRepository) GetProviders(ctx context.Context, limit, offset int) ([]*models.SignalProvider, error) {
+	rows, err := r.pool.Query(ctx, signalProviderQuery+`
+		WHERE p.is_verified
+		ORDER BY p.hit_rate DESC, p.total_signals DESC, p.user_id
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error getting signal providers: %w", err)
+	}
+	defer rows.Close()
+
+	providers := []*models.SignalProvider{}
+	for rows.Next() {
+		provider, err := scanSignalProvider(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning signal provider: %w", err)
+		}
+		providers = append(providers, provider)
+	}
+	return providers, rows.Err()
+}
+
+// PublishSignal inserta la señal de un proveedor verificado y crea la notificación de
+// cada suscriptor vigente
+func (r *PostgresSignalRepository) PublishSignal(ctx context.Context, signal *models.TradingSignal) ([]int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var verified bool
+	err = tx.QueryRow(ctx, `SELECT display_name, is_verified FROM signal_providers WHERE user_id = $1 FOR SHARE`,
+		signal.ProviderID).Scan(&signal.ProviderName, &verified)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && !verified) {
+		return nil, ErrSignalProviderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting signal provider: %w", err)
+	}
+
+	signal.Status = models.SignalActive
+	err = tx.QueryRow(ctx, `
+		INSERT INTO trading_signals (provider_id, symbol, direction, entry_price, timeframe, confidence, analysis,
+		                             status, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`, signal.ProviderID, signal.Symbol, string(signal.Direction), signal.EntryPrice, signal.Timeframe,
+		signal.Confidence, signal.Analysis, string(signal.Status), signal.CreatedAt, signal.ExpiresAt).Scan(&signal.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error inserting trading signal: %w", err)
+	}
+
+	subscribers, err := notifySignalSubscribers(ctx, tx, signal, "trading_signal",
+		"Nueva señal de "+signal.ProviderName,
+		fmt.Sprintf("%s %s (%s) · confianza %d%%", signal.Symbol, strings.ToUpper(string(signal.Direction)),
+			signal.Timeframe, signal.Confidence))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("error committing trading signal: %w", err)
+	}
+	return subscribers, nil
+}
+
+// GetActiveSignals señales pendientes de evaluación (tras un reinicio)
+func (r *PostgresSignalRepository) GetActiveSignals(ctx context.Context) ([]*models.TradingSignal, error) {
+	return r.querySignals(ctx, tradingSignalQuery+`
+		WHERE s.status = $1
+		ORDER BY s.expires_at
+	`, string(models.SignalActive))
+}
+
+// ScoreSignal cierra la señal activa, suma el resultado al historial del proveedor y
+// notifica a los suscriptores
+func (r *PostgresSignalRepository) ScoreSignal(ctx context.Context, signal *models.TradingSignal) ([]int64, bool, error) {
+	if signal.Result == nil || signal.ClosedAt == nil {
+		return nil, false, fmt.Errorf("señal %d sin resultado", signal.ID)
+	}
+	result := *signal.Result
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE trading_signals SET status = $2, result = $3, exit_price = $4, closed_at = $5
+		WHERE id = $1 AND status = $6
+	`, signal.ID, string(models.SignalClosed), string(result), signal.ExitPrice, *signal.ClosedAt,
+		string(models.SignalActive))
+	if err != nil {
+		return nil, false, fmt.Errorf("error scoring trading signal: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, false, nil
+	}
+	signal.Status = models.SignalClosed
+
+	if result != models.SignalVoid {
+		if err := recordSignalResult(ctx, tx, signal.ProviderID, result); err != nil {
+			return nil, false, err
+		}
+	}
+
+	subscribers, err := notifySignalSubscribers(ctx, tx, signal, "signal_result",
+		signalResultTitle(result), signalResultMessage(signal))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("error committing signal result: %w", err)
+	}
+	return subscribers, true, nil
+}
+
+// recordSignalResult actualiza aciertos, fallos y rachas del proveedor
+func recordSignalResult(ctx context.Context, tx pgx.Tx, providerID int64, result models.SignalResult) error {
+	provider, err := scanSignalProvider(tx.QueryRow(ctx, signalProviderQuery+` WHERE p.user_id = $1 FOR UPDATE OF p`, providerID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error getting signal provider: %w", err)
+	}
+
+	provider.Record(result)
+	_, err = tx.Exec(ctx, `
+		UPDATE signal_providers SET
+			total_signals = $2, hits = $3, misses = $4, hit_rate = $5,
+			current_streak = $6, best_streak = $7, worst_streak = $8
+		WHERE user_id = $1
+	`, providerID, provider.TotalSignals, provider.Hits, provider.Misses, provider.HitRate,
+		provider.CurrentStreak, provider.BestStreak, provider.WorstStreak)
+	if err != nil {
+		return fmt.Errorf("error updating signal provider stats: %w", err)
+	}
+	return nil
+}
+
+// notifySignalSubscribers crea la notificación de la señal para cada suscriptor vigente
+// del proveedor y devuelve los usuarios notificados
+func notifySignalSubscribers(ctx context.Context, tx pgx.Tx, signal *models.TradingSignal, notificationType, title, message string) ([]int64, error) {
+	data, err := json.Marshal(signal)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding trading signal: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		INSERT INTO notifications (user_id, type, title, message, data)
+		SELECT user_id, $2, $3, $4, $5::jsonb `+activeSubscribersQuery+`
+		RETURNING user_id
+	`, signal.ProviderID, notificationType, title, message, string(data))
+	if err != nil {
+		return nil, fmt.Errorf("error notifying signal subscribers: %w", err)
+	}
+	defer rows.Close()
+
+	var subscribers []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("error scanning signal subscriber: %w", err)
+		}
+		subscribers = append(subscribers, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error notifying signal subscribers: %w", err)
+	}
+	return subscribers, nil
+}
+
+func signalResultTitle(result models.SignalResult) string {
+	switch result {
+	case models.SignalHit:
+		return "Señal acertada"
+	case models.SignalMiss:
+		return "Señal fallida"
+	default:
+		return "Señal anulada"
+	}
+}
+
+func signalResultMessage(signal *models.TradingSignal) string {
+	summary := fmt.Sprintf("%s %s (%s) de %s", signal.Symbol, strings.ToUpper(string(signal.Direction)),
+		signal.Timeframe, signal.ProviderName)
+	if signal.ExitPrice == nil {
+		return summary + ": sin precio de cierre"
+	}
+	return fmt.Sprintf("%s: entrada %.5f, cierre %.5f", summary, signal.EntryPrice, *signal.ExitPrice)
+}
+
+// GetSignal obtiene una señal por ID
+func (r *PostgresSignalRepository) GetSignal(ctx context.Context, id int64) (*models.TradingSignal, error) {
+	signal, err := scanTradingSignal(r.pool.QueryRow(ctx, tradingSignalQuery+` WHERE s.id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting trading signal: %w", err)
+	}
+	return signal, nil
+}
+
+// GetFeed señales recientes de los proveedores con suscripción vigente del usuario
+func (r *PostgresSignalRepository) GetFeed(ctx context.Context, userID int64, limit, offset int) ([]*models.TradingSignal, error) {
+	return r.querySignals(ctx, tradingSignalQuery+`
+		JOIN signal_subscriptions sub ON sub.provider_id = s.provider_id
+		WHERE sub.user_id = $1 AND sub.status = 'active' AND (sub.expires_at IS NULL OR sub.expires_at > NOW())
+		ORDER BY s.created_at DESC, s.id DESC
+		LIMIT $2 OFFSET $3
+	`, userID, limit, offset)
+}
+
+// GetProviderSignals historial de señales del proveedor
+func (r *PostgresSignalRepository) GetProviderSignals(ctx context.Context, providerID int64, includeActive bool, limit, offset int) ([]*models.TradingSignal, error) {
+	return r.querySignals(ctx, tradingSignalQuery+`
+		WHERE s.provider_id = $1 AND ($2 OR s.status <> $3)
+		ORDER BY s.created_at DESC, s.id DESC
+		LIMIT $4 OFFSET $5
+	`, providerID, includeActive, string(models.SignalActive), limit, offset)
+}
+
+func (r *PostgresSignalRepository) querySignals(ctx context.Context, query string, args ...interface{}) ([]*models.TradingSignal, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting trading signals: %w", err)
+	}
+	defer rows.Close()
+
+	signals := []*models.TradingSignal{}
+	for rows.Next() {
+		signal, err := scanTradingSignal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning trading signal: %w", err)
+		}
+		signals = append(signals, signal)
+	}
+	return signals, rows.Err()
+}
+
+// Subscribe crea o reactiva la suscripción del usuario a un proveedor verificado
+func (r *PostgresSignalRepository) Subscribe(ctx context.Context, userID, providerID int64) (*models.SignalSubscription, error) {
+	var id int64
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO signal_subscriptions (user_id, provider_id, status, subscribed_at)
+		SELECT $1, user_id, $3, NOW() FROM signal_providers WHERE user_id = $2 AND is_verified
+		ON CONFLICT (user_id, provider_id) DO UPDATE SET
+			subscribed_at = CASE WHEN signal_subscriptions.status = EXCLUDED.status
+			                     AND (signal_subscriptions.expires_at IS NULL OR signal_subscriptions.expires_at > NOW())
+			                     THEN signal_subscriptions.subscribed_at ELSE NOW() END,
+			status = EXCLUDED.status,
+			expires_at = NULL
+		RETURNING id
+	`, userID, providerID, models.SignalSubscriptionActive).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSignalProviderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error saving signal subscription: %w", err)
+	}
+
+	sub, err := scanSignalSubscription(r.pool.QueryRow(ctx, signalSubscriptionQuery+` WHERE s.id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("error getting signal subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// Unsubscribe cancela la suscripción activa
+func (r *PostgresSignalRepository) Unsubscribe(ctx context.Context, userID, providerID int64) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE signal_subscriptions SET status = $3
+		WHERE user_id = $1 AND provider_id = $2 AND status = $4
+	`, userID, providerID, models.SignalSubscriptionCanceled, models.SignalSubscriptionActive)
+	if err != nil {
+		return false, fmt.Errorf("error canceling signal subscription: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// IsSubscribed indica si el usuario tiene suscripción vigente al proveedor
+func (r *PostgresSignalRepository) IsSubscribed(ctx context.Context, userID, providerID int64) (bool, error) {
+	var subscribed bool
+	err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 `+activeSubscribersQuery+` AND user_id = $2)`,
+		providerID, userID).Scan(&subscribed)
+	if err != nil {
+		return false, fmt.Errorf("error checking signal subscription: %w", err)
+	}
+	return subscribed, nil
+}
+
+// GetUserSubscriptions suscripciones del usuario (vigentes primero)
+func (r *PostgresSignalRepository) GetUserSubscriptions(ctx context.Context, userID int64) ([]*models.SignalSubscription, error) {
+	rows, err := r.pool.Query(ctx, signalSubscriptionQuery+`
+		WHERE s.user_id = $1
+		ORDER BY s.status, s.subscribed_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting signal subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := []*models.SignalSubscription{}
+	for rows.Next() {
+		sub, err := scanSignalSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning signal subscription: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+func scanSignalProvider(row pgx.Row) (*models.SignalProvider, error) {
+	p := &models.SignalProvider{}
+	err := row.Scan(&p.UserID, &p.DisplayName, &p.Bio, &p.IsVerified, &p.VerifiedAt, &p.TotalSignals,
+		&p.Hits, &p.Misses, &p.HitRate, &p.CurrentStreak, &p.BestStreak, &p.WorstStreak, &p.Subscribers, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func scanTradingSignal(row pgx.Row) (*models.TradingSignal, error) {
+	s := &models.TradingSignal{}
+	var direction, status string
+	var result *string
+	err := row.Scan(&s.ID, &s.ProviderID, &s.ProviderName, &s.Symbol, &direction, &s.Timeframe, &s.Confidence,
+		&s.Analysis, &s.EntryPrice, &s.ExitPrice, &status, &result, &s.CreatedAt, &s.ExpiresAt, &s.ClosedAt)
+	if err != nil {
+		return nil, err
+	}
+	s.Direction = models.TradeDirection(direction)
+	s.Status = models.SignalStatus(status)
+	if result != nil {
+		r := models.SignalResult(*result)
+		s.Result = &r
+	}
+	return s, nil
+}
+
+func scanSignalSubscription(row pgx.Row) (*models.SignalSubscription, error) {
+	sub := &models.SignalSubscription{}
+	err := row.Scan(&sub.ID, &sub.UserID, &sub.ProviderID, &sub.ProviderName, &sub.Status, &sub.SubscribedAt, &sub.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
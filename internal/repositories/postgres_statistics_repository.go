@@ -0,0 +1,417 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"tormentus/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresStatisticsRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresStatisticsRepository(pool *pgxpool.Pool) *PostgresStatisticsRepository {
+	return &PostgresStatisticsRepository{pool: pool}
+}
+
+// statsPendingTrades trades cerrados que aún no se sumaron a las estadísticas
+const statsPendingTrades = `
+	stats_recorded_at IS NULL AND tournament_id IS NULL AND status IN ('won', 'lost', 'draw', 'sold')`
+
+// recordTradeStatistics suma un trade cerrado a las estadísticas diarias, por símbolo,
+// por hora y a las rachas de su dueño, en la transacción de la liquidación. Cada trade
+// se suma una sola vez (trades.stats_recorded_at); los de torneo no se registran.
+func recordTradeStatistics(ctx context.Context, tx pgx.Tx, tradeID int64) error {
+	var (
+		userID              int64
+		symbol, status      string
+		isDemo              bool
+		amount, profit      float64
+		closedDate, closeAt time.Time
+		hour                int
+	)
+	err := tx.QueryRow(ctx, `
+		UPDATE trades SET stats_recorded_at = NOW()
+		WHERE id = $1 AND `+statsPendingTrades+`
+		RETURNING user_id, symbol, is_demo, amount, COALESCE(profit, 0), status,
+		          COALESCE(closed_at, LOCALTIMESTAMP)::date, COALESCE(closed_at, LOCALTIMESTAMP), EXTRACT(HOUR FROM created_at)::int
+	`, tradeID).Scan(&userID, &symbol, &isDemo, &amount, &profit, &status, &closedDate, &closeAt, &hour)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error marking trade statistics: %w", err)
+	}
+
+	won, lost := 0, 0
+	switch models.TradeStatus(status) {
+	case models.TradeWon:
+		won = 1
+	case models.TradeLost:
+		lost = 1
+	}
+	gain, loss := math.Max(profit, 0), math.Max(-profit, 0)
+	winRate := 0.0
+	if won+lost > 0 {
+		winRate = float64(won) * 100
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO user_daily_statistics (user_id, is_demo, date, trades_count, won_count, lost_count, volume, profit, loss, net)
+		VALUES ($1, $2, $3, 1, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (user_id, is_demo, date) DO UPDATE SET
+			trades_count = user_daily_statistics.trades_count + 1,
+			won_count = user_daily_statistics.won_count + EXCLUDED.won_count,
+			lost_count = user_daily_statistics.lost_count + EXCLUDED.lost_count,
+			volume = user_daily_statistics.volume + EXCLUDED.volume,
+			profit = user_daily_statistics.profit + EXCLUDED.profit,
+			loss = user_daily_statistics.loss + EXCLUDED.loss,
+			net = user_daily_statistics.net + EXCLUDED.net
+	`, userID, isDemo, closedDate, won, lost, amount, gain, loss, profit)
+	if err != nil {
+		return fmt.Errorf("error updating daily statistics: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO user_symbol_statistics (user_id, is_demo, symbol, trades_count, won_count, lost_count, win_rate,
+		                                    volume, gross_profit, gross_loss, total_profit, best_trade, worst_trade, updated_at)
+		VALUES ($1, $2, $3, 1, $4, $5, $6, $7, $8, $9, $10, $10, $10, NOW())
+		ON CONFLICT (user_id, is_demo, symbol) DO UPDATE SET
+			trades_count = user_symbol_statistics.trades_count + 1,
+			won_count = user_symbol_statistics.won_count + EXCLUDED.won_count,
+			lost_count = user_symbol_statistics.lost_count + EXCLUDED.lost_count,
+			win_rate = COALESCE(ROUND(
+				(user_symbol_statistics.won_count + EXCLUDED.won_count) * 100.0 /
+				NULLIF(user_symbol_statistics.won_count + EXCLUDED.won_count +
+				       user_symbol_statistics.lost_count + EXCLUDED.lost_count, 0), 2), 0),
+			volume = user_symbol_statistics.volume + EXCLUDED.volume,
+			gross_profit = user_symbol_statistics.gross_profit + EXCLUDED.gross_profit,
+			gross_loss = user_symbol_statistics.gross_loss + EXCLUDED.gross_loss,
+			total_profit = user_symbol_statistics.total_profit + EXCLUDED.total_profit,
+			best_trade = GREATEST(user_symbol_statistics.best_trade, EXCLUDED.best_trade),
+			worst_trade = LEAST(user_symbol_statistics.worst_trade, EXCLUDED.worst_trade),
+			updated_at = NOW()
+	`, userID, isDemo, symbol, won, lost, winRate, amount, gain, loss, profit)
+	if err != nil {
+		return fmt.Errorf("error updating symbol statistics: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO user_hourly_statistics (user_id, is_demo, hour, trades_count, won_count, lost_count, net)
+		VALUES ($1, $2, $3, 1, $4, $5, $6)
+		ON CONFLICT (user_id, is_demo, hour) DO UPDATE SET
+			trades_count = user_hourly_statistics.trades_count + 1,
+			won_count = user_hourly_statistics.won_count + EXCLUDED.won_count,
+			lost_count = user_hourly_statistics.lost_count + EXCLUDED.lost_count,
+			net = user_hourly_statistics.net + EXCLUDED.net
+	`, userID, isDemo, hour, won, lost, profit)
+	if err != nil {
+		return fmt.Errorf("error updating hourly statistics: %w", err)
+	}
+
+	if won+lost == 0 {
+		return nil
+	}
+	return recordTradeStreak(ctx, tx, userID, isDemo, won == 1, closeAt)
+}
+
+// recordTradeStreak actualiza las rachas del usuario con un trade ganado o perdido
+func recordTradeStreak(ctx context.Context, tx pgx.Tx, userID int64, isDemo, won bool, at time.Time) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO user_trade_streaks (user_id, is_demo) VALUES ($1, $2) ON CONFLICT DO NOTHING
+	`, userID, isDemo)
+	if err != nil {
+		return fmt.Errorf("error creating trade streaks: %w", err)
+	}
+
+	streaks, err := scanTradeStreaks(tx.QueryRow(ctx, `
+		SELECT current_streak, best_win_streak, worst_loss_streak, last_trade_at
+		FROM user_trade_streaks WHERE user_id = $1 AND is_demo = $2
+		FOR UPDATE
+	`, userID, isDemo))
+	if err != nil {
+		return fmt.Errorf("error getting trade streaks: %w", err)
+	}
+
+	streaks.Record(won, at)
+	_, err = tx.Exec(ctx, `
+		UPDATE user_trade_streaks SET current_streak = $3, best_win_streak = $4, worst_loss_streak = $5, last_trade_at = $6
+		WHERE user_id = $1 AND is_demo = $2
+	`, userID, isDemo, streaks.CurrentStreak, streaks.BestWinStreak, streaks.WorstLossStreak, streaks.LastTradeAt)
+	if err != nil {
+		return fmt.Errorf("error updating trade streaks: %w", err)
+	}
+	return nil
+}
+
+// BackfillStatistics registra los trades cerrados pendientes en lotes, cada uno en su
+// transacción. Los trades bloqueados por una liquidación en curso se saltan. Como las
+// liquidaciones en vivo registran rachas antes que el historial, al terminar las rachas
+// se reconstruyen en orden de cierre.
+func (r *PostgresStatisticsRepository) BackfillStatistics(ctx context.Context, batchSize int) (int, error) {
+	total := 0
+	for {
+		processed, err := r.backfillBatch(ctx, batchSize)
+		total += processed
+		if err != nil {
+			return total, err
+		}
+		if processed < batchSize {
+			break
+		}
+	}
+	if total == 0 {
+		return 0, nil
+	}
+	return total, r.rebuildTradeStreaks(ctx)
+}
+
+// rebuildTradeStreaks recalcula las rachas de cada cuenta con trades registrados,
+// recorriendo sus trades ganados y perdidos en orden de cierre
+func (r *PostgresStatisticsRepository) rebuildTradeStreaks(ctx context.Context) error {
+	rows, err := r.pool.Query(ctx, `
+		SELECT DISTINCT user_id, is_demo FROM trades
+		WHERE stats_recorded_at IS NOT NULL AND tournament_id IS NULL AND status IN ('won', 'lost')
+	`)
+	if err != nil {
+		return fmt.Errorf("error getting streak accounts: %w", err)
+	}
+	type account struct {
+		userID int64
+		isDemo bool
+	}
+	var accounts []account
+	for rows.Next() {
+		var a account
+		if err := rows.Scan(&a.userID, &a.isDemo); err != nil {
+			rows.Close()
+			return fmt.Errorf("error scanning streak account: %w", err)
+		}
+		accounts = append(accounts, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error getting streak accounts: %w", err)
+	}
+
+	for _, a := range accounts {
+		if err := r.rebuildAccountStreaks(ctx, a.userID, a.isDemo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rebuildAccountStreaks recalcula las rachas de una cuenta con la fila bloqueada: una
+// liquidación simultánea espera y suma su trade sobre el resultado
+func (r *PostgresStatisticsRepository) rebuildAccountStreaks(ctx context.Context, userID int64, isDemo bool) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO user_trade_streaks (user_id, is_demo) VALUES ($1, $2) ON CONFLICT DO NOTHING
+	`, userID, isDemo)
+	if err != nil {
+		return fmt.Errorf("error creating trade streaks: %w", err)
+	}
+	_, err = tx.Exec(ctx, `
+		SELECT 1 FROM user_trade_streaks WHERE user_id = $1 AND is_demo = $2 FOR UPDATE
+	`, userID, isDemo)
+	if err != nil {
+		return fmt.Errorf("error locking trade streaks: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT status = 'won', COALESCE(closed_at, created_at)
+		FROM trades
+		WHERE user_id = $1 AND is_demo = $2 AND stats_recorded_at IS NOT NULL
+		  AND tournament_id IS NULL AND status IN ('won', 'lost')
+		ORDER BY COALESCE(closed_at, created_at), id
+	`, userID, isDemo)
+	if err != nil {
+		return fmt.Errorf("error getting streak history: %w", err)
+	}
+	streaks := &models.TradeStreaks{}
+	for rows.Next() {
+		var won bool
+		var at time.Time
+		if err := rows.Scan(&won, &at); err != nil {
+			rows.Close()
+			return fmt.Errorf("error scanning streak history: %w", err)
+		}
+		streaks.Record(won, at)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error getting streak history: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE user_trade_streaks SET current_streak = $3, best_win_streak = $4, worst_loss_streak = $5, last_trade_at = $6
+		WHERE user_id = $1 AND is_demo = $2
+	`, userID, isDemo, streaks.CurrentStreak, streaks.BestWinStreak, streaks.WorstLossStreak, streaks.LastTradeAt)
+	if err != nil {
+		return fmt.Errorf("error updating trade streaks: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing trade streaks: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresStatisticsRepository) backfillBatch(ctx context.Context, batchSize int) (int, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT id FROM trades
+		WHERE `+statsPendingTrades+`
+		ORDER BY closed_at, id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("error getting pending trades: %w", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("error scanning pending trade: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error getting pending trades: %w", err)
+	}
+
+	for _, id := range ids {
+		if err := recordTradeStatistics(ctx, tx, id); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("error committing statistics backfill: %w", err)
+	}
+	return len(ids), nil
+}
+
+// GetDailyStatistics calendario de P&L del usuario
+func (r *PostgresStatisticsRepository) GetDailyStatistics(ctx context.Context, userID int64, isDemo bool, from, to time.Time) ([]*models.DailyStatistics, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT date, COALESCE(trades_count, 0), COALESCE(won_count, 0), COALESCE(lost_count, 0),
+		       COALESCE(volume, 0), COALESCE(profit, 0), COALESCE(loss, 0), COALESCE(net, 0)
+		FROM user_daily_statistics
+		WHERE user_id = $1 AND is_demo = $2 AND date BETWEEN $3 AND $4
+		ORDER BY date
+	`, userID, isDemo, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error getting daily statistics: %w", err)
+	}
+	defer rows.Close()
+
+	days := []*models.DailyStatistics{}
+	for rows.Next() {
+		d := &models.DailyStatistics{}
+		var date time.Time
+		if err := rows.Scan(&date, &d.Trades, &d.Wins, &d.Losses, &d.Volume, &d.Profit, &d.Loss, &d.Net); err != nil {
+			return nil, fmt.Errorf("error scanning daily statistics: %w", err)
+		}
+		d.Date = date.Format("2006-01-02")
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
+
+// GetSymbolStatistics resultado por símbolo, más operados primero
+func (r *PostgresStatisticsRepository) GetSymbolStatistics(ctx context.Context, userID int64, isDemo bool) ([]*models.SymbolStatistics, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT symbol, COALESCE(trades_count, 0), COALESCE(won_count, 0), COALESCE(lost_count, 0), COALESCE(win_rate, 0),
+		       COALESCE(volume, 0), COALESCE(total_profit, 0), COALESCE(best_trade, 0), COALESCE(worst_trade, 0)
+		FROM user_symbol_statistics
+		WHERE user_id = $1 AND is_demo = $2
+		ORDER BY trades_count DESC, symbol
+	`, userID, isDemo)
+	if err != nil {
+		return nil, fmt.Errorf("error getting symbol statistics: %w", err)
+	}
+	defer rows.Close()
+
+	symbols := []*models.SymbolStatistics{}
+	for rows.Next() {
+		s := &models.SymbolStatistics{}
+		if err := rows.Scan(&s.Symbol, &s.Trades, &s.Wins, &s.Losses, &s.WinRate,
+			&s.Volume, &s.NetProfit, &s.BestTrade, &s.WorstTrade); err != nil {
+			return nil, fmt.Errorf("error scanning symbol statistics: %w", err)
+		}
+		symbols = append(symbols, s)
+	}
+	return symbols, rows.Err()
+}
+
+// GetHourlyStatistics resultado por hora de apertura (solo horas con trades)
+func (r *PostgresStatisticsRepository) GetHourlyStatistics(ctx context.Context, userID int64, isDemo bool) ([]*models.HourlyStatistics, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT hour, trades_count, won_count, lost_count, net
+		FROM user_hourly_statistics
+		WHERE user_id = $1 AND is_demo = $2
+		ORDER BY hour
+	`, userID, isDemo)
+	if err != nil {
+		return nil, fmt.Errorf("error getting hourly statistics: %w", err)
+	}
+	defer rows.Close()
+
+	hours := []*models.HourlyStatistics{}
+	for rows.Next() {
+		h := &models.HourlyStatistics{}
+		if err := rows.Scan(&h.Hour, &h.Trades, &h.Wins, &h.Losses, &h.Net); err != nil {
+			return nil, fmt.Errorf("error scanning hourly statistics: %w", err)
+		}
+		if decided := h.Wins + h.Losses; decided > 0 {
+			h.WinRate = math.Round(float64(h.Wins)*10000/float64(decided)) / 100
+		}
+		hours = append(hours, h)
+	}
+	return hours, rows.Err()
+}
+
+// GetStreaks rachas del usuario (ceros si aún no cerró trades)
+func (r *PostgresStatisticsRepository) GetStreaks(ctx context.Context, userID int64, isDemo bool) (*models.TradeStreaks, error) {
+	streaks, err := scanTradeStreaks(r.pool.QueryRow(ctx, `
+		SELECT current_streak, best_win_streak, worst_loss_streak, last_trade_at
+		FROM user_trade_streaks WHERE user_id = $1 AND is_demo = $2
+	`, userID, isDemo))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &models.TradeStreaks{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting trade streaks: %w", err)
+	}
+	return streaks, nil
+}
+
+func scanTradeStreaks(row pgx.Row) (*models.TradeStreaks, error) {
+	s := &models.TradeStreaks{}
+	if err := row.Scan(&s.CurrentStreak, &s.BestWinStreak, &s.WorstLossStreak, &s.LastTradeAt); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -105,9 +105,9 @@ func (r *PostgresTournamentRepository) Join(tournamentID, userID int64) (*models
 func (r *PostgresTournamentRepository) GetParticipant(tournamentID, userID int64) (*models.TournamentParticipant, error) {
 	var p models.TournamentParticipant
 	err := r.db.QueryRow(`
-		SELECT id, tournament_id, user_id, balance, profit, rank, trades_count, joined_at
+		SELECT id, tournament_id, user_id, balance, profit, rank, trades_count, COALESCE(wins_count, 0), joined_at
 		FROM tournament_participants WHERE tournament_id = $1 AND user_id = $2
-	`, tournamentID, userID).Scan(&p.ID, &p.TournamentID, &p.UserID, &p.Balance, &p.Profit, &p.Rank, &p.TradesCount, &p.JoinedAt)
+	`, tournamentID, userID).Scan(&p.ID, &p.TournamentID, &p.UserID, &p.Balance, &p.Profit, &p.Rank, &p.TradesCount, &p.WinsCount, &p.JoinedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -128,7 +128,7 @@ func (r *PostgresTournamentRepository) GetLeaderboard(tournamentID int64, limit
 	}
 
 	rows, err := r.db.Query(`
-		SELECT tp.id, tp.tournament_id, tp.user_id, tp.balance, tp.profit, tp.trades_count, tp.joined_at,
+		SELECT tp.id, tp.tournament_id, tp.user_id, tp.balance, tp.profit, tp.trades_count, COALESCE(tp.wins_count, 0), tp.joined_at,
 			   COALESCE(u.first_name, 'Trader') || ' ' || COALESCE(LEFT(u.last_name, 1), '') as username
 		FROM tournament_participants tp
 		LEFT JOIN users u ON tp.user_id = u.id
@@ -149,7 +149,7 @@ func (r *PostgresTournamentRepository) GetLeaderboard(tournamentID int64, limit
 	for rows.Next() {
 		rank++
 		var p models.TournamentParticipant
-		err := rows.Scan(&p.ID, &p.TournamentID, &p.UserID, &p.Balance, &p.Profit, &p.TradesCount, &p.JoinedAt, &p.Username)
+		err := rows.Scan(&p.ID, &p.TournamentID, &p.UserID, &p.Balance, &p.Profit, &p.TradesCount, &p.WinsCount, &p.JoinedAt, &p.Username)
 		if err != nil {
 			continue
 		}
@@ -192,19 +192,10 @@ func (r *PostgresTournamentRepository) GetUserTournaments(userID int64) ([]model
 	return tournaments, nil
 }
 
-func (r *PostgresTournamentRepository) UpdateParticipantBalance(tournamentID, userID int64, balance, profit float64) error {
-	_, err := r.db.Exec(`
-		UPDATE tournament_participants 
-		SET balance = $1, profit = $2, trades_count = trades_count + 1
-		WHERE tournament_id = $3 AND user_id = $4
-	`, balance, profit, tournamentID, userID)
-	return err
-}
-
 func (r *PostgresTournamentRepository) Rebuy(tournamentID, userID int64, initialBalance float64) error {
 	_, err := r.db.Exec(`
 		UPDATE tournament_participants 
-		SET balance = $1, profit = 0, trades_count = 0
+		SET balance = $1, profit = 0, trades_count = 0, wins_count = 0
 		WHERE tournament_id = $2 AND user_id = $3
 	`, initialBalance, tournamentID, userID)
 	return err
@@ -2,16 +2,22 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
+	"tormentus/internal/events"
 	"tormentus/internal/models"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrInsufficientBalance indica que el débito condicional no encontró saldo suficiente
+var ErrInsufficientBalance = errors.New("balance insuficiente")
+
 type PostgresTradeRepository struct {
 	pool *pgxpool.Pool
 }
@@ -21,14 +27,31 @@ func NewPostgresTradeRepository(pool *pgxpool.Pool) *PostgresTradeRepository {
 }
 
 func (r *PostgresTradeRepository) CreateTrade(ctx context.Context, trade *models.Trade) error {
+	if err := insertTrade(ctx, r.pool, trade); err != nil {
+		log.Printf("Error creating trade: %v", err)
+		return err
+	}
+
+	log.Printf("Trade created: ID=%d, User=%d, Symbol=%s", trade.ID, trade.UserID, trade.Symbol)
+	return nil
+}
+
+// rowQuerier pool o transacción para consultas de una fila
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// insertTrade inserta el trade (sin débito de balance) y asigna su ID
+func insertTrade(ctx context.Context, q rowQuerier, trade *models.Trade) error {
 	query := `
 		INSERT INTO trades (user_id, symbol, direction, amount, entry_price, payout_percentage, 
-		                    status, duration, is_demo, expires_at, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		                    status, duration, is_demo, expires_at, created_at, tournament_id,
+		                    option_type, barrier_high, barrier_low, expiry_mode, timeframe)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 		RETURNING id
 	`
 
-	err := r.pool.QueryRow(ctx, query,
+	err := q.QueryRow(ctx, query,
 		trade.UserID,
 		trade.Symbol,
 		string(trade.Direction),
@@ -40,27 +63,195 @@ func (r *PostgresTradeRepository) CreateTrade(ctx context.Context, trade *models
 		trade.IsDemo,
 		trade.ExpiresAt,
 		trade.CreatedAt,
+		trade.TournamentID,
+		optionTypeOrDefault(trade.OptionType),
+		trade.BarrierHigh,
+		trade.BarrierLow,
+		expiryModeOrDefault(trade.ExpiryMode),
+		nullIfEmpty(trade.Timeframe),
 	).Scan(&trade.ID)
-
 	if err != nil {
-		log.Printf("Error creating trade: %v", err)
 		return fmt.Errorf("error creating trade: %w", err)
 	}
+	return nil
+}
+
+// PlaceTrade inserta el trade y descuenta el monto del balance en una sola transacción
+// (del balance del participante si el trade pertenece a un torneo).
+// El débito es condicional, por lo que el balance nunca queda negativo. Si se indica
+// idempotencyKey y ya existe un trade del usuario con esa clave, se devuelve ese trade
+// sin volver a debitar (replayed = true). Los eventos TradePlaced y BalanceChanged
+// (este último salvo en torneos) se escriben en el outbox en la misma transacción.
+func (r *PostgresTradeRepository) PlaceTrade(ctx context.Context, trade *models.Trade, idempotencyKey string, checkUsage func(openTrades int, dailyVolume float64) error) (*models.Trade, bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var key *string
+	if idempotencyKey != "" {
+		key = &idempotencyKey
+	}
+
+	// Un insert concurrente con la misma clave espera al primero y no inserta nada
+	insertQuery := `
+		INSERT INTO trades (user_id, symbol, direction, amount, entry_price, payout_percentage, 
+		                    status, duration, is_demo, expires_at, created_at, idempotency_key, tournament_id,
+		                    option_type, barrier_high, barrier_low, expiry_mode, timeframe)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		ON CONFLICT (user_id, idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING
+		RETURNING id
+	`
+	err = tx.QueryRow(ctx, insertQuery,
+		trade.UserID,
+		trade.Symbol,
+		string(trade.Direction),
+		trade.Amount,
+		trade.EntryPrice,
+		trade.Payout,
+		string(trade.Status),
+		trade.Duration,
+		trade.IsDemo,
+		trade.ExpiresAt,
+		trade.CreatedAt,
+		key,
+		trade.TournamentID,
+		optionTypeOrDefault(trade.OptionType),
+		trade.BarrierHigh,
+		trade.BarrierLow,
+		expiryModeOrDefault(trade.ExpiryMode),
+		nullIfEmpty(trade.Timeframe),
+	).Scan(&trade.ID)
+
+	if err == pgx.ErrNoRows {
+		// El trade en conflicto ya fue confirmado por la otra transacción
+		existing, err := r.GetTradeByIdempotencyKey(ctx, trade.UserID, idempotencyKey)
+		if err != nil {
+			return nil, false, err
+		}
+		if existing == nil {
+			return nil, false, fmt.Errorf("trade con clave de idempotencia %q no encontrado", idempotencyKey)
+		}
+		return existing, true, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error creating trade: %w", err)
+	}
+
+	// Los trades de torneo se debitan del balance del participante
+	var tag pgconn.CommandTag
+	switch {
+	case trade.TournamentID != nil:
+		tag, err = tx.Exec(ctx, `
+			UPDATE tournament_participants SET balance = balance - $1
+			WHERE tournament_id = $2 AND user_id = $3 AND balance >= $1
+		`, trade.Amount, *trade.TournamentID, trade.UserID)
+	case trade.IsDemo:
+		tag, err = tx.Exec(ctx, `UPDATE users SET demo_balance = demo_balance - $1 WHERE id = $2 AND demo_balance >= $1`, trade.Amount, trade.UserID)
+	default:
+		tag, err = tx.Exec(ctx, `UPDATE users SET balance = balance - $1 WHERE id = $2 AND balance >= $1`, trade.Amount, trade.UserID)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error debiting balance: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, false, ErrInsufficientBalance
+	}
+
+	if err := checkTradeUsage(ctx, tx, trade, checkUsage); err != nil {
+		return nil, false, err
+	}
+
+	placed := []events.Payload{events.TradePlacedEvent{Trade: trade}}
+	if trade.TournamentID == nil {
+		placed = append(placed, events.BalanceChangedEvent{
+			UserID: trade.UserID,
+			Amount: -trade.Amount,
+			IsDemo: trade.IsDemo,
+			Reason: events.ReasonTradePlaced,
+			RefID:  trade.ID,
+		})
+	}
+	if err := insertOutboxEvents(ctx, tx, placed...); err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("error committing trade: %w", err)
+	}
 
 	log.Printf("Trade created: ID=%d, User=%d, Symbol=%s", trade.ID, trade.UserID, trade.Symbol)
-	return nil
+	return trade, false, nil
+}
+
+// checkTradeUsage aplica checkUsage con las operaciones abiertas y el volumen del día
+// de la cuenta del trade (real o demo; los trades de torneo no se verifican). Bloquea la
+// fila del usuario para que las colocaciones simultáneas se verifiquen de a una; el
+// trade recién insertado no se cuenta. El error de checkUsage se devuelve tal cual.
+func checkTradeUsage(ctx context.Context, tx pgx.Tx, trade *models.Trade, checkUsage func(openTrades int, dailyVolume float64) error) error {
+	if checkUsage == nil || trade.TournamentID != nil {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, trade.UserID); err != nil {
+		return fmt.Errorf("error locking user: %w", err)
+	}
+
+	var openTrades int
+	var dailyVolume float64
+	err := tx.QueryRow(ctx, `
+		SELECT COUNT(*) FILTER (WHERE status = 'pending'),
+		       COALESCE(SUM(amount) FILTER (WHERE created_at >= date_trunc('day', NOW())), 0)
+		FROM trades
+		WHERE user_id = $1 AND is_demo = $2 AND tournament_id IS NULL AND id <> $3
+	`, trade.UserID, trade.IsDemo, trade.ID).Scan(&openTrades, &dailyVolume)
+	if err != nil {
+		return fmt.Errorf("error getting trade usage: %w", err)
+	}
+
+	return checkUsage(openTrades, dailyVolume)
+}
+
+// GetTradeByIdempotencyKey busca el trade colocado por el usuario con esa clave
+func (r *PostgresTradeRepository) GetTradeByIdempotencyKey(ctx context.Context, userID int64, key string) (*models.Trade, error) {
+	query := `
+		SELECT id, user_id, symbol, direction, amount, entry_price, exit_price,
+		       payout_percentage, profit, status, duration, is_demo,
+		       created_at, expires_at, closed_at, tournament_id,
+		       COALESCE(option_type, 'high_low'), barrier_high, barrier_low, touched_at,
+		       COALESCE(expiry_mode, 'duration'), COALESCE(timeframe, '')
+		FROM trades WHERE user_id = $1 AND idempotency_key = $2
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID, key)
+	if err != nil {
+		return nil, fmt.Errorf("error getting trade by idempotency key: %w", err)
+	}
+	defer rows.Close()
+
+	trades, err := r.scanTrades(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(trades) == 0 {
+		return nil, nil
+	}
+	return trades[0], nil
 }
 
 func (r *PostgresTradeRepository) GetTradeByID(ctx context.Context, id int64) (*models.Trade, error) {
 	query := `
 		SELECT id, user_id, symbol, direction, amount, entry_price, exit_price,
 		       payout_percentage, profit, status, duration, is_demo, 
-		       created_at, expires_at, closed_at
+		       created_at, expires_at, closed_at, tournament_id,
+		       COALESCE(option_type, 'high_low'), barrier_high, barrier_low, touched_at,
+		       COALESCE(expiry_mode, 'duration'), COALESCE(timeframe, '')
 		FROM trades WHERE id = $1
 	`
 
 	var trade models.Trade
-	var direction, status string
+	var direction, status, optionType, expiryMode string
 	var exitPrice, profit *float64
 	var closedAt *time.Time
 
@@ -68,6 +259,8 @@ func (r *PostgresTradeRepository) GetTradeByID(ctx context.Context, id int64) (*
 		&trade.ID, &trade.UserID, &trade.Symbol, &direction, &trade.Amount,
 		&trade.EntryPrice, &exitPrice, &trade.Payout, &profit, &status,
 		&trade.Duration, &trade.IsDemo, &trade.CreatedAt, &trade.ExpiresAt, &closedAt,
+		&trade.TournamentID, &optionType, &trade.BarrierHigh, &trade.BarrierLow, &trade.TouchedAt,
+		&expiryMode, &trade.Timeframe,
 	)
 
 	if err != nil {
@@ -79,6 +272,8 @@ func (r *PostgresTradeRepository) GetTradeByID(ctx context.Context, id int64) (*
 
 	trade.Direction = models.TradeDirection(direction)
 	trade.Status = models.TradeStatus(status)
+	trade.OptionType = models.OptionType(optionType)
+	trade.ExpiryMode = models.ExpiryMode(expiryMode)
 	if exitPrice != nil {
 		trade.ExitPrice = *exitPrice
 	}
@@ -90,12 +285,13 @@ func (r *PostgresTradeRepository) GetTradeByID(ctx context.Context, id int64) (*
 	return &trade, nil
 }
 
-
 func (r *PostgresTradeRepository) GetUserTrades(ctx context.Context, userID int64, limit, offset int) ([]*models.Trade, error) {
 	query := `
 		SELECT id, user_id, symbol, direction, amount, entry_price, exit_price,
 		       payout_percentage, profit, status, duration, is_demo,
-		       created_at, expires_at, closed_at
+		       created_at, expires_at, closed_at, tournament_id,
+		       COALESCE(option_type, 'high_low'), barrier_high, barrier_low, touched_at,
+		       COALESCE(expiry_mode, 'duration'), COALESCE(timeframe, '')
 		FROM trades 
 		WHERE user_id = $1 
 		ORDER BY created_at DESC 
@@ -115,7 +311,9 @@ func (r *PostgresTradeRepository) GetActiveTrades(ctx context.Context, userID in
 	query := `
 		SELECT id, user_id, symbol, direction, amount, entry_price, exit_price,
 		       payout_percentage, profit, status, duration, is_demo,
-		       created_at, expires_at, closed_at
+		       created_at, expires_at, closed_at, tournament_id,
+		       COALESCE(option_type, 'high_low'), barrier_high, barrier_low, touched_at,
+		       COALESCE(expiry_mode, 'duration'), COALESCE(timeframe, '')
 		FROM trades 
 		WHERE user_id = $1 AND status = 'active'
 		ORDER BY created_at DESC
@@ -130,38 +328,196 @@ func (r *PostgresTradeRepository) GetActiveTrades(ctx context.Context, userID in
 	return r.scanTrades(rows)
 }
 
-func (r *PostgresTradeRepository) UpdateTrade(ctx context.Context, trade *models.Trade) error {
+// GetPendingTrades obtiene todos los trades sin liquidar, ordenados por expiración
+func (r *PostgresTradeRepository) GetPendingTrades(ctx context.Context) ([]*models.Trade, error) {
+	query := `
+		SELECT id, user_id, symbol, direction, amount, entry_price, exit_price,
+		       payout_percentage, profit, status, duration, is_demo,
+		       created_at, expires_at, closed_at, tournament_id,
+		       COALESCE(option_type, 'high_low'), barrier_high, barrier_low, touched_at,
+		       COALESCE(expiry_mode, 'duration'), COALESCE(timeframe, '')
+		FROM trades 
+		WHERE status = 'pending'
+		ORDER BY expires_at ASC
+	`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("error getting pending trades: %w", err)
+	}
+	defer rows.Close()
+
+	return r.scanTrades(rows)
+}
+
+// UpdateTrade persiste el resultado de un trade pending, acredita credit (al balance
+// real/demo o al participante del torneo), liquida el copy trading de los trades en
+// cuenta real y guarda su registro de liquidación. Los eventos indicados se escriben
+// en el outbox en la misma transacción. Si el trade ya
+// no está pending devuelve models.ErrTradeAlreadySettled sin acreditar nada.
+func (r *PostgresTradeRepository) UpdateTrade(ctx context.Context, trade *models.Trade, credit float64, evts ...events.Payload) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
 		UPDATE trades 
-		SET exit_price = $1, profit = $2, status = $3, closed_at = $4
-		WHERE id = $5
+		SET exit_price = $1, profit = $2, status = $3, closed_at = $4, touched_at = $5
+		WHERE id = $6 AND status = $7
 	`
 
-	_, err := r.pool.Exec(ctx, query,
+	tag, err := tx.Exec(ctx, query,
 		trade.ExitPrice,
 		trade.Profit,
 		string(trade.Status),
 		trade.ClosedAt,
+		trade.TouchedAt,
 		trade.ID,
+		string(models.TradePending),
 	)
 
 	if err != nil {
 		return fmt.Errorf("error updating trade: %w", err)
 	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrTradeAlreadySettled
+	}
+
+	if err := creditSettlement(ctx, tx, trade, credit); err != nil {
+		return err
+	}
+
+	// Copy trading: comisión del líder sobre la ganancia ya acreditada al seguidor
+	if !trade.IsDemo && trade.TournamentID == nil {
+		if err := settleCopy(ctx, tx, trade); err != nil {
+			return err
+		}
+	}
+
+	if err := insertSettlement(ctx, tx, trade.Settlement); err != nil {
+		return err
+	}
+
+	if err := recordTradeStatistics(ctx, tx, trade.ID); err != nil {
+		return err
+	}
+
+	if err := insertOutboxEvents(ctx, tx, evts...); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("error committing trade update: %w", err)
+	}
 
 	return nil
 }
 
+// creditSettlement acredita el cierre de un trade. Los trades de torneo suman al
+// balance, resultado y contadores del participante; los demás, al balance real o demo.
+func creditSettlement(ctx context.Context, tx pgx.Tx, trade *models.Trade, credit float64) error {
+	var err error
+	switch {
+	case trade.TournamentID != nil:
+		wins := 0
+		if trade.Status == models.TradeWon {
+			wins = 1
+		}
+		_, err = tx.Exec(ctx, `
+			UPDATE tournament_participants 
+			SET balance = balance + $1, profit = profit + $2, trades_count = trades_count + 1,
+			    wins_count = COALESCE(wins_count, 0) + $3
+			WHERE tournament_id = $4 AND user_id = $5
+		`, credit, trade.Profit, wins, *trade.TournamentID, trade.UserID)
+	case credit <= 0:
+		return nil
+	case trade.IsDemo:
+		_, err = tx.Exec(ctx, `UPDATE users SET demo_balance = demo_balance + $1 WHERE id = $2`, credit, trade.UserID)
+	default:
+		_, err = tx.Exec(ctx, `UPDATE users SET balance = balance + $1 WHERE id = $2`, credit, trade.UserID)
+	}
+	if err != nil {
+		return fmt.Errorf("error crediting settlement: %w", err)
+	}
+	return nil
+}
+
+// insertSettlement guarda el registro de liquidación; el primero registrado es definitivo
+func insertSettlement(ctx context.Context, tx pgx.Tx, record *models.SettlementRecord) error {
+	if record == nil {
+		return nil
+	}
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO trade_settlements (trade_id, rule, price_source, exit_price, bid, ask,
+		                               tick_at, settled_at, status, profit, note)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (trade_id) DO NOTHING
+	`,
+		record.TradeID,
+		string(record.Rule),
+		nullIfEmpty(record.PriceSource),
+		record.ExitPrice,
+		record.Bid,
+		record.Ask,
+		record.TickAt,
+		record.SettledAt,
+		string(record.Status),
+		record.Profit,
+		nullIfEmpty(record.Note),
+	)
+	if err != nil {
+		return fmt.Errorf("error saving settlement record: %w", err)
+	}
+	return nil
+}
+
+// GetSettlement obtiene el registro de liquidación de un trade
+func (r *PostgresTradeRepository) GetSettlement(ctx context.Context, tradeID int64) (*models.SettlementRecord, error) {
+	return getSettlement(ctx, r.pool, `
+		SELECT trade_id, rule, COALESCE(price_source, ''), COALESCE(exit_price, 0),
+		       COALESCE(bid, 0), COALESCE(ask, 0), tick_at, settled_at, status,
+		       COALESCE(profit, 0), COALESCE(note, '')
+		FROM trade_settlements
+		WHERE trade_id = $1
+	`, tradeID)
+}
+
+// getSettlement ejecuta una consulta que devuelve las columnas de trade_settlements
+func getSettlement(ctx context.Context, pool *pgxpool.Pool, query string, args ...interface{}) (*models.SettlementRecord, error) {
+	var record models.SettlementRecord
+	var rule, status string
+	err := pool.QueryRow(ctx, query, args...).Scan(
+		&record.TradeID, &rule, &record.PriceSource, &record.ExitPrice,
+		&record.Bid, &record.Ask, &record.TickAt, &record.SettledAt, &status,
+		&record.Profit, &record.Note,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting settlement record: %w", err)
+	}
+	record.Rule = models.SettlementRule(rule)
+	record.Status = models.TradeStatus(status)
+	return &record, nil
+}
+
+// GetUserTradeStats totales de trades cerrados fuera de torneos (cuenta real y demo),
+// leídos de las estadísticas por símbolo mantenidas al liquidar. El win rate cuenta
+// solo ganados y perdidos.
 func (r *PostgresTradeRepository) GetUserTradeStats(ctx context.Context, userID int64) (*models.TradeStats, error) {
 	query := `
 		SELECT 
-			COUNT(*) as total_trades,
-			COALESCE(SUM(CASE WHEN status = 'won' THEN 1 ELSE 0 END), 0) as wins,
-			COALESCE(SUM(CASE WHEN status = 'lost' THEN 1 ELSE 0 END), 0) as losses,
-			COALESCE(SUM(profit), 0) as total_profit,
-			COALESCE(SUM(amount), 0) as total_volume
-		FROM trades 
-		WHERE user_id = $1 AND status IN ('won', 'lost')
+			COALESCE(SUM(trades_count), 0) as total_trades,
+			COALESCE(SUM(won_count), 0) as wins,
+			COALESCE(SUM(lost_count), 0) as losses,
+			COALESCE(SUM(total_profit), 0) as total_profit,
+			COALESCE(SUM(volume), 0) as total_volume
+		FROM user_symbol_statistics 
+		WHERE user_id = $1
 	`
 
 	var stats models.TradeStats
@@ -177,8 +533,8 @@ func (r *PostgresTradeRepository) GetUserTradeStats(ctx context.Context, userID
 		return nil, fmt.Errorf("error getting trade stats: %w", err)
 	}
 
-	if stats.TotalTrades > 0 {
-		stats.WinRate = float64(stats.Wins) / float64(stats.TotalTrades) * 100
+	if decided := stats.Wins + stats.Losses; decided > 0 {
+		stats.WinRate = float64(stats.Wins) / float64(decided) * 100
 	}
 
 	return &stats, nil
@@ -209,12 +565,35 @@ func (r *PostgresTradeRepository) GetRecentWinners(ctx context.Context, hours in
 	return winners, nil
 }
 
+// optionTypeOrDefault los trades sin tipo son high_low
+func optionTypeOrDefault(o models.OptionType) string {
+	if o == "" {
+		return string(models.OptionHighLow)
+	}
+	return string(o)
+}
+
+// expiryModeOrDefault los trades sin modo expiran por duración
+func expiryModeOrDefault(m models.ExpiryMode) string {
+	if m == "" {
+		return string(models.ExpiryDuration)
+	}
+	return string(m)
+}
+
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
 func (r *PostgresTradeRepository) scanTrades(rows pgx.Rows) ([]*models.Trade, error) {
 	var trades []*models.Trade
 
 	for rows.Next() {
 		var trade models.Trade
-		var direction, status string
+		var direction, status, optionType, expiryMode string
 		var exitPrice, profit *float64
 		var closedAt *time.Time
 
@@ -222,6 +601,8 @@ func (r *PostgresTradeRepository) scanTrades(rows pgx.Rows) ([]*models.Trade, er
 			&trade.ID, &trade.UserID, &trade.Symbol, &direction, &trade.Amount,
 			&trade.EntryPrice, &exitPrice, &trade.Payout, &profit, &status,
 			&trade.Duration, &trade.IsDemo, &trade.CreatedAt, &trade.ExpiresAt, &closedAt,
+			&trade.TournamentID, &optionType, &trade.BarrierHigh, &trade.BarrierLow, &trade.TouchedAt,
+			&expiryMode, &trade.Timeframe,
 		)
 		if err != nil {
 			return nil, err
@@ -229,6 +610,8 @@ func (r *PostgresTradeRepository) scanTrades(rows pgx.Rows) ([]*models.Trade, er
 
 		trade.Direction = models.TradeDirection(direction)
 		trade.Status = models.TradeStatus(status)
+		trade.OptionType = models.OptionType(optionType)
+		trade.ExpiryMode = models.ExpiryMode(expiryMode)
 		if exitPrice != nil {
 			trade.ExitPrice = *exitPrice
 		}
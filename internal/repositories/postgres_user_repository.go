@@ -231,30 +231,30 @@ func (r *PostgresUserRepository) GetUserStats(ctx context.Context, userID int64)
 
 	// Obtener datos básicos del usuario
 	userQuery := `
-		SELECT total_trades, win_rate, total_deposits, total_withdrawals
+		SELECT total_deposits, total_withdrawals
 		FROM users WHERE id = $1
 	`
-	err := r.db.QueryRow(ctx, userQuery, userID).Scan(
-		&stats.TotalTrades, &stats.WinRate, &stats.TotalDeposits, &stats.TotalWithdrawals,
-	)
+	err := r.db.QueryRow(ctx, userQuery, userID).Scan(&stats.TotalDeposits, &stats.TotalWithdrawals)
 	if err != nil && err != pgx.ErrNoRows {
 		return nil, fmt.Errorf("error getting user data: %w", err)
 	}
 
-	// Obtener estadísticas de trades
+	// Estadísticas de trades (mantenidas al liquidar, sin torneos). Total, montos y
+	// promedio cubren todos los trades cerrados (ganados, perdidos, empatados y vendidos)
 	tradesQuery := `
 		SELECT 
-			COALESCE(COUNT(*) FILTER (WHERE status = 'won'), 0) as won,
-			COALESCE(COUNT(*) FILTER (WHERE status = 'lost'), 0) as lost,
-			COALESCE(SUM(profit) FILTER (WHERE profit > 0), 0) as total_profit,
-			COALESCE(ABS(SUM(profit) FILTER (WHERE profit < 0)), 0) as total_loss,
-			COALESCE(MAX(profit), 0) as best_trade,
-			COALESCE(MIN(profit), 0) as worst_trade,
-			COALESCE(AVG(amount), 0) as avg_amount
-		FROM trades WHERE user_id = $1 AND status IN ('won', 'lost')
+			COALESCE(SUM(trades_count), 0) as total_trades,
+			COALESCE(SUM(won_count), 0) as won,
+			COALESCE(SUM(lost_count), 0) as lost,
+			COALESCE(SUM(gross_profit), 0) as total_profit,
+			COALESCE(SUM(gross_loss), 0) as total_loss,
+			COALESCE(MAX(best_trade), 0) as best_trade,
+			COALESCE(MIN(worst_trade), 0) as worst_trade,
+			COALESCE(SUM(volume) / NULLIF(SUM(trades_count), 0), 0) as avg_amount
+		FROM user_symbol_statistics WHERE user_id = $1
 	`
 	err = r.db.QueryRow(ctx, tradesQuery, userID).Scan(
-		&stats.WonTrades, &stats.LostTrades, &stats.TotalProfit, &stats.TotalLoss,
+		&stats.TotalTrades, &stats.WonTrades, &stats.LostTrades, &stats.TotalProfit, &stats.TotalLoss,
 		&stats.BestTrade, &stats.WorstTrade, &stats.AvgTradeAmount,
 	)
 	if err != nil && err != pgx.ErrNoRows {
@@ -262,6 +262,9 @@ func (r *PostgresUserRepository) GetUserStats(ctx context.Context, userID int64)
 	}
 
 	stats.NetProfit = stats.TotalProfit - stats.TotalLoss
+	if decided := stats.WonTrades + stats.LostTrades; decided > 0 {
+		stats.WinRate = float64(stats.WonTrades) / float64(decided) * 100
+	}
 
 	// Contar torneos
 	tournamentQuery := `SELECT COUNT(*) FROM tournament_participants WHERE user_id = $1`
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+// PriceTickRepository persiste ticks de precio para liquidaciones y auditoría
+type PriceTickRepository interface {
+	SaveTicks(ctx context.Context, ticks []models.PriceData) error
+	GetTickAt(ctx context.Context, symbol string, at time.Time) (*models.PriceData, error)
+	DeleteTicksBefore(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,12 @@
+package repositories
+
+import (
+	"context"
+
+	"tormentus/internal/models"
+)
+
+// RiskRepository reúne bloqueos y límites configurados por operadores
+type RiskRepository interface {
+	GetRiskProfile(ctx context.Context, userID int64, symbol string, isDemo bool) (*models.RiskProfile, error)
+}
@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"tormentus/internal/models"
+)
+
+// ErrSignalProviderNotFound el proveedor no existe o no está verificado
+var ErrSignalProviderNotFound = errors.New("proveedor de señales no encontrado")
+
+// SignalRepository señales de trading, proveedores y suscripciones
+type SignalRepository interface {
+	// UpsertProvider registra o actualiza el perfil de proveedor y lo marca verificado
+	UpsertProvider(ctx context.Context, provider *models.SignalProvider, verifiedBy int64) error
+	// RevokeProvider retira la verificación; false si no estaba verificado
+	RevokeProvider(ctx context.Context, userID int64) (bool, error)
+	GetProvider(ctx context.Context, userID int64) (*models.SignalProvider, error)
+	GetProviders(ctx context.Context, limit, offset int) ([]*models.SignalProvider, error)
+
+	// PublishSignal inserta la señal y notifica a los suscriptores activos en una sola
+	// transacción (ErrSignalProviderNotFound si el proveedor no está verificado).
+	// Devuelve los usuarios notificados.
+	PublishSignal(ctx context.Context, signal *models.TradingSignal) (subscribers []int64, err error)
+	GetActiveSignals(ctx context.Context) ([]*models.TradingSignal, error)
+	// ScoreSignal cierra la señal con su resultado, actualiza el historial del proveedor y
+	// notifica a los suscriptores. scored es false si la señal ya estaba cerrada.
+	ScoreSignal(ctx context.Context, signal *models.TradingSignal) (subscribers []int64, scored bool, err error)
+	GetSignal(ctx context.Context, id int64) (*models.TradingSignal, error)
+	// GetFeed señales de los proveedores a los que el usuario está suscrito
+	GetFeed(ctx context.Context, userID int64, limit, offset int) ([]*models.TradingSignal, error)
+	// GetProviderSignals historial del proveedor; las activas solo si includeActive
+	GetProviderSignals(ctx context.Context, providerID int64, includeActive bool, limit, offset int) ([]*models.TradingSignal, error)
+
+	// Subscribe crea o reactiva la suscripción (ErrSignalProviderNotFound si no está verificado)
+	Subscribe(ctx context.Context, userID, providerID int64) (*models.SignalSubscription, error)
+	// Unsubscribe cancela la suscripción; false si no estaba activa
+	Unsubscribe(ctx context.Context, userID, providerID int64) (bool, error)
+	IsSubscribed(ctx context.Context, userID, providerID int64) (bool, error)
+	GetUserSubscriptions(ctx context.Context, userID int64) ([]*models.SignalSubscription, error)
+}
@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+// StatisticsRepository estadísticas de trading por usuario, mantenidas al liquidar cada
+// trade (ver recordTradeStatistics). No incluyen trades de torneo.
+type StatisticsRepository interface {
+	// GetDailyStatistics días con trades cerrados en [from, to]
+	GetDailyStatistics(ctx context.Context, userID int64, isDemo bool, from, to time.Time) ([]*models.DailyStatistics, error)
+	GetSymbolStatistics(ctx context.Context, userID int64, isDemo bool) ([]*models.SymbolStatistics, error)
+	GetHourlyStatistics(ctx context.Context, userID int64, isDemo bool) ([]*models.HourlyStatistics, error)
+	GetStreaks(ctx context.Context, userID int64, isDemo bool) (*models.TradeStreaks, error)
+	// BackfillStatistics suma los trades cerrados aún no registrados, en orden de cierre y
+	// en lotes de batchSize, y luego reconstruye las rachas desde el historial ordenado.
+	// Devuelve la cantidad de trades procesados.
+	BackfillStatistics(ctx context.Context, batchSize int) (int, error)
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"time"
 
+	"tormentus/internal/models"
+
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -741,6 +743,18 @@ func (r *SupportAgentRepository) GetUserNotes(ctx context.Context, userID int64)
 	return notes, nil
 }
 
+// GetUserTradeSettlement obtiene el registro de liquidación de un trade del usuario
+func (r *SupportAgentRepository) GetUserTradeSettlement(ctx context.Context, userID, tradeID int64) (*models.SettlementRecord, error) {
+	return getSettlement(ctx, r.pool, `
+		SELECT s.trade_id, s.rule, COALESCE(s.price_source, ''), COALESCE(s.exit_price, 0),
+		       COALESCE(s.bid, 0), COALESCE(s.ask, 0), s.tick_at, s.settled_at, s.status,
+		       COALESCE(s.profit, 0), COALESCE(s.note, '')
+		FROM trade_settlements s
+		JOIN trades t ON t.id = s.trade_id
+		WHERE t.user_id = $1 AND s.trade_id = $2
+	`, userID, tradeID)
+}
+
 // AddUserNote agrega una nota a un usuario
 func (r *SupportAgentRepository) AddUserNote(ctx context.Context, userID, authorID int64, note string) (*AgentUserNote, error) {
 	query := `
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"tormentus/internal/events"
 	"tormentus/internal/models"
 )
 
@@ -11,7 +12,9 @@ type TradeRepository interface {
 	GetTradeByID(ctx context.Context, id int64) (*models.Trade, error)
 	GetUserTrades(ctx context.Context, userID int64, limit, offset int) ([]*models.Trade, error)
 	GetActiveTrades(ctx context.Context, userID int64) ([]*models.Trade, error)
-	UpdateTrade(ctx context.Context, trade *models.Trade) error
+	GetPendingTrades(ctx context.Context) ([]*models.Trade, error)
+	UpdateTrade(ctx context.Context, trade *models.Trade, credit float64, evts ...events.Payload) error
 	GetUserTradeStats(ctx context.Context, userID int64) (*models.TradeStats, error)
 	GetRecentWinners(ctx context.Context, hours int) ([]int64, error)
+	GetSettlement(ctx context.Context, tradeID int64) (*models.SettlementRecord, error)
 }
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"tormentus/internal/models"
+
+	"github.com/gorilla/websocket"
+)
+
+// binanceReadTimeout sin mensajes durante este plazo la conexión se da por caída
+const binanceReadTimeout = 30 * time.Second
+
+// binanceStreamMessage mensaje de un stream combinado (/stream?streams=...)
+type binanceStreamMessage struct {
+	Stream string              `json:"stream"`
+	Data   binanceTickerStream `json:"data"`
+}
+
+// binanceTickerStream evento 24hrTicker de <symbol>@ticker. encoding/json compara las
+// claves sin distinguir mayúsculas: las que solo difieren en eso ("e"/"E", "c"/"C", ...)
+// se declaran todas para que cada una vaya a su campo.
+type binanceTickerStream struct {
+	Event              string          `json:"e"`
+	EventTime          int64           `json:"E"`
+	Symbol             string          `json:"s"`
+	PriceChange        string          `json:"p"`
+	PriceChangePercent string          `json:"P"`
+	LastPrice          string          `json:"c"`
+	CloseTime          int64           `json:"C"`
+	BidPrice           string          `json:"b"`
+	BidQty             string          `json:"B"`
+	AskPrice           string          `json:"a"`
+	AskQty             string          `json:"A"`
+	HighPrice          string          `json:"h"`
+	LowPrice           string          `json:"l"`
+	LastTradeID        json.RawMessage `json:"L"`
+	Volume             string          `json:"v"`
+}
+
+// BinanceFeed adaptador WebSocket estilo Binance: se suscribe a <symbol>@ticker de cada
+// símbolo en un stream combinado (baseURL + /stream?streams=...)
+type BinanceFeed struct {
+	baseURL string
+	dialer  *websocket.Dialer
+}
+
+// NewBinanceFeed crea el feed WebSocket (ej. wss://stream.binance.com:9443)
+func NewBinanceFeed(baseURL string) *BinanceFeed {
+	return &BinanceFeed{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		dialer:  &websocket.Dialer{HandshakeTimeout: 10 * time.Second},
+	}
+}
+
+func (f *BinanceFeed) Name() string {
+	return FeedBinance
+}
+
+// Run mantiene la conexión hasta que se cancele ctx; devuelve error si se pierde
+func (f *BinanceFeed) Run(ctx context.Context, symbols []string, emit func(tick models.PriceData)) error {
+	symbolMap := newSymbolMap(symbols)
+	streams := make([]string, 0, len(symbols))
+	for _, exchange := range symbolMap.exchangeSymbols(symbols) {
+		streams = append(streams, strings.ToLower(exchange)+"@ticker")
+	}
+
+	conn, _, err := f.dialer.DialContext(ctx, f.baseURL+"/stream?streams="+strings.Join(streams, "/"), nil)
+	if err != nil {
+		return fmt.Errorf("error conectando a %s: %w", f.baseURL, err)
+	}
+	defer conn.Close()
+
+	// Cerrar la conexión al cancelar ctx desbloquea ReadMessage
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(binanceReadTimeout))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("error leyendo stream: %w", err)
+		}
+
+		var msg binanceStreamMessage
+		if err := json.Unmarshal(data, &msg); err != nil || msg.Data.Event != "24hrTicker" {
+			continue
+		}
+		symbol, ok := symbolMap.internal(msg.Data.Symbol)
+		if !ok {
+			continue
+		}
+		tick, ok := parseBinanceTicker(symbol, msg.Data.LastPrice, msg.Data.BidPrice, msg.Data.AskPrice,
+			msg.Data.HighPrice, msg.Data.LowPrice, msg.Data.PriceChangePercent, msg.Data.Volume)
+		if ok {
+			emit(tick)
+		}
+	}
+}
+
+// parseBinanceTicker convierte los campos de texto de un ticker; false si falta el precio
+func parseBinanceTicker(symbol, last, bid, ask, high, low, changePercent, volume string) (models.PriceData, bool) {
+	parse := func(s string) float64 {
+		v, _ := strconv.ParseFloat(s, 64)
+		return v
+	}
+
+	tick := models.PriceData{
+		Symbol:    symbol,
+		Price:     parse(last),
+		Bid:       parse(bid),
+		Ask:       parse(ask),
+		High24h:   parse(high),
+		Low24h:    parse(low),
+		Change24h: parse(changePercent),
+		Volume:    parse(volume),
+	}
+	return tick, tick.Price > 0
+}
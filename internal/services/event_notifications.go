@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"tormentus/internal/events"
+	"tormentus/internal/models"
+)
+
+// NotificationCreator persiste notificaciones de usuario
+type NotificationCreator interface {
+	CreateNotification(notification *models.Notification) error
+}
+
+// EventNotifier crea notificaciones a partir de los eventos de wallet
+type EventNotifier struct {
+	notifications NotificationCreator
+}
+
+// NewEventNotifier crea el suscriptor de notificaciones
+func NewEventNotifier(notifications NotificationCreator) *EventNotifier {
+	return &EventNotifier{notifications: notifications}
+}
+
+// Register suscribe el notificador a depósitos confirmados y retiros procesados
+func (n *EventNotifier) Register(bus *events.Bus) {
+	bus.Subscribe("wallet_notifications", n.Handle, events.DepositConfirmed, events.WithdrawalProcessed)
+}
+
+// Handle crea la notificación correspondiente al evento
+func (n *EventNotifier) Handle(ctx context.Context, event events.Event) error {
+	var notification *models.Notification
+
+	switch event.Type {
+	case events.DepositConfirmed:
+		var e events.DepositConfirmedEvent
+		if err := event.Decode(&e); err != nil {
+			return err
+		}
+		notification = &models.Notification{
+			UserID:  e.UserID,
+			Type:    "deposit",
+			Title:   "Depósito confirmado",
+			Message: fmt.Sprintf("Se acreditaron $%.2f a tu cuenta", e.CreditedAmount),
+			Data:    string(event.Payload),
+		}
+	case events.WithdrawalProcessed:
+		var e events.WithdrawalProcessedEvent
+		if err := event.Decode(&e); err != nil {
+			return err
+		}
+		notification = &models.Notification{
+			UserID:  e.UserID,
+			Type:    "withdrawal",
+			Title:   "Retiro aprobado",
+			Message: fmt.Sprintf("Tu retiro de $%.2f fue aprobado", e.Amount),
+			Data:    string(event.Payload),
+		}
+		if e.Status == "rejected" {
+			notification.Title = "Retiro rechazado"
+			notification.Message = fmt.Sprintf("Tu retiro de $%.2f fue rechazado", e.Amount)
+		}
+	default:
+		return nil
+	}
+
+	return n.notifications.CreateNotification(notification)
+}
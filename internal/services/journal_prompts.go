@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"tormentus/internal/events"
+	"tormentus/internal/models"
+	"tormentus/internal/websocket"
+)
+
+// journalPromptMaxAge antigüedad máxima de un cierre para invitar a registrarlo (los
+// eventos atrasados tras un reinicio no generan invitaciones)
+const journalPromptMaxAge = 10 * time.Minute
+
+// msgJournalPrompt mensaje WebSocket de invitación al diario
+const msgJournalPrompt = "journal_prompt"
+
+// JournalEntryFinder busca la entrada del diario de un trade
+type JournalEntryFinder interface {
+	GetEntryByTrade(ctx context.Context, tradeID int64) (*models.JournalEntry, error)
+}
+
+// JournalPrompter invita por WebSocket a completar el diario al cerrarse un trade
+type JournalPrompter struct {
+	entries JournalEntryFinder
+	hub     *websocket.Hub
+}
+
+// NewJournalPrompter crea el suscriptor de invitaciones al diario
+func NewJournalPrompter(entries JournalEntryFinder, hub *websocket.Hub) *JournalPrompter {
+	return &JournalPrompter{entries: entries, hub: hub}
+}
+
+// Register suscribe el invitador a los trades cerrados
+func (p *JournalPrompter) Register(bus *events.Bus) {
+	bus.Subscribe("journal_prompts", p.Handle, events.TradeSettled)
+}
+
+// Handle envía la invitación salvo para trades anulados o ya registrados con motivo de salida
+func (p *JournalPrompter) Handle(ctx context.Context, event events.Event) error {
+	if time.Since(event.CreatedAt) > journalPromptMaxAge {
+		return nil
+	}
+
+	var e events.TradeSettledEvent
+	if err := event.Decode(&e); err != nil {
+		return err
+	}
+	trade := e.Trade
+	if trade == nil || trade.Status == models.TradeCanceled {
+		return nil
+	}
+
+	entry, err := p.entries.GetEntryByTrade(ctx, trade.ID)
+	if err != nil {
+		return err
+	}
+	if entry != nil && entry.ExitReason != "" {
+		return nil
+	}
+
+	prompt := models.JournalPrompt{
+		TradeID:   trade.ID,
+		Symbol:    trade.Symbol,
+		Direction: trade.Direction,
+		Status:    trade.Status,
+		Profit:    trade.Profit,
+	}
+	if entry != nil {
+		prompt.EntryID = &entry.ID
+	}
+	p.hub.BroadcastToUser(trade.UserID, msgJournalPrompt, prompt)
+	return nil
+}
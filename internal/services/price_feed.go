@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"tormentus/internal/models"
+)
+
+// Nombres de los feeds de precios (PRICE_FEED_DEFAULT y PRICE_FEED_SYMBOLS)
+const (
+	FeedSimulated = "simulated" // Precios simulados (desarrollo)
+	FeedBinance   = "binance"   // WebSocket estilo Binance (<symbol>@ticker)
+	FeedREST      = "rest"      // Consulta periódica a /api/v3/ticker/24hr
+	FeedReplay    = "replay"    // Reproducción de ticks desde un archivo CSV
+)
+
+// PriceFeed fuente de precios de mercado. Run emite los ticks de los símbolos indicados
+// (en formato interno, ej. "BTC/USDT") hasta que se cancele ctx. Si devuelve un error
+// (ej. conexión perdida) PriceService lo vuelve a iniciar con backoff exponencial; si
+// devuelve nil el feed terminó y no se reinicia.
+type PriceFeed interface {
+	Name() string
+	Run(ctx context.Context, symbols []string, emit func(tick models.PriceData)) error
+}
+
+// priceSetter feed que acepta fijar el precio de un símbolo (ver SetManipulatedPrice)
+type priceSetter interface {
+	SetPrice(symbol string, price float64)
+}
+
+// ExchangeSymbol símbolo del proveedor para un símbolo interno ("BTC/USDT" → "BTCUSDT")
+func ExchangeSymbol(symbol string) string {
+	return strings.ToUpper(strings.ReplaceAll(symbol, "/", ""))
+}
+
+// symbolMap traduce entre los símbolos internos de un feed y los del proveedor
+type symbolMap struct {
+	toExchange   map[string]string
+	fromExchange map[string]string
+}
+
+func newSymbolMap(symbols []string) symbolMap {
+	m := symbolMap{
+		toExchange:   make(map[string]string, len(symbols)),
+		fromExchange: make(map[string]string, len(symbols)),
+	}
+	for _, symbol := range symbols {
+		exchange := ExchangeSymbol(symbol)
+		m.toExchange[symbol] = exchange
+		m.fromExchange[exchange] = symbol
+	}
+	return m
+}
+
+// exchangeSymbols símbolos del proveedor, en el orden de symbols
+func (m symbolMap) exchangeSymbols(symbols []string) []string {
+	result := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		result = append(result, m.toExchange[symbol])
+	}
+	return result
+}
+
+// internal símbolo interno de un símbolo del proveedor (sin distinguir mayúsculas)
+func (m symbolMap) internal(exchange string) (string, bool) {
+	symbol, ok := m.fromExchange[strings.ToUpper(exchange)]
+	return symbol, ok
+}
@@ -0,0 +1,150 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"tormentus/internal/models"
+	"tormentus/internal/services"
+	"tormentus/internal/services/pricestub"
+)
+
+// startStub levanta el servidor de precios de prueba hasta el fin del test
+func startStub(t *testing.T, config pricestub.Config) *httptest.Server {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	stub := pricestub.New(config)
+	go stub.Run(ctx)
+	srv := httptest.NewServer(stub.Handler())
+	t.Cleanup(func() {
+		cancel()
+		srv.Close()
+	})
+	return srv
+}
+
+// collect corre el feed hasta recibir n ticks o vencer el plazo
+func collect(t *testing.T, feed services.PriceFeed, symbols []string, n int) []models.PriceData {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ticks := make(chan models.PriceData, n)
+	done := make(chan error, 1)
+	go func() {
+		done <- feed.Run(ctx, symbols, func(tick models.PriceData) {
+			select {
+			case ticks <- tick:
+			default:
+				cancel()
+			}
+		})
+	}()
+
+	var got []models.PriceData
+	for len(got) < n {
+		select {
+		case tick := <-ticks:
+			got = append(got, tick)
+		case err := <-done:
+			t.Fatalf("%s terminó tras %d de %d ticks: %v", feed.Name(), len(got), n, err)
+		case <-ctx.Done():
+			t.Fatalf("%s: %d de %d ticks antes del plazo", feed.Name(), len(got), n)
+		}
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("%s terminó con error al cancelar: %v", feed.Name(), err)
+	}
+	return got
+}
+
+// writeReplayFile guarda ticks en el formato de ReplayFeed, separados por step
+func writeReplayFile(t *testing.T, ticks []models.PriceData, step time.Duration) string {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString("# grabado del stub\ntimestamp,symbol,price,bid,ask\n")
+	start := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	for i, tick := range ticks {
+		at := start.Add(time.Duration(i) * step)
+		ts := strconv.FormatInt(at.UnixMilli(), 10)
+		if i%2 == 1 {
+			ts = at.Format(time.RFC3339Nano) // Ambos formatos de timestamp
+		}
+		fmt.Fprintf(&b, "%s,%s,%s,%s,%s\n", ts, tick.Symbol,
+			strconv.FormatFloat(tick.Price, 'f', -1, 64),
+			strconv.FormatFloat(tick.Bid, 'f', -1, 64),
+			strconv.FormatFloat(tick.Ask, 'f', -1, 64))
+	}
+
+	path := filepath.Join(t.TempDir(), "ticks.csv")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReplayFeedReplaysStubTicks(t *testing.T) {
+	srv := startStub(t, pricestub.Config{Interval: 5 * time.Millisecond})
+	symbols := []string{"BTC/USDT", "ETH/USDT"}
+
+	// Grabar ticks del stub con el feed REST y reproducirlos
+	recorded := collect(t, services.NewRESTFeed(srv.URL, 10*time.Millisecond), symbols, 6)
+	path := writeReplayFile(t, recorded, time.Second)
+
+	// speed 1000: un segundo del archivo se reproduce en 1ms
+	replayed := collect(t, services.NewReplayFeed(path, 1000), symbols, len(recorded)*2)
+	for i, tick := range replayed {
+		want := recorded[i%len(recorded)] // Al terminar el archivo vuelve a empezar
+		if tick.Symbol != want.Symbol || tick.Price != want.Price || tick.Bid != want.Bid || tick.Ask != want.Ask {
+			t.Fatalf("tick %d = %s %.8f (%.8f/%.8f), se esperaba %s %.8f (%.8f/%.8f)", i,
+				tick.Symbol, tick.Price, tick.Bid, tick.Ask, want.Symbol, want.Price, want.Bid, want.Ask)
+		}
+	}
+}
+
+func TestReplayFeedFiltersSymbols(t *testing.T) {
+	ticks := []models.PriceData{
+		{Symbol: "BTC/USDT", Price: 100},
+		{Symbol: "ETH/USDT", Price: 10},
+		{Symbol: "BTC/USDT", Price: 101},
+	}
+	path := writeReplayFile(t, ticks, time.Millisecond)
+
+	got := collect(t, services.NewReplayFeed(path, 1), []string{"BTC/USDT"}, 3)
+	for i, want := range []float64{100, 101, 100} {
+		if got[i].Symbol != "BTC/USDT" || got[i].Price != want {
+			t.Fatalf("tick %d = %s %.2f, se esperaba BTC/USDT %.2f", i, got[i].Symbol, got[i].Price, want)
+		}
+	}
+
+	// Sin ticks de los símbolos asignados el feed falla en vez de girar en vacío
+	err := services.NewReplayFeed(path, 1).Run(context.Background(), []string{"SOL/USDT"}, func(models.PriceData) {})
+	if err == nil {
+		t.Fatal("se esperaba error sin ticks de SOL/USDT")
+	}
+}
+
+func TestBinanceFeedReceivesStubStream(t *testing.T) {
+	srv := startStub(t, pricestub.Config{Interval: 5 * time.Millisecond})
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	got := collect(t, services.NewBinanceFeed(wsURL), []string{"BTC/USDT", "EUR/USD"}, 4)
+	seen := make(map[string]bool)
+	for _, tick := range got {
+		if tick.Price <= 0 || tick.Bid <= 0 || tick.Ask <= tick.Bid {
+			t.Fatalf("tick inválido: %+v", tick)
+		}
+		seen[tick.Symbol] = true
+	}
+	if !seen["BTC/USDT"] || !seen["EUR/USD"] {
+		t.Errorf("símbolos recibidos = %v, se esperaban BTC/USDT y EUR/USD", seen)
+	}
+}
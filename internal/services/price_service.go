@@ -2,11 +2,10 @@ package services
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
-	"math/rand"
-	"net/http"
+	"math"
+	"sort"
 	"sync"
 	"time"
 
@@ -14,153 +13,249 @@ import (
 	"tormentus/internal/websocket"
 )
 
+// TickStore persiste ticks de precio para liquidaciones posteriores
+type TickStore interface {
+	SaveTicks(ctx context.Context, ticks []models.PriceData) error
+	DeleteTicksBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
 // PriceService maneja la obtención y distribución de precios
 type PriceService struct {
-	hub         *websocket.Hub
-	httpClient  *http.Client
-	prices      map[string]*models.PriceData
-	mutex       sync.RWMutex
-	
-	// Configuración de APIs
-	binanceWsURL string
-	alphaVantageKey string
+	hub     *websocket.Hub
+	prices  map[string]*models.PriceData
+	history map[string][]models.PriceData // Ticks recientes por símbolo (orden cronológico)
+	mutex   sync.RWMutex
+
+	// Suscriptores a cada tick (ej. barreras de opciones touch)
+	tickListeners []func(tick *models.PriceData)
+
+	// Persistencia de ticks (opcional)
+	tickStore    TickStore
+	pendingTicks map[string]models.PriceData // Último tick por símbolo desde el último guardado
+
+	// Fuentes de precios: cada símbolo se asigna a un solo feed (ver UseFeeds)
+	feeds      map[string]PriceFeed
+	symbolFeed map[string]string // Símbolo -> nombre del feed
 }
 
-// NewPriceService crea un nuevo servicio de precios
-func NewPriceService(hub *websocket.Hub) *PriceService {
+const (
+	// priceHistorySize cantidad de ticks retenidos por símbolo (~5 minutos a 500ms)
+	priceHistorySize = 600
+	// tickFlushInterval frecuencia de guardado de ticks en DB
+	tickFlushInterval = time.Second
+	// tickRetention antigüedad máxima de los ticks guardados
+	tickRetention = 7 * 24 * time.Hour
+
+	// feedBackoffMin y feedBackoffMax espera entre reintentos de un feed caído; si el
+	// feed funcionó más de feedBackoffMax la espera vuelve al mínimo
+	feedBackoffMin = time.Second
+	feedBackoffMax = time.Minute
+)
+
+// NewPriceService crea un nuevo servicio de precios.
+// tickStore puede ser nil si no se desea persistir ticks.
+func NewPriceService(hub *websocket.Hub, tickStore TickStore) *PriceService {
 	return &PriceService{
-		hub:         hub,
-		httpClient:  &http.Client{Timeout: 10 * time.Second},
-		prices:      make(map[string]*models.PriceData),
-		binanceWsURL: "wss://stream.binance.com:9443/ws",
+		hub:          hub,
+		prices:       make(map[string]*models.PriceData),
+		history:      make(map[string][]models.PriceData),
+		tickStore:    tickStore,
+		pendingTicks: make(map[string]models.PriceData),
+	}
+}
+
+// UseFeeds asigna cada símbolo a su feed: los de assignments (símbolo -> nombre) al
+// indicado y el resto de los símbolos de los mercados a defaultFeed. Debe llamarse antes
+// de Start; sin feeds configurados todos los símbolos usan precios simulados.
+func (ps *PriceService) UseFeeds(feeds map[string]PriceFeed, defaultFeed string, assignments map[string]string) error {
+	if _, ok := feeds[defaultFeed]; !ok {
+		return fmt.Errorf("feed de precios no configurado: %s", defaultFeed)
+	}
+
+	symbolFeed := make(map[string]string)
+	for _, symbols := range marketSymbols {
+		for _, symbol := range symbols {
+			symbolFeed[symbol] = defaultFeed
+		}
+	}
+	for symbol, name := range assignments {
+		if _, ok := feeds[name]; !ok {
+			return fmt.Errorf("feed de precios no configurado para %s: %s", symbol, name)
+		}
+		symbolFeed[symbol] = name
 	}
+
+	ps.feeds = feeds
+	ps.symbolFeed = symbolFeed
+	return nil
 }
 
 // Start inicia el servicio de precios
 func (ps *PriceService) Start(ctx context.Context) {
 	log.Println("Servicio de precios iniciado")
-	
-	// Iniciar generador de precios simulados para desarrollo
-	go ps.startSimulatedPrices(ctx)
-	
-	// En producción, conectar a APIs reales:
-	// go ps.connectBinanceWebSocket(ctx)
-	// go ps.fetchForexPrices(ctx)
-}
 
-// startSimulatedPrices genera precios simulados para desarrollo
-func (ps *PriceService) startSimulatedPrices(ctx context.Context) {
-	// Precios base para diferentes activos
-	basePrices := map[string]float64{
-		// Crypto
-		"BTC/USDT":  67500.00,
-		"ETH/USDT":  3450.00,
-		"BNB/USDT":  580.00,
-		"SOL/USDT":  145.00,
-		"XRP/USDT":  0.52,
-		"DOGE/USDT": 0.12,
-		"ADA/USDT":  0.45,
-		"AVAX/USDT": 35.50,
-		"DOT/USDT":  7.20,
-		"LINK/USDT": 14.80,
-		
-		// Forex
-		"EUR/USD": 1.0850,
-		"GBP/USD": 1.2650,
-		"USD/JPY": 154.50,
-		"USD/CHF": 0.8820,
-		"AUD/USD": 0.6520,
-		"USD/CAD": 1.3650,
-		"NZD/USD": 0.5980,
-		"EUR/GBP": 0.8580,
-		"EUR/JPY": 167.60,
-		"GBP/JPY": 195.40,
-		
-		// Commodities
-		"XAU/USD": 2340.00, // Oro
-		"XAG/USD": 27.50,   // Plata
-		"WTI/USD": 78.50,   // Petróleo WTI
-		"BRENT/USD": 82.30, // Petróleo Brent
-		"XPT/USD": 980.00,  // Platino
-		"XPD/USD": 1050.00, // Paladio
-		"NG/USD":  2.85,    // Gas Natural
-		"COPPER/USD": 4.25, // Cobre
-		
-		// Stocks (índices/ETFs)
-		"SPY/USD":  520.00,  // S&P 500 ETF
-		"QQQ/USD":  445.00,  // Nasdaq ETF
-		"DIA/USD":  390.00,  // Dow Jones ETF
-		"AAPL/USD": 185.00,  // Apple
-		"GOOGL/USD": 175.00, // Google
-		"MSFT/USD": 420.00,  // Microsoft
-		"AMZN/USD": 185.00,  // Amazon
-		"TSLA/USD": 175.00,  // Tesla
-		"NVDA/USD": 880.00,  // Nvidia
-		"META/USD": 505.00,  // Meta
+	if ps.feeds == nil {
+		ps.UseFeeds(map[string]PriceFeed{FeedSimulated: NewSimulatedFeed()}, FeedSimulated, nil)
 	}
 
-	// Inicializar precios
-	for symbol, basePrice := range basePrices {
-		ps.prices[symbol] = &models.PriceData{
-			Symbol:    symbol,
-			Price:     basePrice,
-			Bid:       basePrice * 0.9999,
-			Ask:       basePrice * 1.0001,
-			High24h:   basePrice * 1.02,
-			Low24h:    basePrice * 0.98,
-			Change24h: 0,
-			Timestamp: time.Now(),
-		}
+	// Un goroutine por feed con sus símbolos
+	symbolsByFeed := make(map[string][]string)
+	for symbol, name := range ps.symbolFeed {
+		symbolsByFeed[name] = append(symbolsByFeed[name], symbol)
+	}
+	for name, symbols := range symbolsByFeed {
+		sort.Strings(symbols)
+		log.Printf("[prices] feed %s: %d símbolos", name, len(symbols))
+		go ps.runFeed(ctx, ps.feeds[name], symbols)
 	}
 
-	ticker := time.NewTicker(500 * time.Millisecond) // Actualizar cada 500ms
-	defer ticker.Stop()
+	// Persistir ticks para poder liquidar trades tras un reinicio
+	if ps.tickStore != nil {
+		go ps.persistTicks(ctx)
+	}
+}
 
+// runFeed ejecuta el feed y lo reinicia con backoff exponencial mientras devuelva error
+func (ps *PriceService) runFeed(ctx context.Context, feed PriceFeed, symbols []string) {
+	name := feed.Name()
+	emit := func(tick models.PriceData) {
+		// Un feed solo actualiza los símbolos que tiene asignados
+		if ps.symbolFeed[tick.Symbol] == name {
+			ps.applyTick(tick)
+		}
+	}
+
+	backoff := feedBackoffMin
 	for {
+		started := time.Now()
+		err := feed.Run(ctx, symbols, emit)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			log.Printf("[prices] feed %s terminó", name)
+			return
+		}
+
+		if time.Since(started) > feedBackoffMax {
+			backoff = feedBackoffMin
+		}
+		log.Printf("[prices] feed %s caído: %v (reintento en %s)", name, err, backoff)
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			ps.updateSimulatedPrices(basePrices)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > feedBackoffMax {
+			backoff = feedBackoffMax
 		}
 	}
 }
 
-// updateSimulatedPrices actualiza precios con variación aleatoria
-func (ps *PriceService) updateSimulatedPrices(basePrices map[string]float64) {
+// applyTick actualiza el precio vigente con un tick de un feed. El timestamp es el de
+// recepción (reloj del servidor), igual para todos los feeds; sin bid/ask se usa el
+// precio y sin máximo/mínimo de 24h se mantienen los observados.
+func (ps *PriceService) applyTick(tick models.PriceData) {
+	if tick.Price <= 0 {
+		return
+	}
+
 	ps.mutex.Lock()
 	defer ps.mutex.Unlock()
 
-	for symbol, price := range ps.prices {
-		// Variación aleatoria de -0.1% a +0.1%
-		variation := (rand.Float64() - 0.5) * 0.002
-		newPrice := price.Price * (1 + variation)
-		
-		// Mantener dentro de rango razonable (±5% del precio base)
-		basePrice := basePrices[symbol]
-		if newPrice > basePrice*1.05 {
-			newPrice = basePrice * 1.05
-		} else if newPrice < basePrice*0.95 {
-			newPrice = basePrice * 0.95
-		}
+	price, exists := ps.prices[tick.Symbol]
+	if !exists {
+		price = &models.PriceData{Symbol: tick.Symbol, High24h: tick.Price, Low24h: tick.Price}
+		ps.prices[tick.Symbol] = price
+	}
 
-		price.Price = newPrice
-		price.Bid = newPrice * 0.9999
-		price.Ask = newPrice * 1.0001
-		price.Change24h = ((newPrice - basePrice) / basePrice) * 100
-		price.Timestamp = time.Now()
+	if tick.Bid <= 0 {
+		tick.Bid = tick.Price
+	}
+	if tick.Ask <= 0 {
+		tick.Ask = tick.Price
+	}
+	if tick.High24h <= 0 {
+		tick.High24h = math.Max(price.High24h, tick.Price)
+	}
+	if tick.Low24h <= 0 {
+		tick.Low24h = math.Min(price.Low24h, tick.Price)
+	}
+	tick.Timestamp = time.Now()
+	*price = tick
 
-		// Actualizar high/low
-		if newPrice > price.High24h {
-			price.High24h = newPrice
-		}
-		if newPrice < price.Low24h {
-			price.Low24h = newPrice
+	ps.recordTick(price)
+
+	// Broadcast a clientes suscritos
+	ps.hub.BroadcastPrice(price)
+}
+
+// recordTick guarda una copia del tick en el historial reciente (requiere mutex tomado)
+func (ps *PriceService) recordTick(price *models.PriceData) {
+	ticks := append(ps.history[price.Symbol], *price)
+	if len(ticks) > priceHistorySize {
+		ticks = ticks[len(ticks)-priceHistorySize:]
+	}
+	ps.history[price.Symbol] = ticks
+	ps.pendingTicks[price.Symbol] = *price
+
+	for _, listener := range ps.tickListeners {
+		tick := *price
+		listener(&tick)
+	}
+}
+
+// OnTick registra una función que recibe una copia de cada tick. Se invoca con el
+// mutex del servicio tomado: no debe bloquear ni llamar de vuelta a PriceService.
+func (ps *PriceService) OnTick(listener func(tick *models.PriceData)) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	ps.tickListeners = append(ps.tickListeners, listener)
+}
+
+// persistTicks guarda periódicamente el último tick de cada símbolo y purga los antiguos
+func (ps *PriceService) persistTicks(ctx context.Context) {
+	flush := time.NewTicker(tickFlushInterval)
+	defer flush.Stop()
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-flush.C:
+			if err := ps.FlushTicks(ctx); err != nil {
+				log.Printf("Error guardando ticks de precio: %v", err)
+			}
+		case <-cleanup.C:
+			deleted, err := ps.tickStore.DeleteTicksBefore(ctx, time.Now().Add(-tickRetention))
+			if err != nil {
+				log.Printf("Error purgando ticks de precio: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Purgados %d ticks de precio antiguos", deleted)
+			}
 		}
+	}
+}
 
-		// Broadcast a clientes suscritos
-		ps.hub.BroadcastPrice(price)
+// FlushTicks guarda de inmediato el último tick pendiente de cada símbolo
+// (ej. al apagar el servidor, para liquidar trades tras el reinicio)
+func (ps *PriceService) FlushTicks(ctx context.Context) error {
+	if ps.tickStore == nil {
+		return nil
+	}
+
+	ps.mutex.Lock()
+	ticks := make([]models.PriceData, 0, len(ps.pendingTicks))
+	for _, tick := range ps.pendingTicks {
+		ticks = append(ticks, tick)
 	}
+	ps.pendingTicks = make(map[string]models.PriceData)
+	ps.mutex.Unlock()
+
+	return ps.tickStore.SaveTicks(ctx, ticks)
 }
 
 // GetPrice obtiene el precio actual de un símbolo
@@ -175,6 +270,29 @@ func (ps *PriceService) GetPrice(symbol string) (*models.PriceData, error) {
 	return price, nil
 }
 
+// GetPriceAt obtiene el último tick registrado en o antes del instante indicado.
+// Devuelve error si el instante no está cubierto por el historial retenido.
+func (ps *PriceService) GetPriceAt(symbol string, at time.Time) (*models.PriceData, error) {
+	ps.mutex.RLock()
+	defer ps.mutex.RUnlock()
+
+	ticks := ps.history[symbol]
+	if len(ticks) == 0 {
+		return nil, fmt.Errorf("sin historial de precios para %s", symbol)
+	}
+
+	// Primer tick posterior a 'at'; el anterior es el vigente en ese instante
+	idx := sort.Search(len(ticks), func(i int) bool {
+		return ticks[i].Timestamp.After(at)
+	})
+	if idx == 0 {
+		return nil, fmt.Errorf("instante %s fuera del historial de %s", at.Format(time.RFC3339), symbol)
+	}
+
+	tick := ticks[idx-1]
+	return &tick, nil
+}
+
 // GetAllPrices obtiene todos los precios
 func (ps *PriceService) GetAllPrices() map[string]*models.PriceData {
 	ps.mutex.RLock()
@@ -187,35 +305,25 @@ func (ps *PriceService) GetAllPrices() map[string]*models.PriceData {
 	return result
 }
 
+// marketSymbols símbolos simulados por tipo de mercado
+var marketSymbols = map[models.MarketType][]string{
+	models.MarketCrypto: {"BTC/USDT", "ETH/USDT", "BNB/USDT", "SOL/USDT", "XRP/USDT",
+		"DOGE/USDT", "ADA/USDT", "AVAX/USDT", "DOT/USDT", "LINK/USDT"},
+	models.MarketForex: {"EUR/USD", "GBP/USD", "USD/JPY", "USD/CHF", "AUD/USD",
+		"USD/CAD", "NZD/USD", "EUR/GBP", "EUR/JPY", "GBP/JPY"},
+	models.MarketCommodities: {"XAU/USD", "XAG/USD", "WTI/USD", "BRENT/USD",
+		"XPT/USD", "XPD/USD", "NG/USD", "COPPER/USD"},
+	models.MarketStocks: {"SPY/USD", "QQQ/USD", "DIA/USD", "AAPL/USD", "GOOGL/USD",
+		"MSFT/USD", "AMZN/USD", "TSLA/USD", "NVDA/USD", "META/USD"},
+}
+
 // GetPricesByMarket obtiene precios por tipo de mercado
 func (ps *PriceService) GetPricesByMarket(marketType models.MarketType) []*models.PriceData {
 	ps.mutex.RLock()
 	defer ps.mutex.RUnlock()
 
 	var result []*models.PriceData
-	
-	cryptoSymbols := []string{"BTC/USDT", "ETH/USDT", "BNB/USDT", "SOL/USDT", "XRP/USDT", 
-		"DOGE/USDT", "ADA/USDT", "AVAX/USDT", "DOT/USDT", "LINK/USDT"}
-	forexSymbols := []string{"EUR/USD", "GBP/USD", "USD/JPY", "USD/CHF", "AUD/USD", 
-		"USD/CAD", "NZD/USD", "EUR/GBP", "EUR/JPY", "GBP/JPY"}
-	commoditySymbols := []string{"XAU/USD", "XAG/USD", "WTI/USD", "BRENT/USD", 
-		"XPT/USD", "XPD/USD", "NG/USD", "COPPER/USD"}
-	stockSymbols := []string{"SPY/USD", "QQQ/USD", "DIA/USD", "AAPL/USD", "GOOGL/USD",
-		"MSFT/USD", "AMZN/USD", "TSLA/USD", "NVDA/USD", "META/USD"}
-
-	var symbols []string
-	switch marketType {
-	case models.MarketCrypto:
-		symbols = cryptoSymbols
-	case models.MarketForex:
-		symbols = forexSymbols
-	case models.MarketCommodities:
-		symbols = commoditySymbols
-	case models.MarketStocks:
-		symbols = stockSymbols
-	}
-
-	for _, symbol := range symbols {
+	for _, symbol := range marketSymbols[marketType] {
 		if price, exists := ps.prices[symbol]; exists {
 			result = append(result, price)
 		}
@@ -224,6 +332,18 @@ func (ps *PriceService) GetPricesByMarket(marketType models.MarketType) []*model
 	return result
 }
 
+// GetMarketType devuelve el tipo de mercado al que pertenece un símbolo
+func (ps *PriceService) GetMarketType(symbol string) (models.MarketType, bool) {
+	for marketType, symbols := range marketSymbols {
+		for _, s := range symbols {
+			if s == symbol {
+				return marketType, true
+			}
+		}
+	}
+	return "", false
+}
+
 // SetManipulatedPrice establece un precio manipulado temporalmente
 func (ps *PriceService) SetManipulatedPrice(symbol string, price float64) {
 	ps.mutex.Lock()
@@ -234,36 +354,13 @@ func (ps *PriceService) SetManipulatedPrice(symbol string, price float64) {
 		priceData.Bid = price * 0.9999
 		priceData.Ask = price * 1.0001
 		priceData.Timestamp = time.Now()
-		
-		ps.hub.BroadcastPrice(priceData)
-	}
-}
-
-// BinanceTickerResponse respuesta de Binance
-type BinanceTickerResponse struct {
-	Symbol    string `json:"symbol"`
-	Price     string `json:"price"`
-	PriceChange string `json:"priceChange"`
-	PriceChangePercent string `json:"priceChangePercent"`
-	HighPrice string `json:"highPrice"`
-	LowPrice  string `json:"lowPrice"`
-	Volume    string `json:"volume"`
-}
+		ps.recordTick(priceData)
 
-// FetchBinancePrices obtiene precios de Binance (para producción)
-func (ps *PriceService) FetchBinancePrices() error {
-	resp, err := ps.httpClient.Get("https://api.binance.com/api/v3/ticker/24hr")
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+		ps.hub.BroadcastPrice(priceData)
 
-	var tickers []BinanceTickerResponse
-	if err := json.NewDecoder(resp.Body).Decode(&tickers); err != nil {
-		return err
+		// Los feeds que lo permiten (simulado) continúan desde este precio
+		if setter, ok := ps.feeds[ps.symbolFeed[symbol]].(priceSetter); ok {
+			setter.SetPrice(symbol, price)
+		}
 	}
-
-	// Procesar y actualizar precios...
-	log.Printf("Obtenidos %d precios de Binance", len(tickers))
-	return nil
 }
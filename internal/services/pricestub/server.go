@@ -0,0 +1,234 @@
+// Package pricestub servidor local de precios con las mismas APIs que usan los feeds
+// binance (WebSocket /stream?streams=<symbol>@ticker) y rest (/api/v3/ticker/24hr).
+// Lo usan el comando price-stub y los tests de los feeds.
+package pricestub
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tormentus/internal/services"
+
+	"github.com/gorilla/websocket"
+)
+
+// quote precio simulado de un símbolo del proveedor
+type quote struct {
+	base, last, high, low, volume float64
+}
+
+// market precios de todos los símbolos, actualizados cada intervalo
+type market struct {
+	mutex  sync.RWMutex
+	quotes map[string]*quote // Símbolo del proveedor (BTCUSDT) -> precio
+}
+
+func newMarket() *market {
+	m := &market{quotes: make(map[string]*quote)}
+	for symbol, base := range services.SimulatedBasePrices() {
+		m.quotes[services.ExchangeSymbol(symbol)] = &quote{base: base, last: base, high: base, low: base}
+	}
+	return m
+}
+
+// run aplica una variación aleatoria de ±0.1% a cada precio por intervalo
+func (m *market) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.mutex.Lock()
+			for _, q := range m.quotes {
+				q.last *= 1 + (rand.Float64()-0.5)*0.002
+				q.high = max(q.high, q.last)
+				q.low = min(q.low, q.last)
+				q.volume += rand.Float64() * 10
+			}
+			m.mutex.Unlock()
+		}
+	}
+}
+
+// ticker respuesta de /api/v3/ticker/24hr para un símbolo (false si no existe)
+func (m *market) ticker(symbol string) (services.BinanceTickerResponse, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	q, ok := m.quotes[strings.ToUpper(symbol)]
+	if !ok {
+		return services.BinanceTickerResponse{}, false
+	}
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', 8, 64) }
+	return services.BinanceTickerResponse{
+		Symbol:             strings.ToUpper(symbol),
+		LastPrice:          format(q.last),
+		BidPrice:           format(q.last * 0.9999),
+		AskPrice:           format(q.last * 1.0001),
+		PriceChange:        format(q.last - q.base),
+		PriceChangePercent: strconv.FormatFloat((q.last-q.base)/q.base*100, 'f', 3, 64),
+		HighPrice:          format(q.high),
+		LowPrice:           format(q.low),
+		Volume:             format(q.volume),
+	}, true
+}
+
+// Config comportamiento del servidor
+type Config struct {
+	Interval  time.Duration // Intervalo entre precios (y entre mensajes del stream)
+	Drop      time.Duration // Cerrar cada conexión WebSocket tras este plazo (0 = nunca)
+	FailEvery int64         // Responder 503 a una de cada N consultas REST (0 = nunca)
+}
+
+// Server endpoints estilo Binance sobre precios simulados
+type Server struct {
+	market   *market
+	config   Config
+	requests atomic.Int64
+	upgrader websocket.Upgrader
+}
+
+// New crea el servidor con los precios base de los activos simulados; Run los actualiza
+func New(config Config) *Server {
+	if config.Interval <= 0 {
+		config.Interval = 500 * time.Millisecond
+	}
+	return &Server{market: newMarket(), config: config}
+}
+
+// Run actualiza los precios cada intervalo hasta que se cancele ctx
+func (s *Server) Run(ctx context.Context) {
+	s.market.run(ctx, s.config.Interval)
+}
+
+// Symbols cantidad de símbolos servidos
+func (s *Server) Symbols() int {
+	return len(s.market.quotes)
+}
+
+// Handler rutas /api/v3/ticker/24hr y /stream
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/ticker/24hr", s.handleTicker24hr)
+	mux.HandleFunc("/stream", s.handleStream)
+	return mux
+}
+
+// handleTicker24hr responde ?symbol=X con un objeto o ?symbols=["X","Y"] con una lista
+func (s *Server) handleTicker24hr(w http.ResponseWriter, r *http.Request) {
+	if s.config.FailEvery > 0 && s.requests.Add(1)%s.config.FailEvery == 0 {
+		http.Error(w, `{"code":-1003,"msg":"Too many requests."}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	var symbols []string
+	single := r.URL.Query().Get("symbol")
+	if single != "" {
+		symbols = []string{single}
+	} else if err := json.Unmarshal([]byte(r.URL.Query().Get("symbols")), &symbols); err != nil || len(symbols) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"code": -1102, "msg": "Mandatory parameter 'symbols' was not sent."})
+		return
+	}
+
+	tickers := make([]services.BinanceTickerResponse, 0, len(symbols))
+	for _, symbol := range symbols {
+		t, ok := s.market.ticker(symbol)
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"code": -1121, "msg": "Invalid symbol."})
+			return
+		}
+		tickers = append(tickers, t)
+	}
+
+	if single != "" {
+		writeJSON(w, http.StatusOK, tickers[0])
+		return
+	}
+	writeJSON(w, http.StatusOK, tickers)
+}
+
+// handleStream envía un evento 24hrTicker por símbolo de ?streams=btcusdt@ticker/... cada
+// intervalo; con Drop cierra la conexión pasado ese plazo
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	var symbols []string
+	for _, stream := range strings.Split(r.URL.Query().Get("streams"), "/") {
+		if symbol, ok := strings.CutSuffix(stream, "@ticker"); ok && symbol != "" {
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		http.Error(w, "streams requerido", http.StatusBadRequest)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	log.Printf("[stub] stream abierto: %d símbolos", len(symbols))
+
+	// Lector para procesar pings/close del cliente
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	var dropped <-chan time.Time
+	if s.config.Drop > 0 {
+		dropped = time.After(s.config.Drop)
+	}
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-dropped:
+			log.Printf("[stub] cerrando stream (drop)")
+			return
+		case <-ticker.C:
+			now := time.Now().UnixMilli()
+			for _, symbol := range symbols {
+				t, ok := s.market.ticker(symbol)
+				if !ok {
+					continue
+				}
+				msg := map[string]any{
+					"stream": symbol + "@ticker",
+					"data": map[string]any{
+						"e": "24hrTicker", "E": now, "s": t.Symbol,
+						"c": t.LastPrice, "b": t.BidPrice, "a": t.AskPrice,
+						"h": t.HighPrice, "l": t.LowPrice, "P": t.PriceChangePercent, "v": t.Volume,
+					},
+				}
+				if err := conn.WriteJSON(msg); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+// replayMaxGap espera máxima entre dos ticks reproducidos (huecos del archivo)
+const replayMaxGap = 10 * time.Second
+
+// ReplayFeed reproduce ticks desde un archivo CSV con columnas
+// timestamp,symbol,price[,bid,ask] (timestamp RFC3339 o Unix en milisegundos), respetando
+// los intervalos originales divididos por speed. Al terminar el archivo vuelve a empezar.
+type ReplayFeed struct {
+	path  string
+	speed float64
+}
+
+// NewReplayFeed crea el feed de reproducción del archivo
+func NewReplayFeed(path string, speed float64) *ReplayFeed {
+	if speed <= 0 {
+		speed = 1
+	}
+	return &ReplayFeed{path: path, speed: speed}
+}
+
+func (f *ReplayFeed) Name() string {
+	return FeedReplay
+}
+
+// Run reproduce el archivo en bucle; solo emite los símbolos indicados
+func (f *ReplayFeed) Run(ctx context.Context, symbols []string, emit func(tick models.PriceData)) error {
+	wanted := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		wanted[symbol] = true
+	}
+
+	for {
+		emitted, err := f.replay(ctx, wanted, emit)
+		if err != nil || ctx.Err() != nil {
+			return err
+		}
+		if emitted == 0 {
+			return fmt.Errorf("%s no tiene ticks de los símbolos asignados", f.path)
+		}
+	}
+}
+
+// replay recorre el archivo una vez y devuelve la cantidad de ticks emitidos
+func (f *ReplayFeed) replay(ctx context.Context, wanted map[string]bool, emit func(tick models.PriceData)) (int, error) {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+
+	var last time.Time
+	emitted := 0
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return emitted, nil
+		}
+		if err != nil {
+			return emitted, fmt.Errorf("%s línea %d: %w", f.path, line, err)
+		}
+		if len(record) < 3 || strings.EqualFold(record[0], "timestamp") {
+			continue
+		}
+
+		at, tick, err := parseReplayRecord(record)
+		if err != nil {
+			return emitted, fmt.Errorf("%s línea %d: %w", f.path, line, err)
+		}
+		if !wanted[tick.Symbol] {
+			continue
+		}
+
+		if !last.IsZero() && at.After(last) {
+			wait := time.Duration(float64(at.Sub(last)) / f.speed)
+			if wait > replayMaxGap {
+				wait = replayMaxGap
+			}
+			select {
+			case <-ctx.Done():
+				return emitted, nil
+			case <-time.After(wait):
+			}
+		}
+		last = at
+
+		emit(tick)
+		emitted++
+	}
+}
+
+// parseReplayRecord interpreta una fila timestamp,symbol,price[,bid,ask]
+func parseReplayRecord(record []string) (time.Time, models.PriceData, error) {
+	var at time.Time
+	raw := strings.TrimSpace(record[0])
+	if ms, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		at = time.UnixMilli(ms)
+	} else if at, err = time.Parse(time.RFC3339Nano, raw); err != nil {
+		return at, models.PriceData{}, fmt.Errorf("timestamp inválido %q", raw)
+	}
+
+	tick := models.PriceData{Symbol: strings.TrimSpace(record[1])}
+	price, err := strconv.ParseFloat(strings.TrimSpace(record[2]), 64)
+	if err != nil || price <= 0 {
+		return at, tick, fmt.Errorf("precio inválido %q", record[2])
+	}
+	tick.Price = price
+	if len(record) >= 5 {
+		tick.Bid, _ = strconv.ParseFloat(strings.TrimSpace(record[3]), 64)
+		tick.Ask, _ = strconv.ParseFloat(strings.TrimSpace(record[4]), 64)
+	}
+	return at, tick, nil
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+// BinanceTickerResponse respuesta de /api/v3/ticker/24hr
+type BinanceTickerResponse struct {
+	Symbol             string `json:"symbol"`
+	LastPrice          string `json:"lastPrice"`
+	BidPrice           string `json:"bidPrice"`
+	AskPrice           string `json:"askPrice"`
+	PriceChange        string `json:"priceChange"`
+	PriceChangePercent string `json:"priceChangePercent"`
+	HighPrice          string `json:"highPrice"`
+	LowPrice           string `json:"lowPrice"`
+	Volume             string `json:"volume"`
+}
+
+// RESTFeed adaptador de consulta periódica a una API REST estilo Binance
+// (baseURL + /api/v3/ticker/24hr?symbols=[...]); una consulta por intervalo para todos
+// sus símbolos
+type RESTFeed struct {
+	baseURL    string
+	interval   time.Duration
+	httpClient *http.Client
+}
+
+// NewRESTFeed crea el feed REST (ej. https://api.binance.com)
+func NewRESTFeed(baseURL string, interval time.Duration) *RESTFeed {
+	return &RESTFeed{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		interval:   interval,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (f *RESTFeed) Name() string {
+	return FeedREST
+}
+
+// Run consulta los precios cada intervalo; devuelve el error de la primera consulta
+// fallida para reintentar con backoff
+func (f *RESTFeed) Run(ctx context.Context, symbols []string, emit func(tick models.PriceData)) error {
+	symbolMap := newSymbolMap(symbols)
+	encoded, err := json.Marshal(symbolMap.exchangeSymbols(symbols))
+	if err != nil {
+		return err
+	}
+	endpoint := f.baseURL + "/api/v3/ticker/24hr?symbols=" + url.QueryEscape(string(encoded))
+
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		tickers, err := f.fetch(ctx, endpoint)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		for _, t := range tickers {
+			symbol, ok := symbolMap.internal(t.Symbol)
+			if !ok {
+				continue
+			}
+			tick, ok := parseBinanceTicker(symbol, t.LastPrice, t.BidPrice, t.AskPrice,
+				t.HighPrice, t.LowPrice, t.PriceChangePercent, t.Volume)
+			if ok {
+				emit(tick)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (f *RESTFeed) fetch(ctx context.Context, endpoint string) ([]BinanceTickerResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error consultando %s: %w", f.baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("respuesta %d de %s", resp.StatusCode, f.baseURL)
+	}
+
+	var tickers []BinanceTickerResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tickers); err != nil {
+		return nil, fmt.Errorf("error decodificando precios: %w", err)
+	}
+	return tickers, nil
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"tormentus/internal/models"
+	"tormentus/internal/websocket"
+)
+
+// Errores de publicación de señales
+var (
+	ErrInvalidSignalTimeframe = errors.New("timeframe de señal no válido")
+	ErrSignalNoPrice          = errors.New("sin precio actual para el símbolo")
+)
+
+// SignalTimeframes timeframes admitidos y su duración hasta la evaluación
+var SignalTimeframes = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"30m": 30 * time.Minute,
+	"1h":  time.Hour,
+	"4h":  4 * time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+const (
+	// signalScoreInterval frecuencia con la que se evalúan las señales vencidas
+	signalScoreInterval = time.Second
+	// signalScoreGrace espera máxima por un precio de cierre antes de anular la señal
+	signalScoreGrace = time.Minute
+)
+
+// Mensajes WebSocket de señales
+const (
+	msgTradingSignal = "trading_signal"
+	msgSignalResult  = "signal_result"
+)
+
+// SignalStore persistencia de señales
+type SignalStore interface {
+	PublishSignal(ctx context.Context, signal *models.TradingSignal) (subscribers []int64, err error)
+	GetActiveSignals(ctx context.Context) ([]*models.TradingSignal, error)
+	ScoreSignal(ctx context.Context, signal *models.TradingSignal) (subscribers []int64, scored bool, err error)
+}
+
+// SignalPrices precios en vivo e historial reciente
+type SignalPrices interface {
+	GetPrice(symbol string) (*models.PriceData, error)
+	GetPriceAt(symbol string, at time.Time) (*models.PriceData, error)
+}
+
+// SignalTicks ticks persistidos, para señales vencidas fuera del historial en memoria
+type SignalTicks interface {
+	GetTickAt(ctx context.Context, symbol string, at time.Time) (*models.PriceData, error)
+}
+
+// SignalService publica las señales de los proveedores verificados y las evalúa al
+// cumplirse su timeframe con el precio de mercado: acierto si el precio se movió en
+// la dirección indicada, fallo en otro caso y anulada si no hay precio de cierre.
+type SignalService struct {
+	store  SignalStore
+	prices SignalPrices
+	ticks  SignalTicks
+	maxAge time.Duration // Antigüedad máxima del tick de cierre respecto al vencimiento
+	hub    *websocket.Hub
+
+	mutex   sync.Mutex
+	pending map[int64]*models.TradingSignal // Señales activas por ID
+}
+
+// NewSignalService crea el servicio; Load carga las señales activas
+func NewSignalService(store SignalStore, prices SignalPrices, ticks SignalTicks, maxAge time.Duration, hub *websocket.Hub) *SignalService {
+	return &SignalService{
+		store:   store,
+		prices:  prices,
+		ticks:   ticks,
+		maxAge:  maxAge,
+		hub:     hub,
+		pending: make(map[int64]*models.TradingSignal),
+	}
+}
+
+// Load carga las señales activas de la DB (tras un reinicio)
+func (s *SignalService) Load(ctx context.Context) error {
+	signals, err := s.store.GetActiveSignals(ctx)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	for _, signal := range signals {
+		s.pending[signal.ID] = signal
+	}
+	s.mutex.Unlock()
+	log.Printf("[signals] %d señales activas cargadas", len(signals))
+	return nil
+}
+
+// Publish fija el precio de entrada y el vencimiento de la señal, la persiste y la
+// envía a los suscriptores del proveedor
+func (s *SignalService) Publish(ctx context.Context, signal *models.TradingSignal) error {
+	timeframe, ok := SignalTimeframes[signal.Timeframe]
+	if !ok {
+		return ErrInvalidSignalTimeframe
+	}
+	price, err := s.prices.GetPrice(signal.Symbol)
+	if err != nil {
+		return ErrSignalNoPrice
+	}
+
+	signal.EntryPrice = price.Price
+	signal.CreatedAt = time.Now()
+	signal.ExpiresAt = signal.CreatedAt.Add(timeframe)
+
+	subscribers, err := s.store.PublishSignal(ctx, signal)
+	if err != nil {
+		return err
+	}
+
+	published := *signal
+	s.mutex.Lock()
+	s.pending[published.ID] = &published
+	s.mutex.Unlock()
+
+	for _, userID := range subscribers {
+		s.hub.BroadcastToUser(userID, msgTradingSignal, signal)
+	}
+	log.Printf("[signals] Señal %d de %d publicada (%s %s %s) a %d suscriptores",
+		signal.ID, signal.ProviderID, signal.Symbol, signal.Direction, signal.Timeframe, len(subscribers))
+	return nil
+}
+
+// Run evalúa las señales vencidas hasta que se cancele el contexto
+func (s *SignalService) Run(ctx context.Context) {
+	ticker := time.NewTicker(signalScoreInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.scoreDue(ctx, now)
+		}
+	}
+}
+
+// scoreDue evalúa las señales cuyo timeframe ya se cumplió. Si falla la persistencia
+// la señal sigue pendiente y se reintenta en la siguiente pasada.
+func (s *SignalService) scoreDue(ctx context.Context, now time.Time) {
+	s.mutex.Lock()
+	var due []*models.TradingSignal
+	for _, signal := range s.pending {
+		if !now.Before(signal.ExpiresAt) {
+			due = append(due, signal)
+		}
+	}
+	s.mutex.Unlock()
+
+	for _, signal := range due {
+		scored := *signal
+		exitPrice, err := s.exitPrice(ctx, &scored)
+		if err != nil {
+			if now.Sub(signal.ExpiresAt) < signalScoreGrace {
+				continue
+			}
+			log.Printf("[signals] Señal %d anulada: %v", signal.ID, err)
+		}
+
+		result := models.SignalVoid
+		if exitPrice != nil {
+			result = scored.Score(*exitPrice)
+		}
+		closedAt := now
+		scored.Result = &result
+		scored.ExitPrice = exitPrice
+		scored.ClosedAt = &closedAt
+
+		subscribers, ok, err := s.store.ScoreSignal(ctx, &scored)
+		if err != nil {
+			log.Printf("[signals] Error evaluando señal %d: %v", signal.ID, err)
+			continue
+		}
+
+		s.mutex.Lock()
+		delete(s.pending, signal.ID)
+		s.mutex.Unlock()
+		if !ok {
+			continue
+		}
+
+		s.hub.BroadcastToUser(scored.ProviderID, msgSignalResult, &scored)
+		for _, userID := range subscribers {
+			if userID != scored.ProviderID {
+				s.hub.BroadcastToUser(userID, msgSignalResult, &scored)
+			}
+		}
+	}
+}
+
+// exitPrice precio vigente al vencimiento de la señal: el historial en memoria y, si
+// ya no lo cubre, los ticks persistidos
+func (s *SignalService) exitPrice(ctx context.Context, signal *models.TradingSignal) (*float64, error) {
+	tick, err := s.prices.GetPriceAt(signal.Symbol, signal.ExpiresAt)
+	if err != nil && s.ticks != nil {
+		tick, err = s.ticks.GetTickAt(ctx, signal.Symbol, signal.ExpiresAt)
+		if err == nil && tick == nil {
+			err = fmt.Errorf("sin ticks guardados para %s", signal.Symbol)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if signal.ExpiresAt.Sub(tick.Timestamp) > s.maxAge {
+		return nil, fmt.Errorf("último tick de %s (%s) demasiado antiguo", signal.Symbol, tick.Timestamp.Format(time.RFC3339))
+	}
+	price := tick.Price
+	return &price, nil
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+// simulatedTickInterval frecuencia de los ticks simulados
+const simulatedTickInterval = 500 * time.Millisecond
+
+// simulatedBasePrices precios base de los activos simulados
+var simulatedBasePrices = map[string]float64{
+	// Crypto
+	"BTC/USDT":  67500.00,
+	"ETH/USDT":  3450.00,
+	"BNB/USDT":  580.00,
+	"SOL/USDT":  145.00,
+	"XRP/USDT":  0.52,
+	"DOGE/USDT": 0.12,
+	"ADA/USDT":  0.45,
+	"AVAX/USDT": 35.50,
+	"DOT/USDT":  7.20,
+	"LINK/USDT": 14.80,
+
+	// Forex
+	"EUR/USD": 1.0850,
+	"GBP/USD": 1.2650,
+	"USD/JPY": 154.50,
+	"USD/CHF": 0.8820,
+	"AUD/USD": 0.6520,
+	"USD/CAD": 1.3650,
+	"NZD/USD": 0.5980,
+	"EUR/GBP": 0.8580,
+	"EUR/JPY": 167.60,
+	"GBP/JPY": 195.40,
+
+	// Commodities
+	"XAU/USD":    2340.00, // Oro
+	"XAG/USD":    27.50,   // Plata
+	"WTI/USD":    78.50,   // Petróleo WTI
+	"BRENT/USD":  82.30,   // Petróleo Brent
+	"XPT/USD":    980.00,  // Platino
+	"XPD/USD":    1050.00, // Paladio
+	"NG/USD":     2.85,    // Gas Natural
+	"COPPER/USD": 4.25,    // Cobre
+
+	// Stocks (índices/ETFs)
+	"SPY/USD":   520.00, // S&P 500 ETF
+	"QQQ/USD":   445.00, // Nasdaq ETF
+	"DIA/USD":   390.00, // Dow Jones ETF
+	"AAPL/USD":  185.00, // Apple
+	"GOOGL/USD": 175.00, // Google
+	"MSFT/USD":  420.00, // Microsoft
+	"AMZN/USD":  185.00, // Amazon
+	"TSLA/USD":  175.00, // Tesla
+	"NVDA/USD":  880.00, // Nvidia
+	"META/USD":  505.00, // Meta
+}
+
+// SimulatedBasePrices copia de los precios base de los activos simulados
+func SimulatedBasePrices() map[string]float64 {
+	prices := make(map[string]float64, len(simulatedBasePrices))
+	for symbol, price := range simulatedBasePrices {
+		prices[symbol] = price
+	}
+	return prices
+}
+
+// SimulatedFeed genera precios con una variación aleatoria de ±0.1% por tick, dentro
+// de ±5% del precio base
+type SimulatedFeed struct {
+	mutex  sync.Mutex
+	prices map[string]*models.PriceData
+}
+
+// NewSimulatedFeed crea el feed de precios simulados
+func NewSimulatedFeed() *SimulatedFeed {
+	return &SimulatedFeed{prices: make(map[string]*models.PriceData)}
+}
+
+func (f *SimulatedFeed) Name() string {
+	return FeedSimulated
+}
+
+// Run emite el precio inicial de cada símbolo simulado y luego un tick cada 500ms. Los
+// símbolos sin precio base se ignoran.
+func (f *SimulatedFeed) Run(ctx context.Context, symbols []string, emit func(tick models.PriceData)) error {
+	f.mutex.Lock()
+	for _, symbol := range symbols {
+		basePrice, ok := simulatedBasePrices[symbol]
+		if !ok {
+			continue
+		}
+		if _, exists := f.prices[symbol]; !exists {
+			f.prices[symbol] = &models.PriceData{
+				Symbol:  symbol,
+				Price:   basePrice,
+				High24h: basePrice * 1.02,
+				Low24h:  basePrice * 0.98,
+			}
+		}
+	}
+	f.mutex.Unlock()
+	f.emitAll(emit, false)
+
+	ticker := time.NewTicker(simulatedTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			f.emitAll(emit, true)
+		}
+	}
+}
+
+// SetPrice fija el precio simulado de un símbolo; los ticks siguientes parten de él
+func (f *SimulatedFeed) SetPrice(symbol string, price float64) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if current, exists := f.prices[symbol]; exists {
+		current.Price = price
+	}
+}
+
+// emitAll emite un tick por símbolo, aplicando antes la variación aleatoria si vary
+func (f *SimulatedFeed) emitAll(emit func(tick models.PriceData), vary bool) {
+	f.mutex.Lock()
+	ticks := make([]models.PriceData, 0, len(f.prices))
+	for symbol, price := range f.prices {
+		basePrice := simulatedBasePrices[symbol]
+		newPrice := price.Price
+		if vary {
+			// Variación aleatoria de -0.1% a +0.1%, dentro de ±5% del precio base
+			newPrice *= 1 + (rand.Float64()-0.5)*0.002
+			if newPrice > basePrice*1.05 {
+				newPrice = basePrice * 1.05
+			} else if newPrice < basePrice*0.95 {
+				newPrice = basePrice * 0.95
+			}
+		}
+
+		price.Price = newPrice
+		price.Bid = newPrice * 0.9999
+		price.Ask = newPrice * 1.0001
+		price.Change24h = ((newPrice - basePrice) / basePrice) * 100
+		if newPrice > price.High24h {
+			price.High24h = newPrice
+		}
+		if newPrice < price.Low24h {
+			price.Low24h = newPrice
+		}
+		ticks = append(ticks, *price)
+	}
+	f.mutex.Unlock()
+
+	for _, tick := range ticks {
+		emit(tick)
+	}
+}
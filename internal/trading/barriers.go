@@ -0,0 +1,72 @@
+package trading
+
+import (
+	"sync"
+
+	"tormentus/internal/models"
+)
+
+// BarrierMonitor vigila las barreras de los trades touch/no_touch abiertos.
+// Cada tick de precio se compara solo contra los trades de su símbolo.
+type BarrierMonitor struct {
+	mutex  sync.Mutex
+	trades map[string]map[int64]*models.Trade // símbolo -> tradeID -> trade
+}
+
+// NewBarrierMonitor crea un monitor de barreras vacío
+func NewBarrierMonitor() *BarrierMonitor {
+	return &BarrierMonitor{trades: make(map[string]map[int64]*models.Trade)}
+}
+
+// Track agrega un trade con barrera; los demás tipos se ignoran
+func (bm *BarrierMonitor) Track(trade *models.Trade) {
+	if !trade.OptionType.IsBarrier() {
+		return
+	}
+
+	bm.mutex.Lock()
+	defer bm.mutex.Unlock()
+
+	bySymbol, exists := bm.trades[trade.Symbol]
+	if !exists {
+		bySymbol = make(map[int64]*models.Trade)
+		bm.trades[trade.Symbol] = bySymbol
+	}
+	bySymbol[trade.ID] = trade
+}
+
+// Untrack deja de vigilar un trade
+func (bm *BarrierMonitor) Untrack(trade *models.Trade) {
+	bm.mutex.Lock()
+	defer bm.mutex.Unlock()
+
+	if bySymbol, exists := bm.trades[trade.Symbol]; exists {
+		delete(bySymbol, trade.ID)
+		if len(bySymbol) == 0 {
+			delete(bm.trades, trade.Symbol)
+		}
+	}
+}
+
+// OnTick devuelve (y deja de vigilar) los trades cuya barrera alcanza el precio
+func (bm *BarrierMonitor) OnTick(price *models.PriceData) []*models.Trade {
+	bm.mutex.Lock()
+	defer bm.mutex.Unlock()
+
+	bySymbol, exists := bm.trades[price.Symbol]
+	if !exists {
+		return nil
+	}
+
+	var touched []*models.Trade
+	for id, trade := range bySymbol {
+		if BarrierHit(trade, price.Price) {
+			touched = append(touched, trade)
+			delete(bySymbol, id)
+		}
+	}
+	if len(bySymbol) == 0 {
+		delete(bm.trades, price.Symbol)
+	}
+	return touched
+}
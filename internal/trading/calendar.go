@@ -0,0 +1,293 @@
+package trading
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+// Códigos de rechazo del calendario de mercado
+const (
+	MarketClosed         = "MARKET_CLOSED"
+	MarketMaintenance    = "MAINTENANCE"
+	MarketExpiryAfterEnd = "EXPIRY_AFTER_CLOSE"
+)
+
+const (
+	// calendarRefreshInterval frecuencia de recarga de horarios y mantenimientos
+	calendarRefreshInterval = time.Minute
+	// maintenanceHorizon ventanas de mantenimiento futuras que se cargan
+	maintenanceHorizon = 7 * 24 * time.Hour
+	// maxCalendarSteps límite de iteraciones al buscar la próxima apertura
+	maxCalendarSteps = 32
+)
+
+// MarketError rechazo de una operación por horario de mercado o mantenimiento
+type MarketError struct {
+	Code     string
+	Message  string
+	NextOpen *time.Time
+	ClosesAt *time.Time
+}
+
+func (e *MarketError) Error() string {
+	return e.Message
+}
+
+// CalendarRepository provee horarios de mercado y ventanas de mantenimiento
+type CalendarRepository interface {
+	GetTradingSessions(ctx context.Context) ([]models.TradingSession, error)
+	GetMaintenanceWindows(ctx context.Context, until time.Time) ([]models.MaintenanceWindow, error)
+}
+
+// SymbolMarkets resuelve el mercado de un símbolo
+type SymbolMarkets interface {
+	GetMarketType(symbol string) (models.MarketType, bool)
+}
+
+// MarketCalendar determina si un mercado está abierto usando trading_hours y
+// maintenance_windows. Un mercado sin horarios configurados opera 24/7; los
+// días sin fila en trading_hours se consideran cerrados.
+type MarketCalendar struct {
+	repo    CalendarRepository
+	symbols SymbolMarkets
+
+	mutex    sync.RWMutex
+	sessions map[models.MarketType][]models.TradingSession
+	windows  []models.MaintenanceWindow
+}
+
+// NewMarketCalendar crea un calendario de mercado vacío (todo abierto) hasta el primer Refresh
+func NewMarketCalendar(repo CalendarRepository, symbols SymbolMarkets) *MarketCalendar {
+	return &MarketCalendar{
+		repo:     repo,
+		symbols:  symbols,
+		sessions: make(map[models.MarketType][]models.TradingSession),
+	}
+}
+
+// Refresh recarga horarios y ventanas de mantenimiento desde la base de datos
+func (mc *MarketCalendar) Refresh(ctx context.Context) error {
+	sessions, err := mc.repo.GetTradingSessions(ctx)
+	if err != nil {
+		return err
+	}
+	windows, err := mc.repo.GetMaintenanceWindows(ctx, time.Now().Add(maintenanceHorizon))
+	if err != nil {
+		return err
+	}
+
+	byMarket := make(map[models.MarketType][]models.TradingSession)
+	for _, s := range sessions {
+		byMarket[s.MarketType] = append(byMarket[s.MarketType], s)
+	}
+
+	mc.mutex.Lock()
+	mc.sessions = byMarket
+	mc.windows = windows
+	mc.mutex.Unlock()
+	return nil
+}
+
+// Run recarga el calendario periódicamente hasta que se cancele el contexto
+func (mc *MarketCalendar) Run(ctx context.Context) {
+	ticker := time.NewTicker(calendarRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := mc.Refresh(ctx); err != nil {
+				log.Printf("Error recargando calendario de mercado: %v", err)
+			}
+		}
+	}
+}
+
+// CheckTrade verifica que el mercado del símbolo esté abierto en openAt y que no
+// cierre (ni entre en mantenimiento) antes de expiresAt
+func (mc *MarketCalendar) CheckTrade(symbol string, openAt, expiresAt time.Time) error {
+	market, _ := mc.symbols.GetMarketType(symbol)
+	status := mc.Status(market, openAt)
+
+	if !status.IsOpen {
+		err := &MarketError{
+			Code:     MarketClosed,
+			Message:  "El mercado está cerrado",
+			NextOpen: status.NextOpen,
+		}
+		if status.Reason == "maintenance" {
+			err.Code = MarketMaintenance
+			err.Message = "Trading suspendido por mantenimiento"
+		}
+		return err
+	}
+
+	if status.ClosesAt != nil && expiresAt.After(*status.ClosesAt) {
+		return &MarketError{
+			Code:     MarketExpiryAfterEnd,
+			Message:  "La operación expiraría después del cierre del mercado",
+			ClosesAt: status.ClosesAt,
+		}
+	}
+	return nil
+}
+
+// Status devuelve si el mercado está abierto en 'at', cuándo cierra o cuándo abre
+func (mc *MarketCalendar) Status(market models.MarketType, at time.Time) models.MarketStatus {
+	mc.mutex.RLock()
+	defer mc.mutex.RUnlock()
+
+	at = at.UTC()
+	sessions := mc.sessions[market]
+
+	if w := mc.activeWindow(at); w != nil {
+		return models.MarketStatus{
+			Reason:   "maintenance",
+			NextOpen: optionalTime(mc.nextOpen(sessions, w.EndsAt)),
+		}
+	}
+
+	sessionEnd, open := sessionEndAt(sessions, at)
+	if !open {
+		return models.MarketStatus{
+			Reason:   "closed",
+			NextOpen: optionalTime(mc.nextOpen(sessions, at)),
+		}
+	}
+
+	closesAt := sessionEnd
+	if next := mc.nextWindowStart(at); !next.IsZero() && (closesAt.IsZero() || next.Before(closesAt)) {
+		closesAt = next
+	}
+	return models.MarketStatus{IsOpen: true, ClosesAt: optionalTime(closesAt)}
+}
+
+// nextOpen primer instante >= t en que el mercado está abierto y sin mantenimiento
+func (mc *MarketCalendar) nextOpen(sessions []models.TradingSession, t time.Time) time.Time {
+	for i := 0; i < maxCalendarSteps; i++ {
+		if w := mc.activeWindow(t); w != nil {
+			t = w.EndsAt
+			continue
+		}
+		if _, open := sessionEndAt(sessions, t); open {
+			return t
+		}
+		next := nextSessionStart(sessions, t)
+		if next.IsZero() {
+			return time.Time{}
+		}
+		t = next
+	}
+	return time.Time{}
+}
+
+// activeWindow ventana de mantenimiento vigente en 'at'
+func (mc *MarketCalendar) activeWindow(at time.Time) *models.MaintenanceWindow {
+	for i := range mc.windows {
+		w := &mc.windows[i]
+		if !at.Before(w.StartsAt) && at.Before(w.EndsAt) {
+			return w
+		}
+	}
+	return nil
+}
+
+// nextWindowStart inicio de la próxima ventana de mantenimiento posterior a 'at'
+func (mc *MarketCalendar) nextWindowStart(at time.Time) time.Time {
+	var next time.Time
+	for _, w := range mc.windows {
+		if w.StartsAt.After(at) && (next.IsZero() || w.StartsAt.Before(next)) {
+			next = w.StartsAt
+		}
+	}
+	return next
+}
+
+// sessionEndAt indica si 'at' cae dentro de una sesión y devuelve su cierre,
+// uniendo sesiones consecutivas (ej. forex de lunes a viernes). Sin horarios
+// configurados el mercado está siempre abierto y el cierre es cero.
+func sessionEndAt(sessions []models.TradingSession, at time.Time) (time.Time, bool) {
+	if len(sessions) == 0 {
+		return time.Time{}, true
+	}
+
+	end, open := sessionContaining(sessions, at)
+	if !open {
+		return time.Time{}, false
+	}
+	for i := 0; i < 7; i++ {
+		next, continues := sessionContaining(sessions, end)
+		if !continues || !next.After(end) {
+			break
+		}
+		end = next
+	}
+	return end, true
+}
+
+// sessionContaining busca la sesión (del día o del anterior) que contiene 'at'
+func sessionContaining(sessions []models.TradingSession, at time.Time) (time.Time, bool) {
+	today := midnight(at)
+	for _, day := range []time.Time{today, today.AddDate(0, 0, -1)} {
+		for _, s := range sessions {
+			if s.IsClosed || s.DayOfWeek != day.Weekday() {
+				continue
+			}
+			start, end := sessionBounds(s, day)
+			if !at.Before(start) && at.Before(end) {
+				return end, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// nextSessionStart próxima apertura de sesión posterior a 'at' (hasta una semana)
+func nextSessionStart(sessions []models.TradingSession, at time.Time) time.Time {
+	var next time.Time
+	today := midnight(at)
+	for i := 0; i <= 7; i++ {
+		day := today.AddDate(0, 0, i)
+		for _, s := range sessions {
+			if s.IsClosed || s.DayOfWeek != day.Weekday() {
+				continue
+			}
+			start, _ := sessionBounds(s, day)
+			if start.After(at) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+		if !next.IsZero() {
+			return next
+		}
+	}
+	return next
+}
+
+// sessionBounds inicio y fin de la sesión en el día indicado
+func sessionBounds(s models.TradingSession, day time.Time) (time.Time, time.Time) {
+	start := day.Add(time.Duration(s.OpenSecond) * time.Second)
+	end := day.Add(time.Duration(s.CloseSecond) * time.Second)
+	if s.CloseSecond <= s.OpenSecond {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end
+}
+
+func midnight(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
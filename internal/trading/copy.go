@@ -0,0 +1,270 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"tormentus/internal/models"
+	"tormentus/internal/websocket"
+)
+
+// minCopyRemaining tiempo mínimo hasta la expiración del trade del líder para copiarlo
+const minCopyRemaining = 5 * time.Second
+
+// Mensajes WebSocket enviados al seguidor
+const (
+	msgCopyOpened  = "copy_trade_opened"
+	msgCopySkipped = "copy_trade_skipped"
+)
+
+// CopyStore persistencia de copy trading
+type CopyStore interface {
+	GetActiveLeaderUserIDs(ctx context.Context) ([]int64, error)
+	GetActiveFollowers(ctx context.Context, leaderUserID int64) ([]*models.CopyRelationship, error)
+	PlaceCopyTrade(ctx context.Context, rel *models.CopyRelationship, trade *models.Trade, checkUsage func(openTrades int, dailyVolume float64) error) (placed bool, err error)
+}
+
+// CopyTradeUpdate trade copiado (o descartado) para un seguidor
+type CopyTradeUpdate struct {
+	LeaderTradeID int64         `json:"leader_trade_id"`
+	TraderID      int64         `json:"trader_id"` // copy_traders.id
+	Trade         *models.Trade `json:"trade,omitempty"`
+	Reason        string        `json:"reason,omitempty"` // Motivo del descarte
+}
+
+// CopyMirror replica los trades en cuenta real de los líderes activos en las cuentas
+// de sus seguidores y cobra la comisión del líder al liquidar los trades copiados.
+// Cada copia respeta el balance, los límites de riesgo y la exposición del seguidor.
+type CopyMirror struct {
+	store   CopyStore
+	engine  *TradingEngine
+	payouts *PayoutResolver
+	risk    *RiskChecker
+	hub     *websocket.Hub
+
+	mutex    sync.Mutex
+	leaders  map[int64]bool // userID de líderes activos
+	stopping bool
+	inflight sync.WaitGroup // Réplicas en curso
+}
+
+// NewCopyMirror crea el replicador; Load carga los líderes activos
+func NewCopyMirror(store CopyStore, engine *TradingEngine, payouts *PayoutResolver, risk *RiskChecker, hub *websocket.Hub) *CopyMirror {
+	return &CopyMirror{
+		store:   store,
+		engine:  engine,
+		payouts: payouts,
+		risk:    risk,
+		hub:     hub,
+		leaders: make(map[int64]bool),
+	}
+}
+
+// Load carga los líderes activos de la DB
+func (cm *CopyMirror) Load(ctx context.Context) error {
+	userIDs, err := cm.store.GetActiveLeaderUserIDs(ctx)
+	if err != nil {
+		return err
+	}
+	cm.mutex.Lock()
+	for _, userID := range userIDs {
+		cm.leaders[userID] = true
+	}
+	cm.mutex.Unlock()
+	log.Printf("[copy] %d líderes de copy trading activos", len(userIDs))
+	return nil
+}
+
+// SetLeader activa o desactiva la réplica de los trades del usuario
+func (cm *CopyMirror) SetLeader(userID int64, active bool) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	if active {
+		cm.leaders[userID] = true
+	} else {
+		delete(cm.leaders, userID)
+	}
+}
+
+// OnTradePlaced replica en segundo plano un trade aceptado por el motor si es de un
+// líder activo. Los trades demo, de torneo y los propios trades copiados no se replican.
+func (cm *CopyMirror) OnTradePlaced(trade *models.Trade) {
+	if trade.IsDemo || trade.TournamentID != nil || trade.CopiedFrom != nil {
+		return
+	}
+
+	cm.mutex.Lock()
+	if cm.stopping || !cm.leaders[trade.UserID] {
+		cm.mutex.Unlock()
+		return
+	}
+	cm.inflight.Add(1)
+	cm.mutex.Unlock()
+
+	// Copia: el motor modifica el trade al liquidarlo
+	leader := *trade
+	go func() {
+		defer cm.inflight.Done()
+		cm.mirror(leader)
+	}()
+}
+
+// Shutdown deja de replicar y espera las réplicas en curso
+func (cm *CopyMirror) Shutdown(ctx context.Context) error {
+	cm.mutex.Lock()
+	cm.stopping = true
+	cm.mutex.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		cm.inflight.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("réplicas de copy trading pendientes al apagar: %w", ctx.Err())
+	}
+}
+
+// mirror abre el trade copiado de cada seguidor activo del líder
+func (cm *CopyMirror) mirror(leader models.Trade) {
+	ctx := context.Background()
+
+	followers, err := cm.store.GetActiveFollowers(ctx, leader.UserID)
+	if err != nil {
+		log.Printf("[copy] Error obteniendo seguidores del trade %d: %v", leader.ID, err)
+		return
+	}
+
+	copied := 0
+	for _, rel := range followers {
+		reason, err := cm.copyTo(ctx, rel, &leader)
+		if err != nil {
+			log.Printf("[copy] Error copiando trade %d a usuario %d: %v", leader.ID, rel.CopierID, err)
+			reason = "error al abrir el trade copiado"
+		}
+		if reason != "" {
+			cm.hub.BroadcastToUser(rel.CopierID, msgCopySkipped, CopyTradeUpdate{
+				LeaderTradeID: leader.ID,
+				TraderID:      rel.TraderID,
+				Reason:        reason,
+			})
+			continue
+		}
+		copied++
+	}
+	if len(followers) > 0 {
+		log.Printf("[copy] Trade %d del líder %d copiado a %d de %d seguidores",
+			leader.ID, leader.UserID, copied, len(followers))
+	}
+}
+
+// copyTo abre el trade copiado de un seguidor con los términos del líder y el monto de
+// la suscripción. La copia se abre al precio actual y vence junto con el trade del líder
+// (duración = tiempo restante). Devuelve el motivo si la copia se descarta.
+func (cm *CopyMirror) copyTo(ctx context.Context, rel *models.CopyRelationship, leader *models.Trade) (string, error) {
+	if !cm.engine.Accepting() {
+		return "el servidor se está reiniciando", nil
+	}
+
+	now := time.Now()
+	if leader.ExpiresAt.Sub(now) < minCopyRemaining {
+		return "el trade del líder está por vencer", nil
+	}
+
+	amount := rel.Stake(leader.Amount)
+	if amount <= 0 {
+		return "monto de copia no válido", nil
+	}
+
+	var checkUsage UsageCheck
+	if cm.risk != nil {
+		var err error
+		if checkUsage, err = cm.risk.Check(ctx, rel.CopierID, leader.Symbol, amount, false); err != nil {
+			var riskErr *RiskError
+			if errors.As(err, &riskErr) {
+				return riskErr.Message, nil
+			}
+			return "", err
+		}
+	}
+
+	// La copia puede abrirse segundos después que el trade del líder
+	price, err := cm.engine.prices.GetPriceAt(leader.Symbol, now)
+	if err != nil {
+		return "", fmt.Errorf("sin precio actual para %s: %w", leader.Symbol, err)
+	}
+	if (leader.OptionType.IsBarrier() || leader.OptionType.IsRange()) && BarrierHit(leader, price.Price) {
+		return "el precio ya alcanzó la barrera del líder", nil
+	}
+	duration := int(math.Ceil(leader.ExpiresAt.Sub(now).Seconds()))
+
+	payout, err := cm.payouts.ResolveOption(ctx, rel.CopierID, leader.Symbol, leader.OptionType, duration, amount,
+		price.Price, leader.BarrierHigh, leader.BarrierLow)
+	if errors.Is(err, ErrOptionNotOffered) || errors.Is(err, ErrBarrierDistance) {
+		return err.Error(), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	leaderTradeID := leader.ID
+	trade := &models.Trade{
+		UserID:      rel.CopierID,
+		Symbol:      leader.Symbol,
+		Direction:   leader.Direction,
+		Amount:      amount,
+		EntryPrice:  price.Price,
+		Duration:    duration,
+		Status:      models.TradePending,
+		Payout:      payout,
+		OptionType:  leader.OptionType,
+		BarrierHigh: leader.BarrierHigh,
+		BarrierLow:  leader.BarrierLow,
+		ExpiryMode:  leader.ExpiryMode,
+		Timeframe:   leader.Timeframe,
+		CreatedAt:   now,
+		ExpiresAt:   leader.ExpiresAt,
+		CopiedFrom:  &leaderTradeID,
+	}
+
+	if err := cm.engine.ReserveExposure(trade); err != nil {
+		return err.Error(), nil
+	}
+
+	placed, err := cm.store.PlaceCopyTrade(ctx, rel, trade, checkUsage)
+	if err != nil || !placed {
+		cm.engine.ReleaseExposure(trade)
+		var riskErr *RiskError
+		if errors.As(err, &riskErr) {
+			return riskErr.Message, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		return "balance insuficiente", nil
+	}
+
+	// Copia para notificar: desde PlaceTrade el motor puede liquidar el trade
+	opened := *trade
+
+	// Si el motor se detuvo, el trade ya persistido queda pending y se recupera al reiniciar
+	if err := cm.engine.PlaceTrade(trade); err != nil {
+		cm.engine.ReleaseExposure(trade)
+	}
+
+	cm.hub.BroadcastToUser(rel.CopierID, msgCopyOpened, CopyTradeUpdate{
+		LeaderTradeID: leader.ID,
+		TraderID:      rel.TraderID,
+		Trade:         &opened,
+	})
+	return "", nil
+}
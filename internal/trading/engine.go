@@ -2,10 +2,13 @@ package trading
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"sync"
 	"time"
 
+	"tormentus/internal/events"
 	"tormentus/internal/models"
 	"tormentus/internal/websocket"
 
@@ -15,7 +18,8 @@ import (
 // TradeRepository interface para persistencia
 type TradeRepository interface {
 	CreateTrade(ctx context.Context, trade *models.Trade) error
-	UpdateTrade(ctx context.Context, trade *models.Trade) error
+	UpdateTrade(ctx context.Context, trade *models.Trade, credit float64, evts ...events.Payload) error
+	GetPendingTrades(ctx context.Context) ([]*models.Trade, error)
 	GetRecentWinners(ctx context.Context, hours int) ([]int64, error)
 }
 
@@ -26,106 +30,246 @@ type UserRepository interface {
 	GetBalance(ctx context.Context, userID int64, isDemo bool) (float64, error)
 }
 
+// CopyTrading replica los trades de los líderes. La comisión a los seguidores se
+// cobra al persistir el cierre (TradeRepository.UpdateTrade).
+type CopyTrading interface {
+	// OnTradePlaced recibe cada trade aceptado por el motor; no debe bloquear
+	OnTradePlaced(trade *models.Trade)
+}
+
+// ErrEngineStopped el motor se está apagando y no acepta operaciones
+var ErrEngineStopped = errors.New("motor de trading detenido")
+
+// EventPublisher despacha los eventos que el motor escribe en el outbox junto con cada cierre
+type EventPublisher interface {
+	Notify()
+}
+
 // TradingEngine maneja todas las operaciones de trading
 type TradingEngine struct {
-	hub           *websocket.Hub
-	algorithm     *TradingAlgorithm
-	activeTrades  map[int64]*models.Trade // tradeID -> trade
-	mutex         sync.RWMutex
-	tradeRepo     TradeRepository
-	userRepo      UserRepository
-	dbPool        *pgxpool.Pool
-	
-	// Canales para procesamiento
-	newTrades     chan *models.Trade
-	closingTrades chan *models.Trade
+	hub          *websocket.Hub
+	settler      Settler
+	prices       PriceSource      // Precio actual para cierres anticipados
+	recovery     Settler          // Liquida trades vencidos mientras el proceso estaba detenido
+	barrierTicks BarrierTickStore // Toques de barrera ocurridos con el proceso detenido (opcional)
+	scheduler    *ExpiryScheduler
+	barriers     *BarrierMonitor         // Trades touch/no_touch evaluados en cada tick
+	exposure     *ExposureBook           // Stake abierto por símbolo (solo cuenta real)
+	copies       CopyTrading             // Copy trading (opcional, ver SetCopyTrading)
+	activeTrades map[int64]*models.Trade // tradeID -> trade
+	mutex        sync.RWMutex
+	tradeRepo    TradeRepository
+	userRepo     UserRepository
+	events       EventPublisher
+	dbPool       *pgxpool.Pool
+
+	// Lotes de trades vencidos pendientes de liquidar
+	closingTrades chan []*models.Trade
+
+	// Apagado ordenado
+	lifecycle sync.Mutex
+	stopping  bool
+	stop      context.CancelFunc
+	done      chan struct{}  // Se cierra cuando el procesador de cierres termina
+	inflight  sync.WaitGroup // Cierres en curso fuera del procesador (barreras, recompras)
 }
 
 // NewTradingEngine crea un nuevo motor de trading
-func NewTradingEngine(hub *websocket.Hub, dbPool *pgxpool.Pool, tradeRepo TradeRepository, userRepo UserRepository) *TradingEngine {
-	return &TradingEngine{
+func NewTradingEngine(hub *websocket.Hub, dbPool *pgxpool.Pool, tradeRepo TradeRepository, userRepo UserRepository, publisher EventPublisher, exposure *ExposureBook, prices PriceSource, settler, recovery Settler) *TradingEngine {
+	te := &TradingEngine{
 		hub:           hub,
-		algorithm:     NewTradingAlgorithm(),
+		prices:        prices,
+		settler:       settler,
+		recovery:      recovery,
 		activeTrades:  make(map[int64]*models.Trade),
-		newTrades:     make(chan *models.Trade, 100),
-		closingTrades: make(chan *models.Trade, 100),
+		barriers:      NewBarrierMonitor(),
+		exposure:      exposure,
+		closingTrades: make(chan []*models.Trade, 100),
 		tradeRepo:     tradeRepo,
 		userRepo:      userRepo,
+		events:        publisher,
 		dbPool:        dbPool,
 	}
+	te.scheduler = NewExpiryScheduler(func(ctx context.Context, trades []*models.Trade) {
+		// Tras el apagado el lote queda pending en la DB y se recupera al reiniciar
+		select {
+		case te.closingTrades <- trades:
+		case <-ctx.Done():
+		}
+	})
+	return te
 }
 
-// Start inicia el motor de trading
+// SetBarrierTicks registra los ticks guardados con los que la recuperación detecta
+// toques de barrera ocurridos mientras el proceso estaba detenido. Debe llamarse antes de Start.
+func (te *TradingEngine) SetBarrierTicks(store BarrierTickStore) {
+	te.barrierTicks = store
+}
+
+// SetCopyTrading registra el copy trading. Debe llamarse antes de Start.
+func (te *TradingEngine) SetCopyTrading(copies CopyTrading) {
+	te.copies = copies
+}
+
+// Start inicia el motor de trading: recupera los trades pendientes de una ejecución
+// anterior antes de volver, para que no compitan con operaciones nuevas, y deja los
+// ciclos de expiración en segundo plano. Se detiene al cancelar ctx o con Shutdown.
 func (te *TradingEngine) Start(ctx context.Context) {
+	te.lifecycle.Lock()
+	if te.stopping {
+		te.lifecycle.Unlock()
+		return
+	}
+	ctx, te.stop = context.WithCancel(ctx)
+	te.done = make(chan struct{})
+	done := te.done
+	te.inflight.Add(1) // La recuperación cuenta como cierre en curso
+	te.lifecycle.Unlock()
+	defer te.inflight.Done()
+
 	log.Println("Motor de trading iniciado")
-	
-	// Goroutine para procesar nuevos trades
-	go te.processNewTrades(ctx)
-	
+
+	// Goroutine que despierta en cada expiración
+	go te.scheduler.Run(ctx)
+
 	// Goroutine para cerrar trades expirados
-	go te.processExpiringTrades(ctx)
-	
-	// Goroutine para limpiar registros antiguos
-	go te.cleanupRoutine(ctx)
+	go func() {
+		defer close(done)
+		te.processExpiringTrades(ctx)
+	}()
+
+	// Recuperar trades pendientes de una ejecución anterior
+	te.recoverPendingTrades(ctx)
+}
+
+// Accepting indica si el motor acepta nuevas operaciones
+func (te *TradingEngine) Accepting() bool {
+	te.lifecycle.Lock()
+	defer te.lifecycle.Unlock()
+	return !te.stopping
+}
+
+// beginClose registra un cierre en curso; falla si el motor se está apagando
+func (te *TradingEngine) beginClose() bool {
+	te.lifecycle.Lock()
+	defer te.lifecycle.Unlock()
+	if te.stopping {
+		return false
+	}
+	te.inflight.Add(1)
+	return true
+}
+
+// StopAccepting rechaza nuevas operaciones y cierres anticipados (ErrEngineStopped)
+func (te *TradingEngine) StopAccepting() {
+	te.lifecycle.Lock()
+	te.stopping = true
+	te.lifecycle.Unlock()
+}
+
+// Shutdown detiene el motor de forma ordenada: deja de aceptar operaciones,
+// liquida los lotes ya vencidos y espera los cierres en curso. Los trades que
+// siguen abiertos quedan en estado pending en la DB y se recuperan al reiniciar.
+func (te *TradingEngine) Shutdown(ctx context.Context) error {
+	te.lifecycle.Lock()
+	te.stopping = true
+	stop, done := te.stop, te.done
+	te.lifecycle.Unlock()
+
+	if stop == nil {
+		return nil
+	}
+	stop()
+
+	finished := make(chan struct{})
+	go func() {
+		<-done
+		te.inflight.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		return fmt.Errorf("cierres pendientes al apagar el motor: %w", ctx.Err())
+	}
+
+	log.Printf("Motor de trading detenido; %d trades abiertos se recuperarán al reiniciar", te.GetActiveTradeCount())
+	return nil
 }
 
-// PlaceTrade coloca una nueva operación
+// PlaceTrade coloca una nueva operación. Tras Shutdown devuelve ErrEngineStopped:
+// el trade ya persistido queda pending y se recupera al reiniciar.
 func (te *TradingEngine) PlaceTrade(trade *models.Trade) error {
+	if !te.Accepting() {
+		return ErrEngineStopped
+	}
+
 	te.mutex.Lock()
 	te.activeTrades[trade.ID] = trade
 	te.mutex.Unlock()
 
-	te.newTrades <- trade
-	
+	te.scheduler.Schedule(trade)
+	te.barriers.Track(trade)
+	te.trackExposure(trade)
+
 	log.Printf("Nueva operación: ID=%d, Usuario=%d, Símbolo=%s, Dirección=%s, Monto=%.2f",
 		trade.ID, trade.UserID, trade.Symbol, trade.Direction, trade.Amount)
-	
+
+	if te.copies != nil {
+		te.copies.OnTradePlaced(trade)
+	}
+
 	return nil
 }
 
-// processNewTrades procesa nuevas operaciones
-func (te *TradingEngine) processNewTrades(ctx context.Context) {
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case trade := <-te.newTrades:
-			// Programar cierre del trade
-			go te.scheduleTradeClose(trade)
-		}
+// ReserveExposure reserva la exposición de un trade antes de persistirlo.
+// Devuelve *ExposureError si supera la exposición neta máxima del símbolo.
+func (te *TradingEngine) ReserveExposure(trade *models.Trade) error {
+	if te.exposure == nil {
+		return nil
 	}
+	return te.exposure.Reserve(trade)
 }
 
-// scheduleTradeClose programa el cierre de un trade
-func (te *TradingEngine) scheduleTradeClose(trade *models.Trade) {
-	duration := time.Until(trade.ExpiresAt)
-	if duration <= 0 {
-		duration = time.Second
+// ReleaseExposure libera la exposición de un trade cerrado o que no llegó a colocarse
+func (te *TradingEngine) ReleaseExposure(trade *models.Trade) {
+	if te.exposure != nil {
+		te.exposure.Remove(trade)
 	}
+}
 
-	timer := time.NewTimer(duration)
-	<-timer.C
+// Exposure exposición abierta por símbolo para la mesa de riesgo
+func (te *TradingEngine) Exposure() []models.SymbolExposure {
+	if te.exposure == nil {
+		return []models.SymbolExposure{}
+	}
+	return te.exposure.Snapshot()
+}
 
-	te.closingTrades <- trade
+// trackExposure registra un trade ya aceptado sin validar el límite
+func (te *TradingEngine) trackExposure(trade *models.Trade) {
+	if te.exposure != nil {
+		te.exposure.Add(trade)
+	}
 }
 
-// processExpiringTrades procesa trades que están por expirar
+// processExpiringTrades liquida los lotes entregados por el scheduler. Al cancelarse
+// ctx liquida los lotes que ya estaban en cola antes de terminar.
 func (te *TradingEngine) processExpiringTrades(ctx context.Context) {
-	// Acumular trades que expiran en el mismo segundo
-	ticker := time.NewTicker(time.Second)
-	pendingTrades := make([]*models.Trade, 0)
-
 	for {
 		select {
 		case <-ctx.Done():
-			return
-		case trade := <-te.closingTrades:
-			pendingTrades = append(pendingTrades, trade)
-		case <-ticker.C:
-			if len(pendingTrades) > 0 {
-				te.processTradeGroup(pendingTrades)
-				pendingTrades = make([]*models.Trade, 0)
+			for {
+				select {
+				case trades := <-te.closingTrades:
+					te.processTradeGroup(trades)
+				default:
+					return
+				}
 			}
+		case trades := <-te.closingTrades:
+			te.processTradeGroup(trades)
 		}
 	}
 }
@@ -138,47 +282,120 @@ func (te *TradingEngine) processTradeGroup(trades []*models.Trade) {
 
 	log.Printf("Procesando grupo de %d trades", len(trades))
 
-	// Aplicar algoritmo de manipulación
-	processedTrades := te.algorithm.ProcessTradeResults(trades)
+	ctx := context.Background()
+
+	// Liquidar cada trade con el precio de mercado al expirar
+	for _, trade := range trades {
+		te.mutex.Lock()
+		_, active := te.activeTrades[trade.ID]
+		delete(te.activeTrades, trade.ID)
+		te.mutex.Unlock()
+		te.barriers.Untrack(trade)
+
+		// El trade pudo cerrarse antes (cancelación)
+		if !active {
+			continue
+		}
+		te.ReleaseExposure(trade)
+
+		if err := te.settler.Settle(ctx, trade); err != nil {
+			log.Printf("Error liquidando trade %d, se reembolsa: %v", trade.ID, err)
+			Refund(trade, time.Now(), err.Error())
+		}
+
+		te.finalizeTrade(ctx, trade)
+	}
+}
+
+// OnPriceTick evalúa las barreras de opciones touch/no_touch con cada tick.
+// Los trades tocados se liquidan en segundo plano para no frenar el feed de precios;
+// durante el apagado no se liquidan y se recuperan al reiniciar.
+func (te *TradingEngine) OnPriceTick(tick *models.PriceData) {
+	touched := te.barriers.OnTick(tick)
+	if len(touched) == 0 || !te.beginClose() {
+		return
+	}
+	go func() {
+		defer te.inflight.Done()
+		te.settleTouched(touched, *tick)
+	}()
+}
 
+// settleTouched cierra los trades cuya barrera fue tocada: touch gana y no_touch pierde
+func (te *TradingEngine) settleTouched(trades []*models.Trade, tick models.PriceData) {
 	ctx := context.Background()
 
-	// Actualizar trades y notificar usuarios
-	for _, trade := range processedTrades {
+	for _, trade := range trades {
 		te.mutex.Lock()
+		_, active := te.activeTrades[trade.ID]
 		delete(te.activeTrades, trade.ID)
 		te.mutex.Unlock()
 
-		// Persistir resultado en DB
-		if te.tradeRepo != nil {
-			if err := te.tradeRepo.UpdateTrade(ctx, trade); err != nil {
-				log.Printf("Error actualizando trade en DB: %v", err)
-			}
+		// El trade pudo expirar o cerrarse antes
+		if !active {
+			continue
 		}
+		te.scheduler.Cancel(trade.ID)
+		te.ReleaseExposure(trade)
 
-		// Actualizar balance del usuario
-		if te.userRepo != nil {
-			balanceChange := trade.Profit
-			if trade.Status == models.TradeWon {
-				balanceChange = trade.Amount + trade.Profit // Devolver monto + ganancia
-			}
-			
-			if err := te.userRepo.UpdateBalance(ctx, trade.UserID, balanceChange, trade.IsDemo); err != nil {
-				log.Printf("Error actualizando balance: %v", err)
-			}
-			
-			// Actualizar estadísticas
-			if err := te.userRepo.UpdateTradeStats(ctx, trade.UserID, trade.Status == models.TradeWon); err != nil {
-				log.Printf("Error actualizando stats: %v", err)
-			}
+		touchedAt := tick.Timestamp
+		trade.TouchedAt = &touchedAt
+		SettleAt(trade, &tick, models.PriceSourceLiveFeed, models.RuleBarrierTouch, time.Now())
+		te.finalizeTrade(ctx, trade)
+	}
+}
+
+// finalizeTrade persiste el resultado junto con el crédito y notifica el cierre. Si la
+// persistencia falla (o el trade ya estaba liquidado) no se acredita ni se notifica.
+func (te *TradingEngine) finalizeTrade(ctx context.Context, trade *models.Trade) {
+	if te.tradeRepo != nil {
+		if err := te.persistSettlement(ctx, trade); err != nil {
+			log.Printf("Error liquidando trade %d en DB, no se acredita: %v", trade.ID, err)
+			return
 		}
+	}
 
-		// Notificar al usuario via WebSocket
-		te.hub.BroadcastTradeResult(trade.UserID, trade)
+	te.notifySettlement(ctx, trade)
+}
 
-		log.Printf("Trade cerrado: ID=%d, Usuario=%d, Resultado=%s, Profit=%.2f",
-			trade.ID, trade.UserID, trade.Status, trade.Profit)
+// persistSettlement guarda el cierre del trade, acredita al usuario y escribe los
+// eventos TradeSettled y BalanceChanged en una sola transacción
+func (te *TradingEngine) persistSettlement(ctx context.Context, trade *models.Trade) error {
+	credit := SettlementCredit(trade)
+	evts := []events.Payload{events.TradeSettledEvent{Trade: trade, Credit: credit}}
+	if trade.TournamentID == nil && credit > 0 {
+		evts = append(evts, events.BalanceChangedEvent{
+			UserID: trade.UserID,
+			Amount: credit,
+			IsDemo: trade.IsDemo,
+			Reason: events.ReasonTradeSettled,
+			RefID:  trade.ID,
+		})
 	}
+	if err := te.tradeRepo.UpdateTrade(ctx, trade, credit, evts...); err != nil {
+		return err
+	}
+	if te.events != nil {
+		te.events.Notify()
+	}
+	return nil
+}
+
+// notifySettlement completa el cierre de un trade ya persistido y acreditado
+func (te *TradingEngine) notifySettlement(ctx context.Context, trade *models.Trade) {
+	// Actualizar estadísticas solo para trades con resultado
+	if trade.TournamentID == nil && te.userRepo != nil &&
+		(trade.Status == models.TradeWon || trade.Status == models.TradeLost) {
+		if err := te.userRepo.UpdateTradeStats(ctx, trade.UserID, trade.Status == models.TradeWon); err != nil {
+			log.Printf("Error actualizando stats: %v", err)
+		}
+	}
+
+	// Notificar al usuario via WebSocket
+	te.hub.BroadcastTradeResult(trade.UserID, trade)
+
+	log.Printf("Trade cerrado: ID=%d, Usuario=%d, Entrada=%.8f, Salida=%.8f, Resultado=%s, Profit=%.2f",
+		trade.ID, trade.UserID, trade.EntryPrice, trade.ExitPrice, trade.Status, trade.Profit)
 }
 
 // GetActiveTrades obtiene los trades activos de un usuario
@@ -202,37 +419,113 @@ func (te *TradingEngine) GetActiveTradeCount() int {
 	return len(te.activeTrades)
 }
 
-// cleanupRoutine limpia registros antiguos periódicamente
-func (te *TradingEngine) cleanupRoutine(ctx context.Context) {
-	ticker := time.NewTicker(time.Hour)
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			te.algorithm.CleanupOldRecords()
-		}
+// VoidDemoTrades retira del motor los trades demo abiertos del usuario (fuera de
+// torneos) y los anula sin crédito. persist guarda los trades anulados; si falla,
+// vuelven al motor y se devuelve su error.
+func (te *TradingEngine) VoidDemoTrades(userID int64, reason string, persist func(voided []*models.Trade) error) error {
+	if !te.beginClose() {
+		return ErrEngineStopped
 	}
-}
+	defer te.inflight.Done()
+
+	now := time.Now()
 
-// CancelTrade cancela un trade activo
-func (te *TradingEngine) CancelTrade(tradeID int64, userID int64) error {
 	te.mutex.Lock()
-	defer te.mutex.Unlock()
+	var open []*models.Trade
+	for id, trade := range te.activeTrades {
+		if trade.UserID != userID || !trade.IsDemo || trade.TournamentID != nil {
+			continue
+		}
+		open = append(open, trade)
+		delete(te.activeTrades, id)
+		te.scheduler.Cancel(id)
+	}
+	te.mutex.Unlock()
 
-	trade, exists := te.activeTrades[tradeID]
-	if !exists {
-		return nil
+	voided := make([]*models.Trade, 0, len(open))
+	for _, trade := range open {
+		te.barriers.Untrack(trade)
+		closed := *trade
+		Void(&closed, now, reason)
+		voided = append(voided, &closed)
 	}
 
-	if trade.UserID != userID {
-		return nil
+	if err := persist(voided); err != nil {
+		te.mutex.Lock()
+		for _, trade := range open {
+			te.activeTrades[trade.ID] = trade
+		}
+		te.mutex.Unlock()
+		for _, trade := range open {
+			te.scheduler.Schedule(trade)
+			te.barriers.Track(trade)
+		}
+		return err
+	}
+
+	for _, trade := range voided {
+		te.hub.BroadcastTradeResult(trade.UserID, trade)
+	}
+	if len(voided) > 0 {
+		log.Printf("%d trades demo anulados: Usuario=%d", len(voided), userID)
+	}
+	return nil
+}
+
+// CancelTrade cierra un trade activo antes de su expiración (recompra).
+// El valor se calcula con el precio actual (ver SellBackValue), se persiste
+// el estado sold y se acredita al usuario. Devuelve el trade cerrado.
+func (te *TradingEngine) CancelTrade(ctx context.Context, tradeID int64, userID int64) (*models.Trade, error) {
+	if !te.beginClose() {
+		return nil, ErrEngineStopped
 	}
+	defer te.inflight.Done()
 
-	trade.Status = models.TradeCanceled
 	now := time.Now()
-	trade.ClosedAt = &now
+
+	te.mutex.Lock()
+	trade, exists := te.activeTrades[tradeID]
+	if !exists || trade.UserID != userID {
+		te.mutex.Unlock()
+		return nil, ErrTradeNotFound
+	}
+	if trade.ExpiresAt.Sub(now) < MinSellBackRemaining {
+		te.mutex.Unlock()
+		return nil, ErrSellBackClosed
+	}
+	price, err := te.prices.GetPriceAt(trade.Symbol, now)
+	if err != nil {
+		te.mutex.Unlock()
+		return nil, fmt.Errorf("sin precio actual para %s: %w", trade.Symbol, err)
+	}
+
+	// Retirar del motor: desde aquí la expiración ya no lo liquida
 	delete(te.activeTrades, tradeID)
+	te.scheduler.Cancel(tradeID)
+	te.mutex.Unlock()
+	te.barriers.Untrack(trade)
+	te.ReleaseExposure(trade)
 
-	return nil
+	closed := *trade
+	ApplySellBack(&closed, price, now)
+
+	if te.tradeRepo != nil {
+		if err := te.persistSettlement(ctx, &closed); err != nil {
+			// Otra vía ya lo liquidó: no vuelve al motor
+			if errors.Is(err, models.ErrTradeAlreadySettled) {
+				return nil, ErrTradeNotFound
+			}
+			// Sin persistir no se acredita: el trade vuelve al motor
+			te.mutex.Lock()
+			te.activeTrades[tradeID] = trade
+			te.mutex.Unlock()
+			te.scheduler.Schedule(trade)
+			te.barriers.Track(trade)
+			te.trackExposure(trade)
+			return nil, err
+		}
+	}
+
+	te.notifySettlement(ctx, &closed)
+	return &closed, nil
 }
@@ -0,0 +1,24 @@
+package trading
+
+import "time"
+
+// MinCandleRemaining tiempo mínimo entre la apertura y el cierre de vela elegido.
+// Si la vela actual cierra antes, el trade expira al cierre de la siguiente.
+const MinCandleRemaining = 30 * time.Second
+
+// CandleTimeframes temporalidades disponibles para expiraciones al cierre de vela
+var CandleTimeframes = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+}
+
+// CandleExpiry devuelve el cierre de la vela actual (alineado a UTC) o el de la
+// siguiente si faltan menos de MinCandleRemaining
+func CandleExpiry(now time.Time, timeframe time.Duration) time.Time {
+	expiry := now.Truncate(timeframe).Add(timeframe)
+	if expiry.Sub(now) < MinCandleRemaining {
+		expiry = expiry.Add(timeframe)
+	}
+	return expiry
+}
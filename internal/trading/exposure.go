@@ -0,0 +1,252 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+// RiskExposureLimit código de rechazo por exposición neta del símbolo
+const RiskExposureLimit = "EXPOSURE_LIMIT_REACHED"
+
+const (
+	// ExposureBucketSize tramo de expiración con el que se agrupa la exposición
+	ExposureBucketSize = time.Minute
+	// exposureRefreshInterval frecuencia de recarga de límites
+	exposureRefreshInterval = time.Minute
+)
+
+// ExposureError rechazo de una operación que supera la exposición neta del símbolo
+type ExposureError struct {
+	Symbol  string
+	Limit   float64
+	Current float64 // Exposición neta actual (antes de la operación)
+}
+
+func (e *ExposureError) Error() string {
+	return fmt.Sprintf("exposición neta máxima de %s alcanzada (%.2f de %.2f)", e.Symbol, math.Abs(e.Current), e.Limit)
+}
+
+// ExposureLimitRepository provee el límite de exposición neta por símbolo
+type ExposureLimitRepository interface {
+	GetExposureLimits(ctx context.Context) (map[string]float64, error)
+}
+
+// exposureEntry aporte de un trade abierto a la exposición
+type exposureEntry struct {
+	symbol string
+	bucket time.Time
+	up     float64 // Stake en "up" (negativo no aplica)
+	down   float64
+	other  float64
+	payout float64 // Pago si el trade gana
+}
+
+// ExposureBook stake abierto de cuenta real por símbolo y lado. Los trades demo y
+// de torneo no generan riesgo para la casa y no se registran. Solo high_low tiene
+// dirección: touch/range se reportan aparte y no cuentan para la exposición neta.
+type ExposureBook struct {
+	repo ExposureLimitRepository
+
+	mutex   sync.Mutex
+	entries map[*models.Trade]exposureEntry
+	net     map[string]float64 // símbolo -> stake up - stake down
+	open    map[string]int     // símbolo -> trades registrados
+	limits  map[string]float64
+}
+
+// NewExposureBook crea un libro de exposición sin límites hasta el primer Refresh
+func NewExposureBook(repo ExposureLimitRepository) *ExposureBook {
+	return &ExposureBook{
+		repo:    repo,
+		entries: make(map[*models.Trade]exposureEntry),
+		net:     make(map[string]float64),
+		open:    make(map[string]int),
+		limits:  make(map[string]float64),
+	}
+}
+
+// Refresh recarga los límites de exposición desde la base de datos
+func (eb *ExposureBook) Refresh(ctx context.Context) error {
+	if eb.repo == nil {
+		return nil
+	}
+	limits, err := eb.repo.GetExposureLimits(ctx)
+	if err != nil {
+		return err
+	}
+
+	eb.mutex.Lock()
+	eb.limits = limits
+	eb.mutex.Unlock()
+	return nil
+}
+
+// Run recarga los límites periódicamente hasta que se cancele el contexto
+func (eb *ExposureBook) Run(ctx context.Context) {
+	ticker := time.NewTicker(exposureRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := eb.Refresh(ctx); err != nil {
+				log.Printf("Error recargando límites de exposición: %v", err)
+			}
+		}
+	}
+}
+
+// Reserve registra el trade si no lleva la exposición neta del símbolo más allá
+// de su límite; devuelve *ExposureError en caso contrario. Una operación que
+// reduce la exposición neta siempre se acepta.
+func (eb *ExposureBook) Reserve(trade *models.Trade) error {
+	entry, counted := newExposureEntry(trade)
+	if !counted {
+		return nil
+	}
+
+	eb.mutex.Lock()
+	defer eb.mutex.Unlock()
+
+	if _, exists := eb.entries[trade]; exists {
+		return nil
+	}
+
+	current := eb.net[entry.symbol]
+	next := current + entry.up - entry.down
+	if limit, ok := eb.limits[entry.symbol]; ok && math.Abs(next) > limit && math.Abs(next) > math.Abs(current) {
+		return &ExposureError{Symbol: entry.symbol, Limit: limit, Current: current}
+	}
+
+	eb.add(trade, entry)
+	return nil
+}
+
+// Add registra un trade sin validar el límite (ej. trades recuperados tras reinicio)
+func (eb *ExposureBook) Add(trade *models.Trade) {
+	entry, counted := newExposureEntry(trade)
+	if !counted {
+		return
+	}
+
+	eb.mutex.Lock()
+	defer eb.mutex.Unlock()
+
+	if _, exists := eb.entries[trade]; !exists {
+		eb.add(trade, entry)
+	}
+}
+
+// Remove quita el aporte de un trade cerrado o no colocado
+func (eb *ExposureBook) Remove(trade *models.Trade) {
+	eb.mutex.Lock()
+	defer eb.mutex.Unlock()
+
+	entry, exists := eb.entries[trade]
+	if !exists {
+		return
+	}
+	delete(eb.entries, trade)
+	eb.net[entry.symbol] -= entry.up - entry.down
+	eb.open[entry.symbol]--
+	if eb.open[entry.symbol] == 0 {
+		delete(eb.open, entry.symbol)
+		delete(eb.net, entry.symbol)
+	}
+}
+
+// Snapshot exposición actual por símbolo, con tramos de expiración ordenados
+func (eb *ExposureBook) Snapshot() []models.SymbolExposure {
+	eb.mutex.Lock()
+	defer eb.mutex.Unlock()
+
+	bySymbol := make(map[string]*models.SymbolExposure)
+	buckets := make(map[string]map[time.Time]*models.ExposureBucket)
+
+	for _, e := range eb.entries {
+		s, exists := bySymbol[e.symbol]
+		if !exists {
+			s = &models.SymbolExposure{Symbol: e.symbol}
+			if limit, ok := eb.limits[e.symbol]; ok {
+				s.MaxNetExposure = &limit
+			}
+			bySymbol[e.symbol] = s
+			buckets[e.symbol] = make(map[time.Time]*models.ExposureBucket)
+		}
+		s.UpStake += e.up
+		s.DownStake += e.down
+		s.OtherStake += e.other
+		s.OpenTrades++
+		if e.up > 0 {
+			s.UpPayout += e.payout
+		}
+		if e.down > 0 {
+			s.DownPayout += e.payout
+		}
+
+		b, exists := buckets[e.symbol][e.bucket]
+		if !exists {
+			b = &models.ExposureBucket{ExpiresAt: e.bucket}
+			buckets[e.symbol][e.bucket] = b
+		}
+		b.UpStake += e.up
+		b.DownStake += e.down
+		b.OtherStake += e.other
+		b.NetExposure = b.UpStake - b.DownStake
+		b.OpenTrades++
+	}
+
+	result := make([]models.SymbolExposure, 0, len(bySymbol))
+	for symbol, s := range bySymbol {
+		s.NetExposure = s.UpStake - s.DownStake
+		for _, b := range buckets[symbol] {
+			s.Buckets = append(s.Buckets, *b)
+		}
+		sort.Slice(s.Buckets, func(i, j int) bool {
+			return s.Buckets[i].ExpiresAt.Before(s.Buckets[j].ExpiresAt)
+		})
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Symbol < result[j].Symbol
+	})
+	return result
+}
+
+// add registra el aporte (requiere mutex tomado)
+func (eb *ExposureBook) add(trade *models.Trade, entry exposureEntry) {
+	eb.entries[trade] = entry
+	eb.net[entry.symbol] += entry.up - entry.down
+	eb.open[entry.symbol]++
+}
+
+// newExposureEntry calcula el aporte de un trade; demo y torneo no cuentan
+func newExposureEntry(trade *models.Trade) (exposureEntry, bool) {
+	if trade.IsDemo || trade.TournamentID != nil {
+		return exposureEntry{}, false
+	}
+
+	entry := exposureEntry{
+		symbol: trade.Symbol,
+		bucket: trade.ExpiresAt.UTC().Truncate(ExposureBucketSize),
+		payout: trade.Amount * (1 + trade.Payout/100),
+	}
+	switch {
+	case trade.OptionType != "" && trade.OptionType != models.OptionHighLow:
+		entry.other = trade.Amount
+	case trade.Direction == models.TradeUp:
+		entry.up = trade.Amount
+	default:
+		entry.down = trade.Amount
+	}
+	return entry, true
+}
@@ -0,0 +1,374 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"tormentus/internal/models"
+	"tormentus/internal/websocket"
+)
+
+// ErrOrderNotFound la orden no existe, no es del usuario o ya no está pendiente
+var ErrOrderNotFound = errors.New("orden no encontrada")
+
+// orderExpiryInterval frecuencia con la que se vencen las órdenes
+const orderExpiryInterval = time.Second
+
+// EntryOrderStore persistencia de órdenes de entrada
+type EntryOrderStore interface {
+	GetPendingOrders(ctx context.Context) ([]*models.EntryOrder, error)
+	FillOrder(ctx context.Context, order *models.EntryOrder, trade *models.Trade, checkUsage func(openTrades int, dailyVolume float64) error) (filled bool, err error)
+	CloseOrder(ctx context.Context, order *models.EntryOrder) (closed bool, err error)
+}
+
+// TradeWindowChecker verifica que el mercado esté abierto durante toda la operación
+type TradeWindowChecker interface {
+	CheckTrade(symbol string, openAt, expiresAt time.Time) error
+}
+
+// EntryOrderBook órdenes de entrada pendientes evaluadas con cada tick. Al cumplirse
+// la condición la orden se convierte en un trade high_low del motor con el precio
+// del tick; si vence sin activarse, se cancela o no puede abrirse, se devuelve el monto.
+type EntryOrderBook struct {
+	store    EntryOrderStore
+	engine   *TradingEngine
+	calendar TradeWindowChecker
+	risk     *RiskChecker
+	hub      *websocket.Hub
+
+	mutex    sync.Mutex
+	orders   map[int64]*models.EntryOrder            // orderID -> orden
+	bySymbol map[string]map[int64]*models.EntryOrder // símbolo -> órdenes
+	stopping bool
+	inflight sync.WaitGroup // Activaciones en curso
+}
+
+// NewEntryOrderBook crea un libro de órdenes vacío; Load carga las pendientes
+func NewEntryOrderBook(store EntryOrderStore, engine *TradingEngine, calendar TradeWindowChecker, risk *RiskChecker, hub *websocket.Hub) *EntryOrderBook {
+	return &EntryOrderBook{
+		store:    store,
+		engine:   engine,
+		calendar: calendar,
+		risk:     risk,
+		hub:      hub,
+		orders:   make(map[int64]*models.EntryOrder),
+		bySymbol: make(map[string]map[int64]*models.EntryOrder),
+	}
+}
+
+// Load carga las órdenes pendientes de la DB (tras un reinicio)
+func (ob *EntryOrderBook) Load(ctx context.Context) error {
+	orders, err := ob.store.GetPendingOrders(ctx)
+	if err != nil {
+		return err
+	}
+	for _, order := range orders {
+		ob.Add(order)
+	}
+	log.Printf("[orders] %d órdenes de entrada pendientes cargadas", len(orders))
+	return nil
+}
+
+// Run vence las órdenes fuera de su ventana de validez hasta que se cancele el contexto
+func (ob *EntryOrderBook) Run(ctx context.Context) {
+	ticker := time.NewTicker(orderExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			ob.expire(ctx, now)
+		}
+	}
+}
+
+// Add registra una orden pendiente ya persistida
+func (ob *EntryOrderBook) Add(order *models.EntryOrder) {
+	ob.mutex.Lock()
+	defer ob.mutex.Unlock()
+
+	ob.orders[order.ID] = order
+	if ob.bySymbol[order.Symbol] == nil {
+		ob.bySymbol[order.Symbol] = make(map[int64]*models.EntryOrder)
+	}
+	ob.bySymbol[order.Symbol][order.ID] = order
+}
+
+// PendingCount órdenes pendientes del usuario
+func (ob *EntryOrderBook) PendingCount(userID int64) int {
+	ob.mutex.Lock()
+	defer ob.mutex.Unlock()
+
+	count := 0
+	for _, order := range ob.orders {
+		if order.UserID == userID {
+			count++
+		}
+	}
+	return count
+}
+
+// Cancel cancela una orden pendiente del usuario y devuelve el monto reservado
+func (ob *EntryOrderBook) Cancel(ctx context.Context, orderID, userID int64) (*models.EntryOrder, error) {
+	ob.mutex.Lock()
+	order, exists := ob.orders[orderID]
+	if !exists || order.UserID != userID {
+		ob.mutex.Unlock()
+		return nil, ErrOrderNotFound
+	}
+	ob.remove(order)
+	ob.mutex.Unlock()
+
+	closed := *order
+	if err := ob.close(ctx, &closed, models.OrderCanceled, ""); err != nil {
+		if !errors.Is(err, ErrOrderNotFound) {
+			ob.Add(order)
+		}
+		return nil, err
+	}
+	return &closed, nil
+}
+
+// VoidDemoOrders retira del libro las órdenes demo pendientes del usuario. persist las
+// cancela en la DB sin devolver el monto; si falla, vuelven al libro y se devuelve su error.
+func (ob *EntryOrderBook) VoidDemoOrders(userID int64, persist func() error) error {
+	ob.mutex.Lock()
+	var open []*models.EntryOrder
+	for _, order := range ob.orders {
+		if order.UserID == userID && order.IsDemo {
+			open = append(open, order)
+		}
+	}
+	for _, order := range open {
+		ob.remove(order)
+	}
+	ob.mutex.Unlock()
+
+	if err := persist(); err != nil {
+		for _, order := range open {
+			ob.Add(order)
+		}
+		return err
+	}
+
+	closedAt := time.Now()
+	for _, order := range open {
+		closed := *order
+		closed.Status = models.OrderCanceled
+		closed.Reason = models.OrderReasonDemoReset
+		closed.ClosedAt = &closedAt
+		ob.hub.BroadcastOrderUpdate(closed.UserID, &closed)
+	}
+	return nil
+}
+
+// OnPriceTick activa las órdenes del símbolo cuya condición se cumple. Se invoca
+// desde PriceService: las activaciones se procesan en segundo plano.
+func (ob *EntryOrderBook) OnPriceTick(tick *models.PriceData) {
+	ob.mutex.Lock()
+	if ob.stopping {
+		ob.mutex.Unlock()
+		return
+	}
+	var triggered []*models.EntryOrder
+	for _, order := range ob.bySymbol[tick.Symbol] {
+		if order.Triggered(tick.Price) && tick.Timestamp.Before(order.ValidUntil) {
+			triggered = append(triggered, order)
+		}
+	}
+	for _, order := range triggered {
+		ob.remove(order)
+	}
+	if len(triggered) > 0 {
+		ob.inflight.Add(1)
+	}
+	ob.mutex.Unlock()
+
+	if len(triggered) == 0 {
+		return
+	}
+	go func() {
+		defer ob.inflight.Done()
+		for _, order := range triggered {
+			ob.fill(order, *tick)
+		}
+	}()
+}
+
+// Shutdown deja de activar órdenes y espera las activaciones en curso. Las órdenes
+// pendientes siguen en la DB y se cargan al reiniciar.
+func (ob *EntryOrderBook) Shutdown(ctx context.Context) error {
+	ob.mutex.Lock()
+	ob.stopping = true
+	ob.mutex.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		ob.inflight.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("activaciones de órdenes pendientes al apagar: %w", ctx.Err())
+	}
+}
+
+// fill convierte la orden activada en un trade del motor
+func (ob *EntryOrderBook) fill(order *models.EntryOrder, tick models.PriceData) {
+	ctx := context.Background()
+	now := time.Now()
+
+	// Durante el apagado la orden queda pendiente en la DB y se evalúa al reiniciar
+	if !ob.engine.Accepting() {
+		return
+	}
+
+	trade := &models.Trade{
+		UserID:     order.UserID,
+		Symbol:     order.Symbol,
+		Direction:  order.Direction,
+		Amount:     order.Amount,
+		EntryPrice: tick.Price,
+		Duration:   order.Duration,
+		Status:     models.TradePending,
+		Payout:     order.Payout,
+		IsDemo:     order.IsDemo,
+		OptionType: models.OptionHighLow,
+		ExpiryMode: models.ExpiryDuration,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(time.Duration(order.Duration) * time.Second),
+	}
+
+	if ob.calendar != nil {
+		if err := ob.calendar.CheckTrade(trade.Symbol, trade.CreatedAt, trade.ExpiresAt); err != nil {
+			ob.reject(ctx, order, err.Error())
+			return
+		}
+	}
+
+	// Bloqueos y límites vigentes al activarse: pudieron cambiar desde que se creó la orden
+	var checkUsage UsageCheck
+	if ob.risk != nil {
+		var err error
+		if checkUsage, err = ob.risk.Check(ctx, order.UserID, order.Symbol, order.Amount, order.IsDemo); err != nil {
+			var riskErr *RiskError
+			if errors.As(err, &riskErr) {
+				ob.reject(ctx, order, riskErr.Message)
+				return
+			}
+			log.Printf("[orders] Error validando límites de la orden %d: %v", order.ID, err)
+			ob.Add(order)
+			return
+		}
+	}
+
+	if err := ob.engine.ReserveExposure(trade); err != nil {
+		ob.reject(ctx, order, err.Error())
+		return
+	}
+
+	filled, err := ob.store.FillOrder(ctx, order, trade, checkUsage)
+	if err != nil {
+		ob.engine.ReleaseExposure(trade)
+		var riskErr *RiskError
+		if errors.As(err, &riskErr) {
+			ob.reject(ctx, order, riskErr.Message)
+			return
+		}
+		// Se reintenta con el próximo tick que cumpla la condición
+		log.Printf("[orders] Error activando orden %d: %v", order.ID, err)
+		ob.Add(order)
+		return
+	}
+	if !filled {
+		ob.engine.ReleaseExposure(trade)
+		return
+	}
+
+	// Si el motor se detuvo, el trade ya persistido queda pending y se recupera al reiniciar
+	if err := ob.engine.PlaceTrade(trade); err != nil {
+		ob.engine.ReleaseExposure(trade)
+	}
+
+	ob.hub.BroadcastOrderUpdate(order.UserID, order)
+	log.Printf("[orders] Orden %d activada a %.8f: trade %d", order.ID, tick.Price, trade.ID)
+}
+
+// reject cierra una orden activada que no pudo abrirse y devuelve el monto
+func (ob *EntryOrderBook) reject(ctx context.Context, order *models.EntryOrder, reason string) {
+	if err := ob.close(ctx, order, models.OrderRejected, reason); err != nil {
+		if !errors.Is(err, ErrOrderNotFound) {
+			log.Printf("[orders] Error rechazando orden %d: %v", order.ID, err)
+			ob.Add(order)
+		}
+		return
+	}
+	ob.hub.BroadcastOrderUpdate(order.UserID, order)
+}
+
+// expire vence las órdenes cuya ventana de validez terminó
+func (ob *EntryOrderBook) expire(ctx context.Context, now time.Time) {
+	ob.mutex.Lock()
+	var expired []*models.EntryOrder
+	for _, order := range ob.orders {
+		if !now.Before(order.ValidUntil) {
+			expired = append(expired, order)
+		}
+	}
+	for _, order := range expired {
+		ob.remove(order)
+	}
+	ob.mutex.Unlock()
+
+	for _, order := range expired {
+		if err := ob.close(ctx, order, models.OrderExpired, ""); err != nil {
+			if !errors.Is(err, ErrOrderNotFound) {
+				log.Printf("[orders] Error venciendo orden %d: %v", order.ID, err)
+				ob.Add(order)
+			}
+			continue
+		}
+		ob.hub.BroadcastOrderUpdate(order.UserID, order)
+	}
+}
+
+// close persiste el cierre de la orden y devuelve el monto reservado.
+// Devuelve ErrOrderNotFound si la orden ya no estaba pendiente en la DB.
+func (ob *EntryOrderBook) close(ctx context.Context, order *models.EntryOrder, status models.OrderStatus, reason string) error {
+	closedAt := time.Now()
+	order.Status = status
+	order.Reason = reason
+	order.ClosedAt = &closedAt
+
+	closed, err := ob.store.CloseOrder(ctx, order)
+	if err != nil {
+		order.Status = models.OrderPending
+		order.Reason = ""
+		order.ClosedAt = nil
+		return err
+	}
+	if !closed {
+		return ErrOrderNotFound
+	}
+	return nil
+}
+
+// remove quita la orden de los índices (requiere mutex tomado)
+func (ob *EntryOrderBook) remove(order *models.EntryOrder) {
+	delete(ob.orders, order.ID)
+	if symbolOrders := ob.bySymbol[order.Symbol]; symbolOrders != nil {
+		delete(symbolOrders, order.ID)
+		if len(symbolOrders) == 0 {
+			delete(ob.bySymbol, order.Symbol)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"tormentus/internal/models"
+)
+
+// DefaultPayout payout usado cuando el activo no tiene configuración
+const DefaultPayout = 85.0
+
+// MaxOptionPayout tope de payout para opciones touch/range, que pueden pagar más del 100%
+const MaxOptionPayout = 500.0
+
+var (
+	// ErrOptionNotOffered el activo no tiene payout configurado para el tipo de opción
+	ErrOptionNotOffered = errors.New("tipo de opción no disponible para el activo")
+	// ErrBarrierDistance la barrera está fuera de la distancia permitida al precio de entrada
+	ErrBarrierDistance = errors.New("distancia de barrera no permitida")
+)
+
+// PayoutRepository provee la configuración de payout de operadores
+type PayoutRepository interface {
+	GetPayoutSources(ctx context.Context, userID int64, symbol string, optionType models.OptionType, duration int) (*models.PayoutSources, error)
+}
+
+// PayoutResolver calcula el payout que se fija al colocar un trade
+type PayoutResolver struct {
+	repo PayoutRepository
+}
+
+// NewPayoutResolver crea un nuevo resolver de payout
+func NewPayoutResolver(repo PayoutRepository) *PayoutResolver {
+	return &PayoutResolver{repo: repo}
+}
+
+// Resolve obtiene la configuración vigente y devuelve el payout (%) para el trade.
+// Las opciones touch/range sin payout para su tipo devuelven ErrOptionNotOffered.
+func (r *PayoutResolver) Resolve(ctx context.Context, userID int64, symbol string, optionType models.OptionType, duration int, amount float64) (float64, error) {
+	sources, err := r.optionSources(ctx, userID, symbol, optionType, duration)
+	if err != nil {
+		return 0, err
+	}
+	return ComputePayout(sources, optionType, duration, amount), nil
+}
+
+// ResolveOption como Resolve, validando además que las barreras (high/low) respeten la
+// distancia al precio de entrada configurada para el activo y tipo de opción
+func (r *PayoutResolver) ResolveOption(ctx context.Context, userID int64, symbol string, optionType models.OptionType, duration int, amount, entry float64, high, low *float64) (float64, error) {
+	sources, err := r.optionSources(ctx, userID, symbol, optionType, duration)
+	if err != nil {
+		return 0, err
+	}
+	if optionType.IsBarrier() || optionType.IsRange() {
+		if err := CheckBarrierDistance(sources, entry, high, low); err != nil {
+			return 0, err
+		}
+	}
+	return ComputePayout(sources, optionType, duration, amount), nil
+}
+
+// optionSources obtiene las fuentes de payout; touch/range requieren payout propio del
+// tipo de opción para no pagar el payout high/low del activo con barreras arbitrarias
+func (r *PayoutResolver) optionSources(ctx context.Context, userID int64, symbol string, optionType models.OptionType, duration int) (*models.PayoutSources, error) {
+	sources, err := r.repo.GetPayoutSources(ctx, userID, symbol, optionType, duration)
+	if err != nil {
+		return nil, err
+	}
+	if (optionType.IsBarrier() || optionType.IsRange()) && (sources == nil || sources.OptionPayout == nil) {
+		return nil, ErrOptionNotOffered
+	}
+	return sources, nil
+}
+
+// CheckBarrierDistance verifica que cada barrera esté a una distancia del precio de
+// entrada (en % del precio) dentro de MinBarrierDistance-MaxBarrierDistance
+func CheckBarrierDistance(sources *models.PayoutSources, entry float64, high, low *float64) error {
+	if entry <= 0 {
+		return fmt.Errorf("%w: precio de entrada no válido", ErrBarrierDistance)
+	}
+	for _, barrier := range []*float64{high, low} {
+		if barrier == nil {
+			continue
+		}
+		distance := math.Abs(*barrier-entry) / entry * 100
+		if distance < sources.MinBarrierDistance || distance > sources.MaxBarrierDistance {
+			return fmt.Errorf("%w: la barrera debe estar entre %.4g%% y %.4g%% del precio actual",
+				ErrBarrierDistance, sources.MinBarrierDistance, sources.MaxBarrierDistance)
+		}
+	}
+	return nil
+}
+
+// ComputePayout combina las fuentes de payout en este orden:
+//  1. Base: tramo de duración (base + ajuste de volatilidad), o el payout del activo, o DefaultPayout.
+//     Para opciones touch/range la base es el payout del activo para ese tipo (Resolve
+//     exige que exista) y no aplican tramos.
+//  2. Reglas del activo cuya condición se cumple (ajustes aditivos).
+//  3. Override del usuario (ajuste aditivo).
+//
+// El resultado se limita al min/max del tramo (si existen) y al rango 0-100
+// (0-MaxOptionPayout para touch/range).
+func ComputePayout(sources *models.PayoutSources, optionType models.OptionType, duration int, amount float64) float64 {
+	payout := DefaultPayout
+	if sources == nil {
+		return payout
+	}
+
+	maxPayout := 100.0
+	if optionType.IsBarrier() || optionType.IsRange() {
+		maxPayout = MaxOptionPayout
+		// Los tramos de duración solo describen opciones high/low
+		withoutBracket := *sources
+		withoutBracket.Bracket = nil
+		if sources.OptionPayout != nil {
+			withoutBracket.AssetPayout = sources.OptionPayout
+		}
+		sources = &withoutBracket
+	}
+
+	switch {
+	case sources.Bracket != nil:
+		payout = sources.Bracket.BasePayout + sources.Bracket.VolatilityAdjustment
+	case sources.AssetPayout != nil:
+		payout = *sources.AssetPayout
+	}
+
+	for _, rule := range sources.Rules {
+		if ruleMatches(rule, duration, amount) {
+			payout += rule.Adjustment
+		}
+	}
+
+	if sources.UserAdjustment != nil {
+		payout += *sources.UserAdjustment
+	}
+
+	if b := sources.Bracket; b != nil {
+		if b.MinPayout != nil && payout < *b.MinPayout {
+			payout = *b.MinPayout
+		}
+		if b.MaxPayout != nil && payout > *b.MaxPayout {
+			payout = *b.MaxPayout
+		}
+	}
+
+	payout = math.Max(0, math.Min(maxPayout, payout))
+	return math.Round(payout*100) / 100
+}
+
+// ruleMatches evalúa la condición de una regla; los tipos desconocidos no aplican
+func ruleMatches(rule models.PayoutRule, duration int, amount float64) bool {
+	switch rule.ConditionType {
+	case "duration":
+		return inRange(float64(duration), rule.ConditionValue, "min_seconds", "max_seconds")
+	case "amount":
+		return inRange(amount, rule.ConditionValue, "min", "max")
+	default:
+		return false
+	}
+}
+
+// inRange verifica value contra los límites opcionales minKey/maxKey
+func inRange(value float64, cond map[string]float64, minKey, maxKey string) bool {
+	if lo, ok := cond[minKey]; ok && value < lo {
+		return false
+	}
+	if hi, ok := cond[maxKey]; ok && value > hi {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"tormentus/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrQuoteNotFound la cotización no existe, ya fue usada o pertenece a otro usuario
+	ErrQuoteNotFound = errors.New("cotización no encontrada")
+	// ErrQuoteExpired la cotización superó su ventana de validez
+	ErrQuoteExpired = errors.New("cotización expirada")
+)
+
+// QuoteBook guarda en memoria las cotizaciones emitidas hasta que se confirman o expiran
+type QuoteBook struct {
+	mutex  sync.Mutex
+	quotes map[string]*models.TradeQuote
+	ttl    time.Duration
+}
+
+// NewQuoteBook crea un libro de cotizaciones con la validez indicada
+func NewQuoteBook(ttl time.Duration) *QuoteBook {
+	return &QuoteBook{
+		quotes: make(map[string]*models.TradeQuote),
+		ttl:    ttl,
+	}
+}
+
+// Issue asigna ID y validez a la cotización y la registra
+func (qb *QuoteBook) Issue(quote *models.TradeQuote) *models.TradeQuote {
+	quote.ID = uuid.NewString()
+	quote.ValidUntil = quote.CreatedAt.Add(qb.ttl)
+
+	qb.mutex.Lock()
+	qb.quotes[quote.ID] = quote
+	qb.mutex.Unlock()
+
+	return quote
+}
+
+// Take consume la cotización del usuario; solo puede usarse una vez
+func (qb *QuoteBook) Take(quoteID string, userID int64, now time.Time) (*models.TradeQuote, error) {
+	qb.mutex.Lock()
+	defer qb.mutex.Unlock()
+
+	quote, exists := qb.quotes[quoteID]
+	if !exists || quote.UserID != userID {
+		return nil, ErrQuoteNotFound
+	}
+	delete(qb.quotes, quoteID)
+
+	if now.After(quote.ValidUntil) {
+		return nil, ErrQuoteExpired
+	}
+	return quote, nil
+}
+
+// Run elimina periódicamente las cotizaciones vencidas. Se conservan un ttl extra
+// para poder responder "expirada" en lugar de "no encontrada".
+func (qb *QuoteBook) Run(ctx context.Context) {
+	ticker := time.NewTicker(qb.ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			qb.mutex.Lock()
+			for id, quote := range qb.quotes {
+				if now.Sub(quote.ValidUntil) > qb.ttl {
+					delete(qb.quotes, id)
+				}
+			}
+			qb.mutex.Unlock()
+		}
+	}
+}
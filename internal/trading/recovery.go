@@ -0,0 +1,102 @@
+package trading
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+// BarrierTickStore busca en los ticks guardados el primer toque de barrera
+type BarrierTickStore interface {
+	GetFirstBarrierTouch(ctx context.Context, symbol string, from, to time.Time, high, low *float64) (*models.PriceData, error)
+}
+
+// recoverPendingTrades recarga los trades pendientes de la DB tras un reinicio.
+// Los que siguen vigentes se reprograman; los vencidos se liquidan con los ticks
+// guardados o se reembolsan si no hay precio disponible. Los touch/no_touch cuya
+// barrera se tocó mientras el proceso estaba detenido se liquidan con ese toque.
+func (te *TradingEngine) recoverPendingTrades(ctx context.Context) {
+	if te.tradeRepo == nil {
+		return
+	}
+
+	trades, err := te.tradeRepo.GetPendingTrades(ctx)
+	if err != nil {
+		log.Printf("[recovery] Error cargando trades pendientes: %v", err)
+		return
+	}
+
+	log.Printf("[recovery] %d trades pendientes encontrados", len(trades))
+
+	now := time.Now()
+	for _, trade := range trades {
+		if te.recoverBarrierTouch(ctx, trade, now) {
+			continue
+		}
+
+		if trade.ExpiresAt.After(now) {
+			te.mutex.Lock()
+			te.activeTrades[trade.ID] = trade
+			te.mutex.Unlock()
+
+			te.scheduler.Schedule(trade)
+			te.barriers.Track(trade)
+			te.trackExposure(trade)
+			log.Printf("[recovery] Trade reprogramado: ID=%d, Usuario=%d, Símbolo=%s, Expira=%s",
+				trade.ID, trade.UserID, trade.Symbol, trade.ExpiresAt.Format(time.RFC3339))
+			continue
+		}
+
+		te.settleExpiredTrade(ctx, trade)
+	}
+}
+
+// recoverBarrierTouch revisa los ticks guardados entre CreatedAt y min(now, ExpiresAt)
+// de un trade touch/no_touch. Si la barrera se tocó lo liquida con ese tick (touch gana,
+// no_touch pierde); si no se puede verificar lo reembolsa. Devuelve true si lo cerró.
+func (te *TradingEngine) recoverBarrierTouch(ctx context.Context, trade *models.Trade, now time.Time) bool {
+	if te.barrierTicks == nil || !trade.OptionType.IsBarrier() || trade.TouchedAt != nil {
+		return false
+	}
+
+	until := trade.ExpiresAt
+	if now.Before(until) {
+		until = now
+	}
+
+	tick, err := te.barrierTicks.GetFirstBarrierTouch(ctx, trade.Symbol, trade.CreatedAt, until, trade.BarrierHigh, trade.BarrierLow)
+	switch {
+	case err != nil:
+		log.Printf("[recovery] Trade %d sin verificar toques de barrera, se reembolsa: %v", trade.ID, err)
+		Refund(trade, now, err.Error())
+	case tick == nil:
+		return false
+	default:
+		touchedAt := tick.Timestamp
+		trade.TouchedAt = &touchedAt
+		SettleAt(trade, tick, models.PriceSourceStoredTicks, models.RuleBarrierTouch, now)
+		log.Printf("[recovery] Trade %d con barrera tocada en %s: Resultado=%s",
+			trade.ID, touchedAt.Format(time.RFC3339), trade.Status)
+	}
+
+	te.finalizeTrade(ctx, trade)
+	return true
+}
+
+// settleExpiredTrade liquida un trade que venció mientras el proceso estaba detenido
+func (te *TradingEngine) settleExpiredTrade(ctx context.Context, trade *models.Trade) {
+	if te.recovery == nil {
+		log.Printf("[recovery] Trade %d vencido sin liquidador de recuperación, se reembolsa", trade.ID)
+		Refund(trade, time.Now(), "sin liquidador de recuperación")
+	} else if err := te.recovery.Settle(ctx, trade); err != nil {
+		log.Printf("[recovery] Trade %d sin precio de cierre, se reembolsa: %v", trade.ID, err)
+		Refund(trade, time.Now(), err.Error())
+	} else {
+		log.Printf("[recovery] Trade %d liquidado con tick guardado: Salida=%.8f, Resultado=%s",
+			trade.ID, trade.ExitPrice, trade.Status)
+	}
+
+	te.finalizeTrade(ctx, trade)
+}
@@ -0,0 +1,143 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+
+	"tormentus/internal/models"
+)
+
+// Códigos de rechazo de la validación pre-trade
+const (
+	RiskTradingBlocked     = "TRADING_BLOCKED"
+	RiskLimitExceeded      = "LIMIT_EXCEEDED"
+	RiskAmountBelowMinimum = "AMOUNT_BELOW_MINIMUM"
+)
+
+// Límites que se reportan en RiskError.Limit
+const (
+	LimitMaxStake      = "max_trade_amount"
+	LimitMinStake      = "min_trade_amount"
+	LimitMaxOpenTrades = "max_open_trades"
+	LimitDailyVolume   = "daily_trade_volume"
+	LimitBlockAmount   = "block_max_amount"
+)
+
+// RiskError rechazo de una operación por bloqueo o límite
+type RiskError struct {
+	Code    string
+	Message string
+	Limit   string   // Límite que se violó (vacío para bloqueos)
+	Value   *float64 // Valor del límite
+}
+
+func (e *RiskError) Error() string {
+	return e.Message
+}
+
+// RiskRepository provee bloqueos, límites y uso actual del usuario
+type RiskRepository interface {
+	GetRiskProfile(ctx context.Context, userID int64, symbol string, isDemo bool) (*models.RiskProfile, error)
+}
+
+// RiskChecker valida una operación contra la configuración de los operadores
+type RiskChecker struct {
+	repo RiskRepository
+}
+
+// NewRiskChecker crea un nuevo validador pre-trade
+func NewRiskChecker(repo RiskRepository) *RiskChecker {
+	return &RiskChecker{repo: repo}
+}
+
+// UsageCheck verifica operaciones abiertas y volumen diario contra el uso indicado, sin
+// contar la operación nueva. Devuelve un *RiskError si se supera algún límite.
+type UsageCheck func(openTrades int, dailyVolume float64) error
+
+// Check devuelve un *RiskError si la operación no está permitida. El UsageCheck devuelto
+// (nil si no hay límites) se vuelve a aplicar dentro de la transacción que coloca el
+// trade, para que solicitudes simultáneas no superen juntas los límites de uso.
+func (rc *RiskChecker) Check(ctx context.Context, userID int64, symbol string, amount float64, isDemo bool) (UsageCheck, error) {
+	profile, err := rc.repo.GetRiskProfile(ctx, userID, symbol, isDemo)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo perfil de riesgo: %w", err)
+	}
+	if err := EvaluateRisk(profile, symbol, amount, isDemo); err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return nil, nil
+	}
+	return usageCheck(profile, amount, isDemo), nil
+}
+
+// EvaluateRisk aplica bloqueos y límites en este orden: bloqueos, monto mínimo/máximo,
+// operaciones abiertas y volumen diario (solo cuenta real).
+func EvaluateRisk(profile *models.RiskProfile, symbol string, amount float64, isDemo bool) error {
+	if profile == nil {
+		return nil
+	}
+
+	for _, block := range profile.Blocks {
+		if !blockApplies(block, symbol) {
+			continue
+		}
+		if block.MaxAmount != nil {
+			if amount > *block.MaxAmount {
+				return limitError(RiskLimitExceeded, LimitBlockAmount, *block.MaxAmount,
+					fmt.Sprintf("El monto máximo permitido es %.2f", *block.MaxAmount))
+			}
+			continue
+		}
+		return &RiskError{
+			Code:    RiskTradingBlocked,
+			Message: "Trading bloqueado: " + block.Reason,
+		}
+	}
+
+	if profile.MinStake != nil && amount < *profile.MinStake {
+		return limitError(RiskAmountBelowMinimum, LimitMinStake, *profile.MinStake,
+			fmt.Sprintf("El monto mínimo es %.2f", *profile.MinStake))
+	}
+	if profile.MaxStake != nil && amount > *profile.MaxStake {
+		return limitError(RiskLimitExceeded, LimitMaxStake, *profile.MaxStake,
+			fmt.Sprintf("El monto máximo es %.2f", *profile.MaxStake))
+	}
+
+	return usageCheck(profile, amount, isDemo)(profile.OpenTrades, profile.DailyVolume)
+}
+
+// usageCheck límites de operaciones abiertas y volumen diario (solo cuenta real) del perfil
+func usageCheck(profile *models.RiskProfile, amount float64, isDemo bool) UsageCheck {
+	maxOpenTrades, dailyLimit := profile.MaxOpenTrades, profile.DailyLimit
+	return func(openTrades int, dailyVolume float64) error {
+		if maxOpenTrades != nil && openTrades >= *maxOpenTrades {
+			return limitError(RiskLimitExceeded, LimitMaxOpenTrades, float64(*maxOpenTrades),
+				fmt.Sprintf("Máximo de %d operaciones abiertas", *maxOpenTrades))
+		}
+
+		if !isDemo && dailyLimit != nil && dailyVolume+amount > *dailyLimit {
+			return limitError(RiskLimitExceeded, LimitDailyVolume, *dailyLimit,
+				fmt.Sprintf("Límite diario de %.2f alcanzado", *dailyLimit))
+		}
+
+		return nil
+	}
+}
+
+// blockApplies un bloqueo sin símbolos aplica a todos
+func blockApplies(block models.TradingBlockRule, symbol string) bool {
+	if len(block.BlockedSymbols) == 0 {
+		return true
+	}
+	for _, s := range block.BlockedSymbols {
+		if s == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+func limitError(code, limit string, value float64, message string) *RiskError {
+	return &RiskError{Code: code, Message: message, Limit: limit, Value: &value}
+}
@@ -0,0 +1,167 @@
+package trading
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+// expiryItem entrada del heap de expiraciones
+type expiryItem struct {
+	trade *models.Trade
+	index int // posición en el heap, mantenida por expiryHeap
+}
+
+// expiryHeap min-heap ordenado por ExpiresAt
+type expiryHeap []*expiryItem
+
+func (h expiryHeap) Len() int { return len(h) }
+
+func (h expiryHeap) Less(i, j int) bool {
+	return h[i].trade.ExpiresAt.Before(h[j].trade.ExpiresAt)
+}
+
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiryHeap) Push(x interface{}) {
+	item := x.(*expiryItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*h = old[:n-1]
+	return item
+}
+
+// ExpiryScheduler agenda el cierre de trades con un único timer que despierta
+// exactamente en la próxima expiración. Los trades que vencen en el mismo
+// instante se entregan juntos a onExpire, que recibe el contexto de Run para no
+// quedar bloqueado si el consumidor ya se detuvo.
+type ExpiryScheduler struct {
+	mutex    sync.Mutex
+	queue    expiryHeap
+	items    map[int64]*expiryItem // tradeID -> entrada
+	wake     chan struct{}
+	onExpire func(ctx context.Context, trades []*models.Trade)
+}
+
+// NewExpiryScheduler crea un nuevo scheduler de expiraciones
+func NewExpiryScheduler(onExpire func(ctx context.Context, trades []*models.Trade)) *ExpiryScheduler {
+	return &ExpiryScheduler{
+		items:    make(map[int64]*expiryItem),
+		wake:     make(chan struct{}, 1),
+		onExpire: onExpire,
+	}
+}
+
+// Schedule agenda el cierre de un trade; si ya estaba agendado se reprograma
+func (s *ExpiryScheduler) Schedule(trade *models.Trade) {
+	s.mutex.Lock()
+	if item, exists := s.items[trade.ID]; exists {
+		item.trade = trade
+		heap.Fix(&s.queue, item.index)
+	} else {
+		item := &expiryItem{trade: trade}
+		heap.Push(&s.queue, item)
+		s.items[trade.ID] = item
+	}
+	isNext := s.queue[0].trade.ID == trade.ID
+	s.mutex.Unlock()
+
+	// Solo hace falta despertar el loop si cambió la próxima expiración
+	if isNext {
+		s.notify()
+	}
+}
+
+// Cancel quita un trade del scheduler. Devuelve false si no estaba agendado.
+func (s *ExpiryScheduler) Cancel(tradeID int64) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item, exists := s.items[tradeID]
+	if !exists {
+		return false
+	}
+	heap.Remove(&s.queue, item.index)
+	delete(s.items, tradeID)
+	return true
+}
+
+// Len devuelve la cantidad de trades agendados
+func (s *ExpiryScheduler) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.queue)
+}
+
+// Run procesa las expiraciones hasta que se cancele el contexto
+func (s *ExpiryScheduler) Run(ctx context.Context) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		due, next := s.popDue(time.Now())
+		if len(due) > 0 {
+			s.onExpire(ctx, due)
+		}
+
+		wait := time.Hour
+		if !next.IsZero() {
+			wait = time.Until(next)
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// popDue extrae los trades vencidos a 'now' y devuelve la siguiente expiración
+func (s *ExpiryScheduler) popDue(now time.Time) ([]*models.Trade, time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var due []*models.Trade
+	for len(s.queue) > 0 && !s.queue[0].trade.ExpiresAt.After(now) {
+		item := heap.Pop(&s.queue).(*expiryItem)
+		delete(s.items, item.trade.ID)
+		due = append(due, item.trade)
+	}
+
+	if len(s.queue) == 0 {
+		return due, time.Time{}
+	}
+	return due, s.queue[0].trade.ExpiresAt
+}
+
+// notify despierta el loop sin bloquear
+func (s *ExpiryScheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
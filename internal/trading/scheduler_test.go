@@ -0,0 +1,135 @@
+package trading
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+// benchmarkTrades cantidad de trades abiertos en los benchmarks
+const benchmarkTrades = 100_000
+
+// newScheduledTrades crea n trades con expiraciones repartidas en la próxima hora
+func newScheduledTrades(n int, now time.Time) []*models.Trade {
+	trades := make([]*models.Trade, n)
+	for i := range trades {
+		trades[i] = &models.Trade{
+			ID:        int64(i + 1),
+			ExpiresAt: now.Add(time.Duration((i*7919)%3600) * time.Second),
+		}
+	}
+	return trades
+}
+
+func TestExpirySchedulerFiresInExpiryOrder(t *testing.T) {
+	fired := make(chan []*models.Trade, 10)
+	s := NewExpiryScheduler(func(ctx context.Context, trades []*models.Trade) {
+		fired <- trades
+	})
+
+	now := time.Now()
+	at := func(ms int) time.Time { return now.Add(time.Duration(ms) * time.Millisecond) }
+	s.Schedule(&models.Trade{ID: 1, ExpiresAt: at(90)})
+	s.Schedule(&models.Trade{ID: 2, ExpiresAt: at(30)})
+	s.Schedule(&models.Trade{ID: 3, ExpiresAt: at(60)})
+	s.Schedule(&models.Trade{ID: 4, ExpiresAt: at(30)}) // Mismo instante que el 2: mismo lote
+	s.Schedule(&models.Trade{ID: 5, ExpiresAt: at(45)})
+	if !s.Cancel(5) {
+		t.Fatal("Cancel(5) = false, se esperaba true")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	want := [][]int64{{2, 4}, {3}, {1}}
+	for i, ids := range want {
+		select {
+		case batch := <-fired:
+			got := make(map[int64]bool, len(batch))
+			for _, trade := range batch {
+				got[trade.ID] = true
+				if time.Now().Before(trade.ExpiresAt) {
+					t.Errorf("trade %d entregado antes de su expiración", trade.ID)
+				}
+			}
+			if len(got) != len(ids) {
+				t.Fatalf("lote %d: %d trades, se esperaban %v", i, len(batch), ids)
+			}
+			for _, id := range ids {
+				if !got[id] {
+					t.Fatalf("lote %d: falta el trade %d, se esperaban %v", i, id, ids)
+				}
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("lote %d (%v) no se entregó", i, ids)
+		}
+	}
+
+	if n := s.Len(); n != 0 {
+		t.Errorf("Len() = %d tras entregar todo, se esperaba 0", n)
+	}
+}
+
+func TestExpirySchedulerDoesNotBlockAfterCancel(t *testing.T) {
+	// Consumidor detenido: onExpire solo puede salir por ctx
+	blocked := make(chan []*models.Trade)
+	s := NewExpiryScheduler(func(ctx context.Context, trades []*models.Trade) {
+		select {
+		case blocked <- trades:
+		case <-ctx.Done():
+		}
+	})
+	s.Schedule(&models.Trade{ID: 1, ExpiresAt: time.Now()})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run no terminó tras cancelar el contexto")
+	}
+}
+
+func BenchmarkSchedule(b *testing.B) {
+	trades := newScheduledTrades(benchmarkTrades, time.Now())
+	noop := func(context.Context, []*models.Trade) {}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := NewExpiryScheduler(noop)
+		for _, trade := range trades {
+			s.Schedule(trade)
+		}
+	}
+}
+
+func BenchmarkCancel(b *testing.B) {
+	trades := newScheduledTrades(benchmarkTrades, time.Now())
+	noop := func(context.Context, []*models.Trade) {}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		s := NewExpiryScheduler(noop)
+		for _, trade := range trades {
+			s.Schedule(trade)
+		}
+		b.StartTimer()
+
+		for _, trade := range trades {
+			s.Cancel(trade.ID)
+		}
+	}
+}
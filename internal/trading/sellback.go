@@ -0,0 +1,55 @@
+package trading
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+// SellBackFee descuento que retiene la plataforma sobre el valor de recompra
+const SellBackFee = 0.05
+
+// MinSellBackRemaining tiempo mínimo restante para permitir el cierre anticipado
+const MinSellBackRemaining = 3 * time.Second
+
+var (
+	// ErrTradeNotFound el trade no está activo o pertenece a otro usuario
+	ErrTradeNotFound = errors.New("trade no encontrado")
+	// ErrSellBackClosed el trade está demasiado cerca de expirar para cerrarlo antes
+	ErrSellBackClosed = errors.New("cierre anticipado no disponible")
+)
+
+// SellBackValue calcula cuánto recibe el usuario al cerrar el trade antes de expirar.
+// El valor parte del monto invertido y se acerca al resultado que tendría el trade
+// al precio actual a medida que transcurre su duración; se descuenta SellBackFee.
+func SellBackValue(trade *models.Trade, currentPrice float64, now time.Time) float64 {
+	var intrinsic float64
+	switch Outcome(trade, currentPrice) {
+	case models.TradeDraw:
+		intrinsic = trade.Amount
+	case models.TradeWon:
+		intrinsic = trade.Amount * (1 + trade.Payout/100)
+	default:
+		intrinsic = 0
+	}
+
+	elapsed := 1.0
+	if total := trade.ExpiresAt.Sub(trade.CreatedAt); total > 0 {
+		elapsed = math.Max(0, math.Min(1, float64(now.Sub(trade.CreatedAt))/float64(total)))
+	}
+
+	value := (trade.Amount + (intrinsic-trade.Amount)*elapsed) * (1 - SellBackFee)
+	return math.Round(value*100) / 100
+}
+
+// ApplySellBack cierra el trade con el valor de recompra al tick actual
+func ApplySellBack(trade *models.Trade, tick *models.PriceData, closedAt time.Time) {
+	value := SellBackValue(trade, tick.Price, closedAt)
+	trade.ExitPrice = tick.Price
+	trade.Status = models.TradeSold
+	trade.Profit = value - trade.Amount
+	trade.ClosedAt = &closedAt
+	RecordSettlement(trade, models.RuleSellBack, models.PriceSourceLiveFeed, tick, "")
+}
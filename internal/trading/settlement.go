@@ -0,0 +1,184 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+// PriceSource provee el precio de un símbolo en un instante dado
+type PriceSource interface {
+	GetPriceAt(symbol string, at time.Time) (*models.PriceData, error)
+}
+
+// Settler determina el resultado de un trade expirado
+type Settler interface {
+	Settle(ctx context.Context, trade *models.Trade) error
+}
+
+// PriceSettler liquida trades con el precio de mercado al momento de expiración
+type PriceSettler struct {
+	prices PriceSource
+}
+
+// NewPriceSettler crea un liquidador basado en precios de mercado
+func NewPriceSettler(prices PriceSource) *PriceSettler {
+	return &PriceSettler{prices: prices}
+}
+
+// Settle obtiene el precio en ExpiresAt y aplica el resultado al trade
+func (s *PriceSettler) Settle(ctx context.Context, trade *models.Trade) error {
+	price, err := s.prices.GetPriceAt(trade.Symbol, trade.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("sin precio de cierre para %s: %w", trade.Symbol, err)
+	}
+
+	SettleAt(trade, price, models.PriceSourceLiveFeed, models.RuleExpiryPrice, time.Now())
+	return nil
+}
+
+// TickStore provee ticks persistidos para liquidar trades vencidos fuera de línea
+type TickStore interface {
+	GetTickAt(ctx context.Context, symbol string, at time.Time) (*models.PriceData, error)
+}
+
+// TickSettler liquida trades con el último tick guardado antes de la expiración.
+// Un tick más antiguo que maxAge respecto a ExpiresAt no se considera válido.
+type TickSettler struct {
+	ticks  TickStore
+	maxAge time.Duration
+}
+
+// NewTickSettler crea un liquidador basado en ticks persistidos
+func NewTickSettler(ticks TickStore, maxAge time.Duration) *TickSettler {
+	return &TickSettler{ticks: ticks, maxAge: maxAge}
+}
+
+// Settle busca el tick vigente en ExpiresAt y aplica el resultado al trade
+func (s *TickSettler) Settle(ctx context.Context, trade *models.Trade) error {
+	tick, err := s.ticks.GetTickAt(ctx, trade.Symbol, trade.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	if tick == nil {
+		return fmt.Errorf("sin ticks guardados para %s antes de %s", trade.Symbol, trade.ExpiresAt.Format(time.RFC3339))
+	}
+	if trade.ExpiresAt.Sub(tick.Timestamp) > s.maxAge {
+		return fmt.Errorf("último tick de %s (%s) demasiado antiguo", trade.Symbol, tick.Timestamp.Format(time.RFC3339))
+	}
+
+	SettleAt(trade, tick, models.PriceSourceStoredTicks, models.RuleExpiryPrice, time.Now())
+	return nil
+}
+
+// SettleAt resuelve el trade con el tick indicado y adjunta el registro de liquidación
+func SettleAt(trade *models.Trade, tick *models.PriceData, source string, rule models.SettlementRule, closedAt time.Time) {
+	ApplyResult(trade, tick.Price, closedAt)
+	RecordSettlement(trade, rule, source, tick, "")
+}
+
+// ApplyResult fija el precio de salida y resuelve el trade según su tipo de opción.
+// En high/low un empate se reembolsa: el trade queda en draw con profit 0.
+func ApplyResult(trade *models.Trade, exitPrice float64, closedAt time.Time) {
+	trade.ExitPrice = exitPrice
+	trade.ClosedAt = &closedAt
+	trade.Status = Outcome(trade, exitPrice)
+
+	switch trade.Status {
+	case models.TradeWon:
+		trade.Profit = trade.Amount * (trade.Payout / 100)
+	case models.TradeLost:
+		trade.Profit = -trade.Amount
+	default:
+		trade.Profit = 0
+	}
+}
+
+// Outcome resultado del trade si se liquidara al precio indicado:
+//   - high_low: entrada vs precio (empate = draw)
+//   - touch / no_touch: si la barrera fue tocada (TouchedAt) o el precio la alcanza
+//   - range_in / range_out: si el precio está dentro de la banda (límites incluidos)
+func Outcome(trade *models.Trade, price float64) models.TradeStatus {
+	won := false
+	switch trade.OptionType {
+	case models.OptionTouch, models.OptionNoTouch:
+		touched := trade.TouchedAt != nil || BarrierHit(trade, price)
+		won = touched == (trade.OptionType == models.OptionTouch)
+	case models.OptionRangeIn, models.OptionRangeOut:
+		inside := trade.BarrierLow != nil && trade.BarrierHigh != nil &&
+			price >= *trade.BarrierLow && price <= *trade.BarrierHigh
+		won = inside == (trade.OptionType == models.OptionRangeIn)
+	default:
+		if price == trade.EntryPrice {
+			return models.TradeDraw
+		}
+		won = (price > trade.EntryPrice) == (trade.Direction == models.TradeUp)
+	}
+
+	if won {
+		return models.TradeWon
+	}
+	return models.TradeLost
+}
+
+// BarrierHit indica si el precio alcanza alguna barrera del trade
+func BarrierHit(trade *models.Trade, price float64) bool {
+	return (trade.BarrierHigh != nil && price >= *trade.BarrierHigh) ||
+		(trade.BarrierLow != nil && price <= *trade.BarrierLow)
+}
+
+// Refund cierra un trade sin resultado devolviendo el monto invertido
+func Refund(trade *models.Trade, closedAt time.Time, reason string) {
+	trade.Status = models.TradeCanceled
+	trade.Profit = 0
+	trade.ClosedAt = &closedAt
+	RecordSettlement(trade, models.RuleRefund, "", nil, reason)
+}
+
+// Void anula un trade sin resultado ni crédito (el balance se reemplaza, ej. reset demo)
+func Void(trade *models.Trade, closedAt time.Time, reason string) {
+	trade.Status = models.TradeCanceled
+	trade.Profit = 0
+	trade.ClosedAt = &closedAt
+	RecordSettlement(trade, models.RuleVoid, "", nil, reason)
+}
+
+// RecordSettlement adjunta al trade ya resuelto el registro de cómo se liquidó.
+// tick es nil cuando no hubo precio (reembolso).
+func RecordSettlement(trade *models.Trade, rule models.SettlementRule, source string, tick *models.PriceData, note string) {
+	record := &models.SettlementRecord{
+		TradeID:     trade.ID,
+		Rule:        rule,
+		PriceSource: source,
+		ExitPrice:   trade.ExitPrice,
+		Status:      trade.Status,
+		Profit:      trade.Profit,
+		Note:        note,
+	}
+	if trade.ClosedAt != nil {
+		record.SettledAt = *trade.ClosedAt
+	}
+	if tick != nil {
+		tickAt := tick.Timestamp
+		record.ExitPrice = tick.Price
+		record.Bid = tick.Bid
+		record.Ask = tick.Ask
+		record.TickAt = &tickAt
+	}
+	trade.Settlement = record
+}
+
+// SettlementCredit devuelve el monto a acreditar al usuario al cerrar el trade.
+// El monto invertido ya fue descontado al colocar la operación.
+func SettlementCredit(trade *models.Trade) float64 {
+	switch trade.Status {
+	case models.TradeWon, models.TradeSold:
+		return trade.Amount + trade.Profit
+	case models.TradeDraw, models.TradeCanceled:
+		return trade.Amount
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,175 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"tormentus/internal/models"
+)
+
+// scriptedPrices fuente de precios con respuestas fijas por símbolo
+type scriptedPrices struct {
+	prices map[string]float64
+	asked  []time.Time // Instantes consultados
+}
+
+func (s *scriptedPrices) GetPriceAt(symbol string, at time.Time) (*models.PriceData, error) {
+	s.asked = append(s.asked, at)
+	price, ok := s.prices[symbol]
+	if !ok {
+		return nil, errors.New("sin historial")
+	}
+	return &models.PriceData{Symbol: symbol, Price: price, Bid: price - 1, Ask: price + 1, Timestamp: at}, nil
+}
+
+func TestPriceSettlerSettle(t *testing.T) {
+	expiresAt := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	barrier := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name       string
+		trade      models.Trade
+		price      float64
+		noPrice    bool
+		wantStatus models.TradeStatus
+		wantProfit float64
+	}{
+		{
+			name:       "up gana si sube",
+			trade:      models.Trade{Direction: models.TradeUp, EntryPrice: 100},
+			price:      101,
+			wantStatus: models.TradeWon,
+			wantProfit: 85,
+		},
+		{
+			name:       "up pierde si baja",
+			trade:      models.Trade{Direction: models.TradeUp, EntryPrice: 100},
+			price:      99,
+			wantStatus: models.TradeLost,
+			wantProfit: -100,
+		},
+		{
+			name:       "down gana si baja",
+			trade:      models.Trade{Direction: models.TradeDown, EntryPrice: 100},
+			price:      99,
+			wantStatus: models.TradeWon,
+			wantProfit: 85,
+		},
+		{
+			name:       "empate se reembolsa",
+			trade:      models.Trade{Direction: models.TradeDown, EntryPrice: 100},
+			price:      100,
+			wantStatus: models.TradeDraw,
+			wantProfit: 0,
+		},
+		{
+			name:       "touch gana si el precio alcanza la barrera",
+			trade:      models.Trade{OptionType: models.OptionTouch, EntryPrice: 100, BarrierHigh: barrier(105)},
+			price:      105,
+			wantStatus: models.TradeWon,
+			wantProfit: 85,
+		},
+		{
+			name:       "no_touch pierde si ya fue tocada",
+			trade:      models.Trade{OptionType: models.OptionNoTouch, EntryPrice: 100, BarrierLow: barrier(95), TouchedAt: &expiresAt},
+			price:      100,
+			wantStatus: models.TradeLost,
+			wantProfit: -100,
+		},
+		{
+			name:       "range_in gana en el límite de la banda",
+			trade:      models.Trade{OptionType: models.OptionRangeIn, EntryPrice: 100, BarrierLow: barrier(95), BarrierHigh: barrier(105)},
+			price:      95,
+			wantStatus: models.TradeWon,
+			wantProfit: 85,
+		},
+		{
+			name:       "range_out pierde dentro de la banda",
+			trade:      models.Trade{OptionType: models.OptionRangeOut, EntryPrice: 100, BarrierLow: barrier(95), BarrierHigh: barrier(105)},
+			price:      101,
+			wantStatus: models.TradeLost,
+			wantProfit: -100,
+		},
+		{
+			name:    "sin precio devuelve error",
+			trade:   models.Trade{Direction: models.TradeUp, EntryPrice: 100},
+			noPrice: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prices := &scriptedPrices{prices: map[string]float64{}}
+			if !tt.noPrice {
+				prices.prices["BTC/USDT"] = tt.price
+			}
+			trade := tt.trade
+			trade.ID = 7
+			trade.Symbol = "BTC/USDT"
+			trade.Amount = 100
+			trade.Payout = 85
+			trade.Status = models.TradePending
+			trade.ExpiresAt = expiresAt
+
+			err := NewPriceSettler(prices).Settle(context.Background(), &trade)
+
+			if len(prices.asked) != 1 || !prices.asked[0].Equal(expiresAt) {
+				t.Fatalf("precio consultado en %v, se esperaba solo %v", prices.asked, expiresAt)
+			}
+			if tt.noPrice {
+				if err == nil {
+					t.Fatal("se esperaba error sin precio")
+				}
+				if trade.Status != models.TradePending || trade.Settlement != nil {
+					t.Fatalf("trade modificado sin precio: status=%s", trade.Status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Settle: %v", err)
+			}
+
+			if trade.Status != tt.wantStatus || trade.Profit != tt.wantProfit {
+				t.Errorf("resultado = %s/%.2f, se esperaba %s/%.2f", trade.Status, trade.Profit, tt.wantStatus, tt.wantProfit)
+			}
+			if trade.ExitPrice != tt.price || trade.ClosedAt == nil {
+				t.Errorf("salida = %.2f (cerrado %v), se esperaba %.2f", trade.ExitPrice, trade.ClosedAt, tt.price)
+			}
+
+			record := trade.Settlement
+			if record == nil {
+				t.Fatal("sin registro de liquidación")
+			}
+			if record.TradeID != 7 || record.Rule != models.RuleExpiryPrice || record.PriceSource != models.PriceSourceLiveFeed {
+				t.Errorf("registro = %+v", record)
+			}
+			if record.Status != tt.wantStatus || record.ExitPrice != tt.price || record.TickAt == nil || !record.TickAt.Equal(expiresAt) {
+				t.Errorf("registro no coincide con el cierre: %+v", record)
+			}
+		})
+	}
+}
+
+func TestSettlementCredit(t *testing.T) {
+	tests := []struct {
+		status models.TradeStatus
+		profit float64
+		want   float64
+	}{
+		{models.TradeWon, 85, 185},
+		{models.TradeSold, -40, 60},
+		{models.TradeDraw, 0, 100},
+		{models.TradeCanceled, 0, 100},
+		{models.TradeLost, -100, 0},
+		{models.TradePending, 0, 0},
+	}
+
+	for _, tt := range tests {
+		trade := &models.Trade{Amount: 100, Status: tt.status, Profit: tt.profit}
+		if got := SettlementCredit(trade); got != tt.want {
+			t.Errorf("SettlementCredit(%s) = %.2f, se esperaba %.2f", tt.status, got, tt.want)
+		}
+	}
+}
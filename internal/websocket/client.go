@@ -77,6 +77,7 @@ func (c *Client) writePump() {
 	defer func() {
 		ticker.Stop()
 		c.conn.Close()
+		c.hub.pumps.Done()
 	}()
 
 	for {
@@ -84,7 +85,7 @@ func (c *Client) writePump() {
 		case message, ok := <-c.send:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.conn.WriteMessage(websocket.CloseMessage, c.hub.closeMessage())
 				return
 			}
 
@@ -122,6 +123,15 @@ func ServeWs(hub *Hub, w http.ResponseWriter, r *http.Request, userID int64) {
 		return
 	}
 
+	// Durante el apagado se cierra la conexión recién abierta
+	if !hub.trackClient() {
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutdown"),
+			time.Now().Add(writeWait))
+		conn.Close()
+		return
+	}
+
 	client := &Client{
 		hub:    hub,
 		conn:   conn,
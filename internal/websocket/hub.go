@@ -1,12 +1,16 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"sync"
 	"time"
 
 	"tormentus/internal/models"
+
+	"github.com/gorilla/websocket"
 )
 
 // Hub mantiene el conjunto de clientes activos y broadcast de mensajes
@@ -22,6 +26,10 @@ type Hub struct {
 
 	// Suscripciones por símbolo
 	subscriptions map[string]map[*Client]bool
+
+	// Apagado: no se aceptan clientes y se espera a que cada writePump envíe el cierre
+	closing bool
+	pumps   sync.WaitGroup
 }
 
 // NewHub crea un nuevo hub
@@ -42,7 +50,11 @@ func (h *Hub) Run() {
 		select {
 		case client := <-h.register:
 			h.mutex.Lock()
-			h.clients[client] = true
+			if h.closing {
+				close(client.send)
+			} else {
+				h.clients[client] = true
+			}
 			h.mutex.Unlock()
 			log.Printf("Cliente conectado. Total: %d", len(h.clients))
 
@@ -74,6 +86,55 @@ func (h *Hub) Run() {
 	}
 }
 
+// trackClient registra el writePump de un cliente nuevo; falla si el hub se está cerrando
+func (h *Hub) trackClient() bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.closing {
+		return false
+	}
+	h.pumps.Add(1)
+	return true
+}
+
+// closeMessage frame de cierre que envía writePump al terminar
+func (h *Hub) closeMessage() []byte {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	if h.closing {
+		return websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutdown")
+	}
+	return []byte{}
+}
+
+// Shutdown envía un frame de cierre (going away) a todos los clientes y espera a
+// que se entregue o a que venza ctx. Los clientes que conecten después se rechazan.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.mutex.Lock()
+	h.closing = true
+	count := len(h.clients)
+	for client := range h.clients {
+		delete(h.clients, client)
+		close(client.send)
+	}
+	h.subscriptions = make(map[string]map[*Client]bool)
+	h.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.pumps.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Printf("WebSocket Hub cerrado: %d clientes desconectados", count)
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("clientes WebSocket sin cerrar: %w", ctx.Err())
+	}
+}
+
 // Subscribe suscribe un cliente a un símbolo
 func (h *Hub) Subscribe(client *Client, symbol string) {
 	h.mutex.Lock()
@@ -151,10 +212,30 @@ func (h *Hub) GetPrice(symbol string) *models.PriceData {
 
 // BroadcastTradeResult envía resultado de trade a un usuario específico
 func (h *Hub) BroadcastTradeResult(userID int64, trade *models.Trade) {
-	msg := WSMessage{
+	h.sendToUser(userID, WSMessage{
 		Type: "trade_result",
 		Data: trade,
-	}
+	})
+}
+
+// BroadcastOrderUpdate notifica al usuario la activación, vencimiento o rechazo de una orden de entrada
+func (h *Hub) BroadcastOrderUpdate(userID int64, order *models.EntryOrder) {
+	h.sendToUser(userID, WSMessage{
+		Type: "order_update",
+		Data: order,
+	})
+}
+
+// BroadcastToUser envía un mensaje a todas las conexiones de un usuario
+func (h *Hub) BroadcastToUser(userID int64, msgType string, data interface{}) {
+	h.sendToUser(userID, WSMessage{
+		Type: msgType,
+		Data: data,
+	})
+}
+
+// sendToUser envía un mensaje a todas las conexiones de un usuario
+func (h *Hub) sendToUser(userID int64, msg WSMessage) {
 	data, _ := json.Marshal(msg)
 
 	h.mutex.RLock()
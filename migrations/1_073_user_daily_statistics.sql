@@ -13,4 +13,4 @@ CREATE TABLE IF NOT EXISTS user_daily_statistics (
 );
 
 CREATE INDEX IF NOT EXISTS idx_user_daily_statistics_user_id ON user_daily_statistics(user_id);
-CREATE UNIQUE INDEX IF NOT EXISTS idx_user_daily_statistics_unique ON user_daily_statistics(user_id, date);
+-- La clave única (user_id, is_demo, date) se crea en 1_115 (cuenta real y demo por separado)
@@ -12,4 +12,4 @@ CREATE TABLE IF NOT EXISTS user_symbol_statistics (
 );
 
 CREATE INDEX IF NOT EXISTS idx_user_symbol_statistics_user_id ON user_symbol_statistics(user_id);
-CREATE UNIQUE INDEX IF NOT EXISTS idx_user_symbol_statistics_unique ON user_symbol_statistics(user_id, symbol);
+-- La clave única (user_id, is_demo, symbol) se crea en 1_115 (cuenta real y demo por separado)
@@ -0,0 +1,3 @@
+-- Índice para buscar el último tick de un símbolo en un instante (liquidación y recuperación de trades)
+CREATE INDEX IF NOT EXISTS idx_price_ticks_symbol_timestamp ON price_ticks(symbol, timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_trades_status_expires_at ON trades(status, expires_at);
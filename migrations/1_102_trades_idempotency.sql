@@ -0,0 +1,6 @@
+-- Clave de idempotencia para colocación de trades (header Idempotency-Key)
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_trades_user_idempotency_key
+    ON trades(user_id, idempotency_key)
+    WHERE idempotency_key IS NOT NULL;
@@ -0,0 +1,40 @@
+-- Horarios por defecto (UTC). Un mercado sin filas en trading_hours opera 24/7 (crypto).
+-- Forex y commodities: domingo 22:00 a viernes 22:00. Acciones e índices: lunes a viernes 13:30-20:00.
+-- No sobrescribe horarios ya configurados.
+INSERT INTO trading_hours (market_id, day_of_week, open_time, close_time, is_closed)
+SELECT m.market_id, h.day_of_week, h.open_time, h.close_time, h.is_closed
+FROM (
+    SELECT type, MIN(id) AS market_id FROM markets
+    WHERE type IN ('forex', 'commodities')
+    GROUP BY type
+) m
+CROSS JOIN (VALUES
+    (0, TIME '22:00', TIME '24:00', FALSE),
+    (1, TIME '00:00', TIME '24:00', FALSE),
+    (2, TIME '00:00', TIME '24:00', FALSE),
+    (3, TIME '00:00', TIME '24:00', FALSE),
+    (4, TIME '00:00', TIME '24:00', FALSE),
+    (5, TIME '00:00', TIME '22:00', FALSE),
+    (6, NULL::TIME, NULL::TIME, TRUE)
+) AS h(day_of_week, open_time, close_time, is_closed)
+ON CONFLICT (market_id, day_of_week) DO NOTHING;
+
+INSERT INTO trading_hours (market_id, day_of_week, open_time, close_time, is_closed)
+SELECT m.market_id, h.day_of_week, h.open_time, h.close_time, h.is_closed
+FROM (
+    SELECT type, MIN(id) AS market_id FROM markets
+    WHERE type IN ('stocks', 'indices')
+    GROUP BY type
+) m
+CROSS JOIN (VALUES
+    (0, NULL::TIME, NULL::TIME, TRUE),
+    (1, TIME '13:30', TIME '20:00', FALSE),
+    (2, TIME '13:30', TIME '20:00', FALSE),
+    (3, TIME '13:30', TIME '20:00', FALSE),
+    (4, TIME '13:30', TIME '20:00', FALSE),
+    (5, TIME '13:30', TIME '20:00', FALSE),
+    (6, NULL::TIME, NULL::TIME, TRUE)
+) AS h(day_of_week, open_time, close_time, is_closed)
+ON CONFLICT (market_id, day_of_week) DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS idx_maintenance_windows_active_range ON maintenance_windows(is_active, ends_at);
@@ -0,0 +1,4 @@
+-- Trading dentro de torneos: victorias por participante y búsqueda de trades por torneo
+ALTER TABLE tournament_participants ADD COLUMN IF NOT EXISTS wins_count INTEGER DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_trades_tournament_user ON trades(tournament_id, user_id) WHERE tournament_id IS NOT NULL;
@@ -0,0 +1,5 @@
+-- Tipos de opción: high_low (por defecto), touch, no_touch, range_in, range_out
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS option_type VARCHAR(20) DEFAULT 'high_low';
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS barrier_high DECIMAL(18,8);
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS barrier_low DECIMAL(18,8);
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS touched_at TIMESTAMP;
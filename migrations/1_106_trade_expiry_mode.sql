@@ -0,0 +1,3 @@
+-- Modo de expiración: duration (segundos relativos) o candle (cierre de vela de timeframe)
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS expiry_mode VARCHAR(10) DEFAULT 'duration';
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS timeframe VARCHAR(5);
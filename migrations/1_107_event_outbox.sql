@@ -0,0 +1,28 @@
+-- Outbox transaccional de eventos de dominio y estado de entrega por suscriptor
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_outbox_type ON event_outbox(event_type, id);
+CREATE INDEX IF NOT EXISTS idx_event_outbox_created ON event_outbox(created_at);
+
+CREATE TABLE IF NOT EXISTS event_subscribers (
+    name VARCHAR(100) PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS event_deliveries (
+    subscriber VARCHAR(100) NOT NULL REFERENCES event_subscribers(name) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL REFERENCES event_outbox(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('delivered', 'failed', 'dead')),
+    attempts INTEGER DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (subscriber, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_deliveries_retry ON event_deliveries(subscriber, next_attempt_at) WHERE status = 'failed';
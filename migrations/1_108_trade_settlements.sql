@@ -0,0 +1,15 @@
+-- Registro auditable de la liquidación de cada trade cerrado
+CREATE TABLE IF NOT EXISTS trade_settlements (
+    trade_id INTEGER PRIMARY KEY REFERENCES trades(id) ON DELETE CASCADE,
+    rule VARCHAR(20) NOT NULL,
+    price_source VARCHAR(20),
+    exit_price DECIMAL(18,8),
+    bid DECIMAL(18,8),
+    ask DECIMAL(18,8),
+    tick_at TIMESTAMP,
+    settled_at TIMESTAMP NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    profit DECIMAL(18,8),
+    note TEXT,
+    created_at TIMESTAMP DEFAULT NOW()
+);
@@ -0,0 +1,4 @@
+-- Trades demo abiertos anulados en cada reset
+ALTER TABLE demo_resets ADD COLUMN IF NOT EXISTS voided_trades INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_demo_resets_user_reset_at ON demo_resets(user_id, reset_at DESC);
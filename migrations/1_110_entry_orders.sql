@@ -0,0 +1,24 @@
+-- Órdenes de entrada condicionales: abren un trade cuando el precio cruza trigger_price.
+-- El monto se descuenta al crear la orden y se devuelve si vence, se cancela o se rechaza.
+CREATE TABLE IF NOT EXISTS entry_orders (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    symbol VARCHAR(20) NOT NULL,
+    direction VARCHAR(10) NOT NULL,
+    amount DECIMAL(18,8) NOT NULL,
+    duration INTEGER NOT NULL,
+    payout_percentage DECIMAL(8,4) NOT NULL,
+    is_demo BOOLEAN NOT NULL DEFAULT false,
+    trigger_price DECIMAL(18,8) NOT NULL,
+    trigger_condition VARCHAR(10) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    trade_id INTEGER REFERENCES trades(id),
+    fill_price DECIMAL(18,8),
+    reason TEXT,
+    valid_until TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    closed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_entry_orders_user ON entry_orders(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_entry_orders_pending ON entry_orders(status) WHERE status = 'pending';
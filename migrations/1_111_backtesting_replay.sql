@@ -0,0 +1,27 @@
+-- Reproducción de sesiones de backtesting: temporalidad, balance simulado y posición
+ALTER TABLE backtesting_sessions ADD COLUMN IF NOT EXISTS timeframe VARCHAR(10) NOT NULL DEFAULT '1m';
+ALTER TABLE backtesting_sessions ADD COLUMN IF NOT EXISTS initial_balance DECIMAL(18,8) NOT NULL DEFAULT 10000;
+ALTER TABLE backtesting_sessions ADD COLUMN IF NOT EXISTS balance DECIMAL(18,8) NOT NULL DEFAULT 10000;
+ALTER TABLE backtesting_sessions ADD COLUMN IF NOT EXISTS replay_time TIMESTAMP;
+ALTER TABLE backtesting_sessions ADD COLUMN IF NOT EXISTS finished_at TIMESTAMP;
+
+-- Trades simulados liquidados en una sesión (tiempos de mercado reproducidos)
+CREATE TABLE IF NOT EXISTS backtesting_trades (
+    id SERIAL PRIMARY KEY,
+    session_id INTEGER NOT NULL REFERENCES backtesting_sessions(id) ON DELETE CASCADE,
+    trade_no INTEGER NOT NULL,
+    direction VARCHAR(10) NOT NULL,
+    amount DECIMAL(18,8) NOT NULL,
+    entry_price DECIMAL(18,8) NOT NULL,
+    exit_price DECIMAL(18,8),
+    payout_percentage DECIMAL(8,4) NOT NULL,
+    duration INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    profit DECIMAL(18,8) NOT NULL DEFAULT 0,
+    opened_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    closed_at TIMESTAMP,
+    UNIQUE (session_id, trade_no)
+);
+
+CREATE INDEX IF NOT EXISTS idx_backtesting_trades_session ON backtesting_trades(session_id);
@@ -0,0 +1,13 @@
+-- Copy trading: réplica de trades de líderes y comisión sobre la ganancia de los seguidores
+ALTER TABLE copy_traders ADD COLUMN IF NOT EXISTS winning_trades INTEGER DEFAULT 0;
+ALTER TABLE copy_traders ADD COLUMN IF NOT EXISTS profit_share_earned DECIMAL(18,8) DEFAULT 0;
+
+-- fixed: copy_amount por trade; percentage: copy_percentage del monto del líder
+ALTER TABLE copy_relationships ADD COLUMN IF NOT EXISTS copy_mode VARCHAR(20) NOT NULL DEFAULT 'fixed';
+ALTER TABLE copy_relationships ADD COLUMN IF NOT EXISTS profit_share_paid DECIMAL(18,8) DEFAULT 0;
+
+-- settled_at: la comisión del trade copiado ya se cobró
+ALTER TABLE copy_trades ADD COLUMN IF NOT EXISTS settled_at TIMESTAMP;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_copy_trades_copied_trade_id ON copy_trades(copied_trade_id);
+CREATE INDEX IF NOT EXISTS idx_copy_relationships_active ON copy_relationships(trader_id) WHERE status = 'active';
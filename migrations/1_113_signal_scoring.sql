@@ -0,0 +1,24 @@
+-- Señales de trading: proveedores verificados y evaluación automática al vencer el timeframe
+CREATE TABLE IF NOT EXISTS signal_providers (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    display_name VARCHAR(100) NOT NULL,
+    bio TEXT,
+    is_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    verified_by INTEGER REFERENCES users(id),
+    verified_at TIMESTAMP,
+    total_signals INTEGER NOT NULL DEFAULT 0,
+    hits INTEGER NOT NULL DEFAULT 0,
+    misses INTEGER NOT NULL DEFAULT 0,
+    hit_rate DECIMAL(5,2) NOT NULL DEFAULT 0,
+    current_streak INTEGER NOT NULL DEFAULT 0,
+    best_streak INTEGER NOT NULL DEFAULT 0,
+    worst_streak INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+ALTER TABLE trading_signals ADD COLUMN IF NOT EXISTS exit_price DECIMAL(18,8);
+ALTER TABLE trading_signals ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_trading_signals_active ON trading_signals(expires_at) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_trading_signals_provider_created ON trading_signals(provider_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_signal_subscriptions_provider ON signal_subscriptions(provider_id) WHERE status = 'active';
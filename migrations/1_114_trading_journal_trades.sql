@@ -0,0 +1,4 @@
+-- Diario de trading: una entrada por trade y filtros por etiqueta
+CREATE UNIQUE INDEX IF NOT EXISTS idx_trading_journal_trade_unique ON trading_journal(trade_id) WHERE trade_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_trading_journal_tags ON trading_journal USING GIN (tags);
+CREATE INDEX IF NOT EXISTS idx_trading_journal_user_created ON trading_journal(user_id, created_at DESC);
@@ -0,0 +1,41 @@
+-- Estadísticas de usuario mantenidas al liquidar cada trade (cuenta real y demo por separado).
+-- trades.stats_recorded_at marca los trades ya sumados; el backfill procesa los demás.
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS stats_recorded_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_trades_stats_pending ON trades(closed_at, id)
+    WHERE stats_recorded_at IS NULL AND tournament_id IS NULL AND status IN ('won', 'lost', 'draw', 'sold');
+
+ALTER TABLE user_daily_statistics ADD COLUMN IF NOT EXISTS is_demo BOOLEAN NOT NULL DEFAULT FALSE;
+DROP INDEX IF EXISTS idx_user_daily_statistics_unique;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_daily_statistics_account ON user_daily_statistics(user_id, is_demo, date);
+
+ALTER TABLE user_symbol_statistics ADD COLUMN IF NOT EXISTS is_demo BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE user_symbol_statistics ADD COLUMN IF NOT EXISTS volume DECIMAL(18,8) DEFAULT 0;
+ALTER TABLE user_symbol_statistics ADD COLUMN IF NOT EXISTS gross_profit DECIMAL(18,8) DEFAULT 0;
+ALTER TABLE user_symbol_statistics ADD COLUMN IF NOT EXISTS gross_loss DECIMAL(18,8) DEFAULT 0;
+ALTER TABLE user_symbol_statistics ADD COLUMN IF NOT EXISTS best_trade DECIMAL(18,8) DEFAULT 0;
+ALTER TABLE user_symbol_statistics ADD COLUMN IF NOT EXISTS worst_trade DECIMAL(18,8) DEFAULT 0;
+DROP INDEX IF EXISTS idx_user_symbol_statistics_unique;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_symbol_statistics_account ON user_symbol_statistics(user_id, is_demo, symbol);
+
+-- Resultado por hora de apertura (UTC)
+CREATE TABLE IF NOT EXISTS user_hourly_statistics (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    is_demo BOOLEAN NOT NULL DEFAULT FALSE,
+    hour SMALLINT NOT NULL CHECK (hour >= 0 AND hour <= 23),
+    trades_count INTEGER NOT NULL DEFAULT 0,
+    won_count INTEGER NOT NULL DEFAULT 0,
+    lost_count INTEGER NOT NULL DEFAULT 0,
+    net DECIMAL(18,8) NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, is_demo, hour)
+);
+
+-- Rachas: current_streak positiva en ganadas consecutivas, negativa en perdidas
+CREATE TABLE IF NOT EXISTS user_trade_streaks (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    is_demo BOOLEAN NOT NULL DEFAULT FALSE,
+    current_streak INTEGER NOT NULL DEFAULT 0,
+    best_win_streak INTEGER NOT NULL DEFAULT 0,
+    worst_loss_streak INTEGER NOT NULL DEFAULT 0,
+    last_trade_at TIMESTAMP,
+    PRIMARY KEY (user_id, is_demo)
+);
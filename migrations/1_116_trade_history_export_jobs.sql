@@ -0,0 +1,15 @@
+-- Exportaciones de historial como trabajos en segundo plano: filtros por símbolo y
+-- cuenta, archivo generado en almacenamiento local y expiración
+ALTER TABLE trade_history_exports ADD COLUMN IF NOT EXISTS symbol VARCHAR(20);
+ALTER TABLE trade_history_exports ADD COLUMN IF NOT EXISTS is_demo BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE trade_history_exports ADD COLUMN IF NOT EXISTS file_path VARCHAR(500);
+ALTER TABLE trade_history_exports ADD COLUMN IF NOT EXISTS file_size BIGINT;
+ALTER TABLE trade_history_exports ADD COLUMN IF NOT EXISTS rows_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE trade_history_exports ADD COLUMN IF NOT EXISTS error_message TEXT;
+ALTER TABLE trade_history_exports ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_trade_history_exports_queue ON trade_history_exports(created_at, id)
+    WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_trade_history_exports_expiry ON trade_history_exports(expires_at)
+    WHERE status = 'ready';
+CREATE INDEX IF NOT EXISTS idx_trades_user_closed ON trades(user_id, closed_at);
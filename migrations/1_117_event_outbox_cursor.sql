@@ -0,0 +1,13 @@
+-- Cursor de entrega por suscriptor: Pending solo recorre los eventos posteriores al
+-- último id con todo lo anterior entregado o descartado
+ALTER TABLE event_subscribers ADD COLUMN IF NOT EXISTS delivered_through BIGINT NOT NULL DEFAULT 0;
+
+-- Suscriptores existentes: el cursor arranca en el último evento anterior a su registro
+UPDATE event_subscribers s
+SET delivered_through = COALESCE(
+    (SELECT MAX(e.id) FROM event_outbox e WHERE e.created_at < s.created_at), 0)
+WHERE s.delivered_through = 0;
+
+-- (created_at) se indexa en 1_107 y (subscriber, event_id) está cubierto por la clave
+-- primaria de event_deliveries; event_id sirve al borrado en cascada al limpiar el outbox
+CREATE INDEX IF NOT EXISTS idx_event_deliveries_event ON event_deliveries(event_id);
@@ -0,0 +1,56 @@
+-- Catálogo de activos del operador (usado por /operator/trading-assets y la resolución de payout)
+CREATE TABLE IF NOT EXISTS operator_asset_categories (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    slug VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT,
+    icon VARCHAR(100),
+    display_order INTEGER DEFAULT 0,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_by INTEGER REFERENCES operators(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS operator_trading_assets (
+    id SERIAL PRIMARY KEY,
+    symbol VARCHAR(20) UNIQUE NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    category_id INTEGER REFERENCES operator_asset_categories(id),
+    asset_type VARCHAR(20) NOT NULL,
+    base_currency VARCHAR(10),
+    quote_currency VARCHAR(10),
+    min_trade_amount DECIMAL(18,8) DEFAULT 1,
+    max_trade_amount DECIMAL(18,8) DEFAULT 10000,
+    min_duration_seconds INTEGER DEFAULT 30,
+    max_duration_seconds INTEGER DEFAULT 3600,
+    payout_percentage DECIMAL(5,2) DEFAULT 85,
+    spread DECIMAL(10,5) DEFAULT 0,
+    is_active BOOLEAN DEFAULT TRUE,
+    is_featured BOOLEAN DEFAULT FALSE,
+    risk_level VARCHAR(20) DEFAULT 'medium',
+    volatility_index DECIMAL(10,4),
+    icon_url VARCHAR(500),
+    created_by INTEGER REFERENCES operators(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- condition_type soportados por el resolver de payout:
+--   duration: {"min_seconds": 60, "max_seconds": 300}
+--   amount:   {"min": 100, "max": 1000}
+CREATE TABLE IF NOT EXISTS operator_asset_payout_rules (
+    id SERIAL PRIMARY KEY,
+    asset_id INTEGER REFERENCES operator_trading_assets(id) ON DELETE CASCADE,
+    rule_name VARCHAR(100) NOT NULL,
+    condition_type VARCHAR(50) NOT NULL,
+    condition_value JSONB NOT NULL DEFAULT '{}',
+    payout_adjustment DECIMAL(5,2) NOT NULL,
+    is_active BOOLEAN DEFAULT TRUE,
+    priority INTEGER DEFAULT 0,
+    created_by INTEGER REFERENCES operators(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_operator_trading_assets_symbol ON operator_trading_assets(symbol);
+CREATE INDEX IF NOT EXISTS idx_operator_asset_payout_rules_asset ON operator_asset_payout_rules(asset_id);
+CREATE INDEX IF NOT EXISTS idx_operation_payout_config_symbol_duration ON operation_payout_config(symbol, duration_seconds);
@@ -0,0 +1,24 @@
+-- Sobrescrituras de límites de trading por usuario (usado por /operator/trade-limit-overrides)
+-- limit_type aplicados por la validación pre-trade:
+--   min_trade_amount, max_trade_amount, max_open_trades, daily_trade_volume
+CREATE TABLE IF NOT EXISTS trade_limits_override (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    operator_id INTEGER REFERENCES operators(id),
+    limit_type VARCHAR(50) NOT NULL,
+    original_limit DECIMAL(18,8),
+    new_limit DECIMAL(18,8) NOT NULL,
+    reason TEXT,
+    is_active BOOLEAN DEFAULT TRUE,
+    starts_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP,
+    approved_by INTEGER REFERENCES operators(id),
+    approved_at TIMESTAMP,
+    deactivated_at TIMESTAMP,
+    deactivated_by INTEGER REFERENCES operators(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_trade_limits_override_user ON trade_limits_override(user_id);
+CREATE INDEX IF NOT EXISTS idx_trade_limits_override_active ON trade_limits_override(is_active);
+CREATE INDEX IF NOT EXISTS idx_user_trading_blocks_user_active ON user_trading_blocks(user_id, is_active);
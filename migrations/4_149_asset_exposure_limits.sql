@@ -0,0 +1,2 @@
+-- Exposición neta máxima (stake up - stake down abierto) por activo; NULL = sin límite
+ALTER TABLE operator_trading_assets ADD COLUMN IF NOT EXISTS max_net_exposure DECIMAL(18,8);
@@ -0,0 +1,18 @@
+-- Payout por tipo de opción y activo; sin fila el activo no ofrece ese tipo de opción.
+-- Va después de 4_147 (operator_trading_assets) y 4_001 (operators).
+CREATE TABLE IF NOT EXISTS operator_asset_option_payouts (
+    id SERIAL PRIMARY KEY,
+    asset_id INTEGER REFERENCES operator_trading_assets(id) ON DELETE CASCADE,
+    option_type VARCHAR(20) NOT NULL,
+    payout_percentage DECIMAL(6,2) NOT NULL,
+    -- Distancia permitida entre cada barrera y el precio de entrada, en % del precio
+    min_barrier_distance DECIMAL(8,4) NOT NULL DEFAULT 0.05,
+    max_barrier_distance DECIMAL(8,4) NOT NULL DEFAULT 2,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_by INTEGER REFERENCES operators(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_operator_asset_option_payouts_unique ON operator_asset_option_payouts(asset_id, option_type);
+
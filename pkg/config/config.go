@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -16,6 +17,23 @@ type Config struct {
 	DBName     string
 	ServerPort string
 	JWTSecret  string
+
+	// Cuenta demo
+	DemoBalance            float64 // Balance inicial y tras un reset
+	DemoResetCooldownHours int     // Espera mínima entre resets
+
+	// Exportaciones de historial
+	ExportDir            string // Directorio local de los archivos generados
+	ExportRetentionHours int    // Horas que se conserva cada archivo
+
+	// Feeds de precios
+	PriceFeedDefault        string            // Feed de los símbolos sin asignación (simulated, binance, rest, replay)
+	PriceFeedSymbols        map[string]string // Símbolo -> feed (PRICE_FEED_SYMBOLS=BTC/USDT=binance,EUR/USD=rest)
+	PriceFeedWSURL          string            // Base del WebSocket estilo Binance
+	PriceFeedRESTURL        string            // Base de la API REST estilo Binance
+	PriceFeedRESTIntervalMs int               // Intervalo de consulta del feed REST
+	PriceFeedReplayFile     string            // CSV del feed replay (timestamp,symbol,price[,bid,ask])
+	PriceFeedReplaySpeed    float64           // Velocidad de reproducción (1 = tiempo real)
 }
 
 // Cargade fichero .env silenciosamente
@@ -36,6 +54,20 @@ func Load() *Config {
 		DBName:     getEnv("DB_NAME", "tormentus_dev"),
 		ServerPort: getEnv("SERVER_PORT", "8080"),
 		JWTSecret:  getEnv("JWT_SECRET", "placeholder-secret-change-this-in-env"),
+
+		DemoBalance:            getEnvAsFloat("DEMO_BALANCE", 10000),
+		DemoResetCooldownHours: getEnvAsInt("DEMO_RESET_COOLDOWN_HOURS", 24),
+
+		ExportDir:            getEnv("EXPORT_DIR", "./storage/exports"),
+		ExportRetentionHours: getEnvAsInt("EXPORT_RETENTION_HOURS", 24),
+
+		PriceFeedDefault:        getEnv("PRICE_FEED_DEFAULT", "simulated"),
+		PriceFeedSymbols:        getEnvAsMap("PRICE_FEED_SYMBOLS"),
+		PriceFeedWSURL:          getEnv("PRICE_FEED_WS_URL", "wss://stream.binance.com:9443"),
+		PriceFeedRESTURL:        getEnv("PRICE_FEED_REST_URL", "https://api.binance.com"),
+		PriceFeedRESTIntervalMs: getEnvAsInt("PRICE_FEED_REST_INTERVAL_MS", 1000),
+		PriceFeedReplayFile:     getEnv("PRICE_FEED_REPLAY_FILE", ""),
+		PriceFeedReplaySpeed:    getEnvAsFloat("PRICE_FEED_REPLAY_SPEED", 1),
 	}
 }
 
@@ -57,6 +89,29 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	fmt.Printf("Variable %s no encontrada, usando valor por defecto: %.2f\n", key, defaultValue)
+	return defaultValue
+}
+
+// getEnvAsMap lee una lista clave=valor separada por comas (ej. BTC/USDT=binance,EUR/USD=rest)
+func getEnvAsMap(key string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if ok && k != "" && v != "" {
+			result[k] = v
+		}
+	}
+	return result
+}
+
 func (c *Config) Validate() error {
 	if c.DBHost == "" {
 		return fmt.Errorf("DB_HOST no puede estar vacio")
@@ -73,6 +128,24 @@ func (c *Config) Validate() error {
 	if c.DBName == "" {
 		return fmt.Errorf("DB_NAME no puede estar vacio")
 	}
+	if c.DemoBalance <= 0 {
+		return fmt.Errorf("DEMO_BALANCE debe ser mayor que 0")
+	}
+	if c.DemoResetCooldownHours < 0 {
+		return fmt.Errorf("DEMO_RESET_COOLDOWN_HOURS no puede ser negativo")
+	}
+	if c.ExportDir == "" {
+		return fmt.Errorf("EXPORT_DIR no puede estar vacio")
+	}
+	if c.ExportRetentionHours <= 0 {
+		return fmt.Errorf("EXPORT_RETENTION_HOURS debe ser mayor que 0")
+	}
+	if c.PriceFeedRESTIntervalMs <= 0 {
+		return fmt.Errorf("PRICE_FEED_REST_INTERVAL_MS debe ser mayor que 0")
+	}
+	if c.PriceFeedReplaySpeed <= 0 {
+		return fmt.Errorf("PRICE_FEED_REPLAY_SPEED debe ser mayor que 0")
+	}
 	if c.JWTSecret == "" || len(c.JWTSecret) < 32 {
 		return fmt.Errorf("JWT_SECRET debe tener al menos 32 caracteres")
 	}